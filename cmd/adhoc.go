@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Gu1llaum-3/sshm/internal/adhoc"
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/history"
+	"github.com/Gu1llaum-3/sshm/internal/hook"
+	"github.com/Gu1llaum-3/sshm/internal/validation"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// connectToTarget connects to a target that is not in the SSH config, given
+// as user@host[:port] or ssh://[user@]host[:port]. Once an interactive session
+// ends, it offers to save the target as a new host.
+func connectToTarget(target adhoc.Target, remoteCommand []string) {
+	name := target.String()
+
+	// Only the global hooks apply, the target has no tags
+	var hooks hook.Hooks
+	host := target.SSHHost(name)
+	if settings, ok := loadHookSettings(); ok {
+		hooks = hook.ForHost(settings, host)
+	}
+	if err := hooks.RunPreConnect(host, hook.Terminal()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if historyManager, err := history.NewHistoryManager(); err != nil {
+		fmt.Printf("Warning: Could not initialize connection history: %v\n", err)
+	} else if err := historyManager.RecordConnection(name); err != nil {
+		fmt.Printf("Warning: Could not record connection history: %v\n", err)
+	}
+
+	args := targetSSHArgs(target, remoteCommand)
+	record := shouldRecord(name)
+	if len(remoteCommand) == 0 {
+		if record {
+			fmt.Printf("Connecting to %s (recording)...\n", name)
+		} else {
+			fmt.Printf("Connecting to %s...\n", name)
+		}
+	}
+
+	exitCode := runSession(name, args, hooks, host)
+
+	// Offer to save the target once a session was opened on it
+	if len(remoteCommand) == 0 && exitCode != sshExitError && term.IsTerminal(os.Stdin.Fd()) {
+		hosts, err := loadHosts()
+		if err == nil {
+			if newHost, ok := promptSaveTarget(os.Stdin, os.Stdout, target, hosts); ok {
+				if err := saveHost(newHost); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: could not save host: %v\n", err)
+				} else {
+					fmt.Printf("Saved as '%s'. Connect next time with 'sshm %s'.\n", newHost.Name, newHost.Name)
+				}
+			}
+		}
+	}
+	os.Exit(exitCode)
+}
+
+// targetSSHArgs returns the ssh arguments connecting to target and running remoteCommand
+func targetSSHArgs(target adhoc.Target, remoteCommand []string) []string {
+	var args []string
+	if configFile != "" {
+		args = append(args, "-F", configFile)
+	}
+	if forceTTY {
+		args = append(args, "-t")
+	}
+	args = append(args, target.Args()...)
+	return append(args, remoteCommand...)
+}
+
+// promptSaveTarget asks whether to save target as a host of the SSH config,
+// and under which alias, suggesting one none of hosts uses
+func promptSaveTarget(in io.Reader, out io.Writer, target adhoc.Target, hosts []config.SSHHost) (config.SSHHost, bool) {
+	reader := bufio.NewReader(in)
+
+	fmt.Fprintf(out, "Save %s as a new host? [y/N]: ", target)
+	response, _ := reader.ReadString('\n')
+	response = strings.ToLower(strings.TrimSpace(response))
+	if response != "y" && response != "yes" {
+		return config.SSHHost{}, false
+	}
+
+	suggested := target.SuggestAlias(hosts)
+	for {
+		fmt.Fprintf(out, "Alias [%s]: ", suggested)
+		alias, err := reader.ReadString('\n')
+		alias = strings.TrimSpace(alias)
+		if alias == "" {
+			if err != nil {
+				return config.SSHHost{}, false
+			}
+			alias = suggested
+		}
+
+		switch {
+		case !validation.ValidateHostName(alias):
+			fmt.Fprintf(out, "Invalid alias '%s'.\n", alias)
+		case hostExists(hosts, alias):
+			fmt.Fprintf(out, "Host '%s' already exists.\n", alias)
+		default:
+			return target.SSHHost(alias), true
+		}
+		if err != nil {
+			return config.SSHHost{}, false
+		}
+	}
+}
+
+// hostExists reports whether one of hosts is called name
+func hostExists(hosts []config.SSHHost, name string) bool {
+	for _, host := range hosts {
+		if host.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// saveHost adds host to the SSH config in use
+func saveHost(host config.SSHHost) error {
+	if configFile != "" {
+		return config.AddSSHHostToFile(host, configFile)
+	}
+	return config.AddSSHHost(host)
+}
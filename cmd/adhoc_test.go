@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/adhoc"
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+func TestTargetSSHArgs(t *testing.T) {
+	oldConfig, oldTTY := configFile, forceTTY
+	defer func() { configFile, forceTTY = oldConfig, oldTTY }()
+
+	target := adhoc.Target{User: "deploy", Host: "10.0.0.5", Port: "2222"}
+
+	configFile, forceTTY = "", false
+	if got := strings.Join(targetSSHArgs(target, nil), " "); got != "-p 2222 -l deploy -- 10.0.0.5" {
+		t.Errorf("targetSSHArgs() = %q", got)
+	}
+
+	configFile, forceTTY = "/tmp/config", true
+	got := strings.Join(targetSSHArgs(target, []string{"uptime"}), " ")
+	if got != "-F /tmp/config -t -p 2222 -l deploy -- 10.0.0.5 uptime" {
+		t.Errorf("targetSSHArgs() = %q", got)
+	}
+}
+
+func TestPromptSaveTarget(t *testing.T) {
+	target := adhoc.Target{User: "deploy", Host: "web.example.com", Port: "2222"}
+	hosts := []config.SSHHost{{Name: "web"}, {Name: "db"}}
+
+	var out bytes.Buffer
+	if _, ok := promptSaveTarget(strings.NewReader("\n"), &out, target, hosts); ok {
+		t.Error("Expected the target not to be saved by default")
+	}
+
+	out.Reset()
+	host, ok := promptSaveTarget(strings.NewReader("y\n\n"), &out, target, hosts)
+	if !ok {
+		t.Fatal("Expected the target to be saved")
+	}
+	if host.Name != "web-2" || host.Hostname != "web.example.com" || host.User != "deploy" || host.Port != "2222" {
+		t.Errorf("promptSaveTarget() = %+v", host)
+	}
+	if !strings.Contains(out.String(), "Alias [web-2]") {
+		t.Errorf("Expected the suggested alias in the prompt, got %q", out.String())
+	}
+
+	out.Reset()
+	host, ok = promptSaveTarget(strings.NewReader("yes\ndb\nfront\n"), &out, target, hosts)
+	if !ok || host.Name != "front" {
+		t.Errorf("promptSaveTarget() = %+v, %v, want the alias 'front'", host, ok)
+	}
+	if !strings.Contains(out.String(), "Host 'db' already exists") {
+		t.Errorf("Expected a taken alias to be refused, got %q", out.String())
+	}
+
+	if _, ok := promptSaveTarget(strings.NewReader("y\nbad alias"), &out, target, hosts); ok {
+		t.Error("Expected an invalid alias at the end of input not to be saved")
+	}
+}
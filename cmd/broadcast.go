@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Gu1llaum-3/sshm/internal/history"
+	"github.com/Gu1llaum-3/sshm/internal/ui"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// broadcastTags selects hosts carrying one of these tags
+	broadcastTags []string
+	// broadcastFilter selects hosts matching this search query
+	broadcastFilter string
+)
+
+var broadcastCmd = &cobra.Command{
+	Use:   "broadcast [host...]",
+	Short: "Open interactive sessions to several hosts and type into all of them",
+	Long: `Open interactive sessions to several hosts and type into all of them at once,
+like cssh or tmux synchronize-panes.
+
+Each session runs ssh in its own pane. Keystrokes go to every session, or to
+the focused one only. Commands are typed after the ctrl+b prefix:
+
+  ctrl+b tab/n   focus the next session
+  ctrl+b p       focus the previous session
+  ctrl+b a       toggle between typing to all sessions and the focused one
+  ctrl+b q       close all sessions
+  ctrl+b ctrl+b  send ctrl+b to the sessions
+
+Broadcast sessions are also available in the interactive mode: mark hosts
+with space, press B for the bulk actions and choose "Open a broadcast session".
+
+Examples:
+  sshm broadcast web-1 web-2
+  sshm broadcast --tag web`,
+	Args:              cobra.ArbitraryArgs,
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	ValidArgsFunction: completeHostNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && len(broadcastTags) == 0 && broadcastFilter == "" {
+			fmt.Fprintln(os.Stderr, "Error: specify hosts, or select them with --tag or --filter")
+			os.Exit(2)
+		}
+
+		hosts, err := loadHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading SSH config file: %v\n", err)
+			os.Exit(2)
+		}
+
+		selected, err := selectHosts(hosts, args, broadcastTags, broadcastFilter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		if len(selected) == 0 {
+			fmt.Fprintln(os.Stderr, "No hosts matched.")
+			os.Exit(2)
+		}
+
+		historyManager, err := history.NewHistoryManager()
+		if err != nil {
+			fmt.Printf("Warning: Could not initialize connection history: %v\n", err)
+		} else {
+			for _, host := range selected {
+				if err := historyManager.RecordConnection(host.Name); err != nil {
+					fmt.Printf("Warning: Could not record connection history: %v\n", err)
+				}
+			}
+		}
+
+		if err := ui.RunBroadcast(selected, configFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	broadcastCmd.Flags().StringSliceVar(&broadcastTags, "tag", nil, "Open sessions to hosts with this tag (repeatable)")
+	broadcastCmd.Flags().StringVar(&broadcastFilter, "filter", "", "Open sessions to hosts matching this search query")
+	RootCmd.AddCommand(broadcastCmd)
+}
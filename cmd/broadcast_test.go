@@ -0,0 +1,16 @@
+package cmd
+
+import "testing"
+
+func TestBroadcastCommandFlags(t *testing.T) {
+	if broadcastCmd.Use != "broadcast [host...]" {
+		t.Errorf("Expected Use 'broadcast [host...]', got '%s'", broadcastCmd.Use)
+	}
+
+	flags := broadcastCmd.Flags()
+	for _, name := range []string{"tag", "filter"} {
+		if flags.Lookup(name) == nil {
+			t.Errorf("Expected --%s flag to be defined", name)
+		}
+	}
+}
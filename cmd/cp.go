@@ -0,0 +1,346 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// cpRecursive copies directories recursively
+	cpRecursive bool
+	// cpTags fans the copy out to hosts carrying one of these tags
+	cpTags []string
+	// cpFilter fans the copy out to hosts matching this search query
+	cpFilter string
+	// cpParallel is the maximum number of hosts copied to or from at once
+	cpParallel int
+)
+
+// copyTarget is a source or destination of a copy: a local path, a path on a
+// host, or a path on each host selected with --tag/--filter (":path")
+type copyTarget struct {
+	host string // host name, with an optional user@ prefix
+	path string
+	each bool // the path is on each selected host
+}
+
+func (t copyTarget) remote() bool {
+	return t.host != "" || t.each
+}
+
+// onHost returns the target with each selected host replaced by hostName
+func (t copyTarget) onHost(hostName string) copyTarget {
+	if t.each {
+		return copyTarget{host: hostName, path: t.path}
+	}
+	return t
+}
+
+// String formats the target the way scp expects it
+func (t copyTarget) String() string {
+	if t.host == "" {
+		return t.path
+	}
+	return t.host + ":" + t.path
+}
+
+// hostName returns the host of the target without its user@ prefix
+func (t copyTarget) hostName() string {
+	if i := strings.LastIndex(t.host, "@"); i >= 0 {
+		return t.host[i+1:]
+	}
+	return t.host
+}
+
+// parseCopyTarget parses "host:path", "user@host:path", ":path" or a local path.
+// Like scp, a colon after a slash belongs to a local path.
+func parseCopyTarget(arg string) copyTarget {
+	i := strings.Index(arg, ":")
+	if i < 0 || strings.ContainsAny(arg[:i], `/\`) {
+		return copyTarget{path: arg}
+	}
+	// Windows drive letters, e.g. C:\Users
+	if runtime.GOOS == "windows" && i == 1 {
+		return copyTarget{path: arg}
+	}
+	if i == 0 {
+		return copyTarget{path: arg[1:], each: true}
+	}
+	return copyTarget{host: arg[:i], path: arg[i+1:]}
+}
+
+// copySCPCommand builds the scp command, replaced in tests
+var copySCPCommand = func(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "scp", args...)
+}
+
+var cpCmd = &cobra.Command{
+	Use:   "cp <source>... <destination>",
+	Short: "Copy files to and from hosts",
+	Long: `Copy files to and from hosts with scp, using host:path targets resolved through
+the sshm SSH config (including --config and ProxyJump).
+
+With --tag or --filter, a target written ':path' stands for that path on each
+selected host, and the copy runs on all of them in parallel. When downloading
+from several hosts, each host's files are saved under <destination>/<host>/.
+
+The command exits with status 1 if a copy failed.
+
+Examples:
+  sshm cp ./app.conf web-1:/etc/app/          # Upload a file
+  sshm cp -r web-1:/var/log/nginx ./logs      # Download a directory
+  sshm cp ./app.conf --tag web :/etc/app/     # Upload to every host tagged "web"
+  sshm cp --tag web :/var/log/syslog ./logs   # Download into ./logs/<host>/`,
+	Args:              cobra.MinimumNArgs(2),
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	ValidArgsFunction: completeCopyTargets,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var sources []copyTarget
+		for _, arg := range args[:len(args)-1] {
+			sources = append(sources, parseCopyTarget(arg))
+		}
+		dst := parseCopyTarget(args[len(args)-1])
+
+		hosts, err := loadHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading SSH config file: %v\n", err)
+			os.Exit(2)
+		}
+
+		fanOut := len(cpTags) > 0 || cpFilter != ""
+		if err := checkCopyTargets(hosts, sources, dst, fanOut); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		if !fanOut {
+			os.Exit(runCopy(cmd.Context(), sources, dst))
+		}
+
+		selected, err := selectHosts(hosts, nil, cpTags, cpFilter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		if len(selected) == 0 {
+			fmt.Fprintln(os.Stderr, "No hosts matched.")
+			os.Exit(2)
+		}
+
+		exitCode := runCopyFanOut(cmd.Context(), cmd.OutOrStdout(), selected, sources, dst, cpParallel)
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		return nil
+	},
+}
+
+// checkCopyTargets makes sure a copy involves a host and that named hosts exist
+func checkCopyTargets(hosts []config.SSHHost, sources []copyTarget, dst copyTarget, fanOut bool) error {
+	known := make(map[string]bool)
+	for _, host := range hosts {
+		known[host.Name] = true
+	}
+
+	each, remote := false, false
+	for _, target := range append(append([]copyTarget(nil), sources...), dst) {
+		if target.each {
+			each = true
+		}
+		if target.remote() {
+			remote = true
+		}
+		if target.host != "" && !known[target.hostName()] {
+			return fmt.Errorf("host '%s' not found in SSH configuration", target.hostName())
+		}
+	}
+
+	switch {
+	case !remote:
+		return errors.New("no remote target, use host:path for the source or the destination")
+	case each && !fanOut:
+		return errors.New("':path' targets need --tag or --filter to select the hosts")
+	case fanOut && !each:
+		return errors.New("--tag and --filter need a ':path' target standing for each selected host")
+	}
+	return nil
+}
+
+// scpArgs returns the scp arguments to copy sources to dst
+func scpArgs(sources []copyTarget, dst copyTarget, batch bool) []string {
+	var args []string
+	if configFile != "" {
+		args = append(args, "-F", configFile)
+	}
+	if cpRecursive {
+		args = append(args, "-r")
+	}
+	if batch {
+		// No progress meter or prompts when copying with several hosts at once
+		args = append(args, "-q", "-o", "BatchMode=yes")
+	}
+
+	remoteSources := 0
+	for _, source := range sources {
+		if source.remote() {
+			remoteSources++
+		}
+	}
+	if dst.remote() && remoteSources > 0 {
+		// Copy between hosts through this machine, which knows how to reach both
+		args = append(args, "-3")
+	}
+
+	for _, source := range sources {
+		args = append(args, source.String())
+	}
+	return append(args, dst.String())
+}
+
+// runCopy runs a single scp attached to the terminal, so that it shows its progress meter,
+// and returns its exit code
+func runCopy(ctx context.Context, sources []copyTarget, dst copyTarget) int {
+	scp := copySCPCommand(ctx, scpArgs(sources, dst, false)...)
+	scp.Stdin = os.Stdin
+	scp.Stdout = os.Stdout
+	scp.Stderr = os.Stderr
+
+	if err := scp.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		fmt.Fprintf(os.Stderr, "Error executing scp: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// copyResult is the outcome of a copy with one host
+type copyResult struct {
+	host     string
+	err      error
+	duration time.Duration
+}
+
+// runCopyFanOut copies to or from each host, at most parallel at once, reports each
+// completion as it happens and returns the exit code: 0 when every copy succeeded, 1 otherwise
+func runCopyFanOut(ctx context.Context, out io.Writer, hosts []config.SSHHost, sources []copyTarget, dst copyTarget, parallel int) int {
+	if parallel <= 0 {
+		parallel = 1
+	}
+
+	// Downloads from several hosts go to a directory per host
+	download := !dst.remote()
+
+	var outMutex sync.Mutex
+	completed := 0
+	failed := 0
+	limit := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(host config.SSHHost) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			hostSources := make([]copyTarget, len(sources))
+			for i, source := range sources {
+				hostSources[i] = source.onHost(host.Name)
+			}
+			hostDst := dst.onHost(host.Name)
+
+			result := copyResult{host: host.Name}
+			start := time.Now()
+			if download {
+				hostDst.path = filepath.Join(dst.path, host.Name)
+				result.err = os.MkdirAll(hostDst.path, 0755)
+			}
+			if result.err == nil {
+				result.err = copyWithHost(ctx, hostSources, hostDst)
+			}
+			result.duration = time.Since(start)
+
+			outMutex.Lock()
+			defer outMutex.Unlock()
+			completed++
+			if result.err != nil {
+				failed++
+				fmt.Fprintf(out, "[%d/%d] ✗ %s  %v\n", completed, len(hosts), result.host, result.err)
+			} else {
+				fmt.Fprintf(out, "[%d/%d] ✓ %s  %s\n", completed, len(hosts), result.host, formatLatency(result.duration))
+			}
+		}(host)
+	}
+	wg.Wait()
+
+	fmt.Fprintf(out, "\n%d/%d hosts succeeded\n", len(hosts)-failed, len(hosts))
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// copyWithHost runs scp non-interactively and turns a failure into an error
+// carrying the last line scp printed
+func copyWithHost(ctx context.Context, sources []copyTarget, dst copyTarget) error {
+	var stderr bytes.Buffer
+	scp := copySCPCommand(ctx, scpArgs(sources, dst, true)...)
+	scp.Stderr = &stderr
+
+	if err := scp.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			lines := strings.Split(message, "\n")
+			return errors.New(strings.TrimSpace(lines[len(lines)-1]))
+		}
+		return err
+	}
+	return nil
+}
+
+// completeCopyTargets completes host names followed by ':', and local paths when no host matches
+func completeCopyTargets(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if strings.Contains(toComplete, ":") || strings.ContainsAny(toComplete, `/\`) {
+		// Remote paths are not completed, local ones are left to the shell
+		if parseCopyTarget(toComplete).remote() {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	hosts, _ := completeHostNames(cmd, args, toComplete)
+	if len(hosts) == 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+
+	completions := make([]string, len(hosts))
+	for i, host := range hosts {
+		completions[i] = host + ":"
+	}
+	return completions, cobra.ShellCompDirectiveNoSpace
+}
+
+func init() {
+	cpCmd.Flags().BoolVarP(&cpRecursive, "recursive", "r", false, "Copy directories recursively")
+	cpCmd.Flags().StringSliceVar(&cpTags, "tag", nil, "Copy to or from each host with this tag, for ':path' targets (repeatable)")
+	cpCmd.Flags().StringVar(&cpFilter, "filter", "", "Copy to or from each host matching this search query, for ':path' targets")
+	cpCmd.Flags().IntVarP(&cpParallel, "parallel", "p", 10, "Maximum number of hosts copied to or from at once")
+	RootCmd.AddCommand(cpCmd)
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+func TestCpCommandFlags(t *testing.T) {
+	if cpCmd.Use != "cp <source>... <destination>" {
+		t.Errorf("Expected Use 'cp <source>... <destination>', got '%s'", cpCmd.Use)
+	}
+
+	flags := cpCmd.Flags()
+	for _, name := range []string{"recursive", "tag", "filter", "parallel"} {
+		if flags.Lookup(name) == nil {
+			t.Errorf("Expected --%s flag to be defined", name)
+		}
+	}
+}
+
+func TestParseCopyTarget(t *testing.T) {
+	tests := []struct {
+		arg      string
+		expected copyTarget
+	}{
+		{"./file.txt", copyTarget{path: "./file.txt"}},
+		{"web-1:/etc/hosts", copyTarget{host: "web-1", path: "/etc/hosts"}},
+		{"root@web-1:", copyTarget{host: "root@web-1", path: ""}},
+		{":/var/log/syslog", copyTarget{path: "/var/log/syslog", each: true}},
+		{"./dir:with:colons", copyTarget{path: "./dir:with:colons"}},
+	}
+
+	for _, tt := range tests {
+		if got := parseCopyTarget(tt.arg); got != tt.expected {
+			t.Errorf("parseCopyTarget(%q) = %+v, expected %+v", tt.arg, got, tt.expected)
+		}
+	}
+}
+
+func TestCheckCopyTargets(t *testing.T) {
+	hosts := []config.SSHHost{{Name: "web-1"}}
+	local := copyTarget{path: "./file"}
+
+	if err := checkCopyTargets(hosts, []copyTarget{local}, copyTarget{host: "root@web-1", path: "/tmp"}, false); err != nil {
+		t.Errorf("Expected a copy to a known host to be valid, got %v", err)
+	}
+	if err := checkCopyTargets(hosts, []copyTarget{local}, copyTarget{host: "db", path: "/tmp"}, false); err == nil {
+		t.Error("Expected an error for an unknown host")
+	}
+	if err := checkCopyTargets(hosts, []copyTarget{local}, copyTarget{path: "./other"}, false); err == nil {
+		t.Error("Expected an error for a copy without a remote target")
+	}
+	if err := checkCopyTargets(hosts, []copyTarget{local}, copyTarget{path: "/tmp", each: true}, false); err == nil {
+		t.Error("Expected an error for a ':path' target without --tag or --filter")
+	}
+	if err := checkCopyTargets(hosts, []copyTarget{local}, copyTarget{host: "web-1", path: "/tmp"}, true); err == nil {
+		t.Error("Expected an error for --tag without a ':path' target")
+	}
+}
+
+func TestScpArgs(t *testing.T) {
+	originalConfig, originalRecursive := configFile, cpRecursive
+	t.Cleanup(func() { configFile, cpRecursive = originalConfig, originalRecursive })
+	configFile, cpRecursive = "/tmp/ssh_config", true
+
+	got := scpArgs([]copyTarget{{host: "a", path: "/x"}}, copyTarget{host: "b", path: "/y"}, true)
+	expected := []string{"-F", "/tmp/ssh_config", "-r", "-q", "-o", "BatchMode=yes", "-3", "a:/x", "b:/y"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
+
+func TestRunCopyFanOut(t *testing.T) {
+	original := copySCPCommand
+	t.Cleanup(func() { copySCPCommand = original })
+	// The last two arguments are the source and the destination
+	copySCPCommand = func(ctx context.Context, args ...string) *exec.Cmd {
+		script := `for last; do :; done; case "$*" in *bad:*) echo "scp: /var/log/app.log: No such file" >&2; exit 1;; esac; echo ok > "$last/app.log"`
+		return exec.CommandContext(ctx, "sh", append([]string{"-c", script, "scp"}, args...)...)
+	}
+
+	dir := t.TempDir()
+	hosts := []config.SSHHost{{Name: "web-1"}, {Name: "bad"}}
+
+	var buf bytes.Buffer
+	exitCode := runCopyFanOut(context.Background(), &buf, hosts, []copyTarget{{path: "/var/log/app.log", each: true}}, copyTarget{path: dir}, 2)
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1 when a host fails, got %d", exitCode)
+	}
+
+	output := buf.String()
+	for _, expected := range []string{"✓ web-1", "✗ bad  scp: /var/log/app.log: No such file", "1/2 hosts succeeded"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in output:\n%s", expected, output)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "web-1", "app.log")); err != nil {
+		t.Errorf("Expected the download to be saved in a directory per host: %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// doctorJSON outputs the diagnosis as JSON
+	doctorJSON bool
+	// doctorTimeout is the timeout for the whole diagnosis
+	doctorTimeout time.Duration
+)
+
+type doctorResponse struct {
+	Schema string           `json:"schema"`
+	OK     bool             `json:"ok"`
+	Host   string           `json:"host"`
+	Steps  []doctorJSONStep `json:"steps"`
+}
+
+type doctorJSONStep struct {
+	Step       string  `json:"step"`
+	Target     *string `json:"target"`
+	Status     string  `json:"status"`
+	DurationMs int64   `json:"duration_ms"`
+	Detail     *string `json:"detail"`
+	Error      *string `json:"error"`
+	Reason     *string `json:"reason"`
+	Fix        *string `json:"fix"`
+}
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor <host>",
+	Short: "Diagnose the SSH connection to a host step by step",
+	Long: `Diagnose the SSH connection to a host step by step.
+
+Each stage of the connection is checked in turn and reported as pass, fail or
+skip with its timing and, on failure, a suggested fix:
+
+  jump     each ProxyJump hop, authenticated with the agent or identity files
+  dns      resolution of the hostname (all A/AAAA records)
+  tcp      connection to each resolved address
+  banner   the SSH identification string sent by the server
+  kex      key exchange, and the host key checked against known_hosts
+  auth     authentication with the SSH agent or identity files
+
+The command exits with status 1 if any step failed, and 2 if the host is not found.
+
+Examples:
+  sshm doctor web-1          # Diagnose web-1
+  sshm doctor web-1 --json   # Output the diagnosis as JSON`,
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	ValidArgsFunction: completeHostNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var host *config.SSHHost
+		var err error
+		if configFile != "" {
+			host, err = config.GetSSHHostFromFile(args[0], configFile)
+		} else {
+			host, err = config.GetSSHHost(args[0])
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		pingManager := connectivity.NewPingManager(doctorTimeout, configFile)
+		exitCode := runDoctor(cmd.Context(), cmd.OutOrStdout(), pingManager, *host, doctorJSON)
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		return nil
+	},
+}
+
+// runDoctor diagnoses a host, writes the report to out and returns the exit code:
+// 0 when no step failed, 1 otherwise
+func runDoctor(ctx context.Context, out io.Writer, pingManager *connectivity.PingManager, host config.SSHHost, asJSON bool) int {
+	diagnosis := pingManager.Diagnose(ctx, host)
+
+	if asJSON {
+		writeDoctorJSON(out, diagnosis)
+	} else {
+		writeDoctorText(out, diagnosis)
+	}
+
+	if !diagnosis.OK() {
+		return 1
+	}
+	return 0
+}
+
+// writeDoctorText prints one line per step, followed by the error and fix of failed steps
+func writeDoctorText(out io.Writer, diagnosis *connectivity.Diagnosis) {
+	fmt.Fprintf(out, "Diagnosing %s\n\n", diagnosis.Host)
+
+	for _, step := range diagnosis.Steps {
+		description := step.Detail
+		if step.Target != "" {
+			description = strings.TrimSpace(step.Target + "  " + step.Detail)
+		}
+
+		timing := ""
+		if step.Status != connectivity.StepSkip {
+			timing = formatLatency(step.Duration)
+		}
+		fmt.Fprintf(out, "  %-4s  %-6s  %-9s %s\n", strings.ToUpper(string(step.Status)), step.Name, timing, description)
+
+		if step.Status == connectivity.StepFail {
+			if step.Reason != connectivity.ReasonNone {
+				fmt.Fprintf(out, "%25s %s (%s)\n", "", step.Reason.Description(), step.Reason)
+			}
+			if step.Error != nil {
+				fmt.Fprintf(out, "%25s %s\n", "", step.Error)
+			}
+			if step.Fix != "" {
+				fmt.Fprintf(out, "%25s fix: %s\n", "", step.Fix)
+			}
+		}
+	}
+
+	if diagnosis.OK() {
+		fmt.Fprintln(out, "\nNo problem found.")
+	} else {
+		fmt.Fprintln(out, "\nSome steps failed, see the suggested fixes above.")
+	}
+}
+
+// writeDoctorJSON writes the diagnosis as a single JSON document
+func writeDoctorJSON(out io.Writer, diagnosis *connectivity.Diagnosis) {
+	resp := doctorResponse{
+		Schema: "sshm.doctor.v1",
+		OK:     diagnosis.OK(),
+		Host:   diagnosis.Host,
+		Steps:  []doctorJSONStep{},
+	}
+	for _, step := range diagnosis.Steps {
+		resp.Steps = append(resp.Steps, doctorJSONStep{
+			Step:       step.Name,
+			Target:     maybeString(step.Target),
+			Status:     string(step.Status),
+			DurationMs: step.Duration.Milliseconds(),
+			Detail:     maybeString(step.Detail),
+			Error:      errorString(step.Error),
+			Reason:     maybeString(string(step.Reason)),
+			Fix:        maybeString(step.Fix),
+		})
+	}
+
+	b, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return
+	}
+	_, _ = out.Write(append(b, '\n'))
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "Output the diagnosis as JSON")
+	doctorCmd.Flags().DurationVar(&doctorTimeout, "timeout", 15*time.Second, "Timeout for the whole diagnosis")
+	RootCmd.AddCommand(doctorCmd)
+}
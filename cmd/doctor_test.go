@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+)
+
+func TestDoctorCommand(t *testing.T) {
+	if doctorCmd.Use != "doctor <host>" {
+		t.Errorf("Expected Use 'doctor <host>', got '%s'", doctorCmd.Use)
+	}
+
+	flags := doctorCmd.Flags()
+	for _, name := range []string{"json", "timeout"} {
+		if flags.Lookup(name) == nil {
+			t.Errorf("Expected --%s flag to be defined", name)
+		}
+	}
+}
+
+func TestRunDoctorJSON(t *testing.T) {
+	host := config.SSHHost{Name: "closed", Hostname: "127.0.0.1", Port: "1"}
+	pingManager := connectivity.NewPingManager(time.Second, "")
+
+	var buf bytes.Buffer
+	exitCode := runDoctor(context.Background(), &buf, pingManager, host, true)
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1 when a step fails, got %d", exitCode)
+	}
+
+	var resp doctorResponse
+	if err := json.Unmarshal(buf.Bytes(), &resp); err != nil {
+		t.Fatalf("Output is not valid JSON: %v", err)
+	}
+	if resp.Schema != "sshm.doctor.v1" || resp.OK || resp.Host != "closed" {
+		t.Errorf("Unexpected response header: %+v", resp)
+	}
+
+	var tcp *doctorJSONStep
+	for i := range resp.Steps {
+		if resp.Steps[i].Step == connectivity.StepTCP {
+			tcp = &resp.Steps[i]
+		}
+	}
+	if tcp == nil || tcp.Status != "fail" || tcp.Fix == nil {
+		t.Errorf("Expected a failed tcp step with a fix, got %+v", tcp)
+	}
+}
+
+func TestRunDoctorText(t *testing.T) {
+	host := config.SSHHost{Name: "closed", Hostname: "127.0.0.1", Port: "1"}
+	pingManager := connectivity.NewPingManager(time.Second, "")
+
+	var buf bytes.Buffer
+	runDoctor(context.Background(), &buf, pingManager, host, false)
+
+	output := buf.String()
+	for _, expected := range []string{"FAIL  tcp", "SKIP  banner", "fix:"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Text output should contain %q, got %q", expected, output)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/remote"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// execTags selects hosts carrying one of these tags
+	execTags []string
+	// execFilter selects hosts matching this search query
+	execFilter string
+	// execParallel is the maximum number of hosts running the command at once
+	execParallel int
+	// execTimeout is the timeout for the command on each host (0 means no timeout)
+	execTimeout time.Duration
+	// execGroup prints the output of each host as a block once it is done
+	execGroup bool
+	// execJSON outputs one JSON object per host (NDJSON)
+	execJSON bool
+	// execOutDir is a directory where the stdout and stderr of each host are saved
+	execOutDir string
+)
+
+// sshExitError is the exit code ssh uses for its own errors (connection, authentication)
+const sshExitError = 255
+
+// execOptions controls how a command is run across hosts
+type execOptions struct {
+	parallel int
+	timeout  time.Duration
+	group    bool
+	asJSON   bool
+	outDir   string
+}
+
+// execResult is the outcome of running the command on one host
+type execResult struct {
+	host     string
+	exitCode int
+	timedOut bool
+	err      error
+	stdout   bytes.Buffer
+	stderr   bytes.Buffer
+	duration time.Duration
+}
+
+type execJSONResult struct {
+	Host       string  `json:"host"`
+	ExitCode   int     `json:"exit_code"`
+	TimedOut   bool    `json:"timed_out"`
+	DurationMs int64   `json:"duration_ms"`
+	Stdout     string  `json:"stdout"`
+	Stderr     string  `json:"stderr"`
+	Error      *string `json:"error"`
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec [host...] -- <command>",
+	Short: "Run a command on several hosts in parallel",
+	Long: `Run a command on several hosts in parallel.
+
+Hosts are given by name before '--', or selected with --tag and --filter, which
+use the same matching rules as 'sshm search'. The command runs non-interactively
+(ssh BatchMode), so hosts must accept key-based authentication.
+
+By default each output line is prefixed with the host name as it arrives. Use
+--group to print the output of each host as a block once it is done, or --json
+to get one JSON object per host. A summary of exit codes is printed at the end.
+
+The command exits with status 1 if the command failed on any host.
+
+Examples:
+  sshm exec --tag web -- uptime
+  sshm exec --tag web --filter prod -- systemctl status nginx
+  sshm exec web-1 web-2 --group -- df -h /
+  sshm exec --tag db --parallel 4 --timeout 30s --out-dir ./logs -- journalctl -n 100`,
+	Args:              cobra.ArbitraryArgs,
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	ValidArgsFunction: completeHostNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dash := cmd.ArgsLenAtDash()
+		if dash < 0 || dash == len(args) {
+			fmt.Fprintln(os.Stderr, "Error: missing command, pass it after '--' (e.g. sshm exec --tag web -- uptime)")
+			os.Exit(2)
+		}
+		names, command := args[:dash], args[dash:]
+
+		if len(names) == 0 && len(execTags) == 0 && execFilter == "" {
+			fmt.Fprintln(os.Stderr, "Error: specify hosts, or select them with --tag or --filter")
+			os.Exit(2)
+		}
+
+		hosts, err := loadHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading SSH config file: %v\n", err)
+			os.Exit(2)
+		}
+
+		selected, err := selectHosts(hosts, names, execTags, execFilter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		if len(selected) == 0 {
+			fmt.Fprintln(os.Stderr, "No hosts matched.")
+			os.Exit(2)
+		}
+
+		opts := execOptions{
+			parallel: execParallel,
+			timeout:  execTimeout,
+			group:    execGroup,
+			asJSON:   execJSON,
+			outDir:   execOutDir,
+		}
+		exitCode := runExec(cmd.Context(), cmd.OutOrStdout(), selected, command, opts)
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		return nil
+	},
+}
+
+// runExec runs command on hosts, writes their output to out and returns the exit code:
+// 0 when the command succeeded everywhere, 1 otherwise
+func runExec(ctx context.Context, out io.Writer, hosts []config.SSHHost, command []string, opts execOptions) int {
+	if opts.outDir != "" {
+		if err := os.MkdirAll(opts.outDir, 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating output directory: %v\n", err)
+			return 2
+		}
+	}
+	if opts.parallel <= 0 {
+		opts.parallel = 1
+	}
+
+	prefixWidth := 0
+	for _, host := range hosts {
+		if len(host.Name) > prefixWidth {
+			prefixWidth = len(host.Name)
+		}
+	}
+
+	var outMutex sync.Mutex
+	results := make([]*execResult, len(hosts))
+	limit := make(chan struct{}, opts.parallel)
+	var wg sync.WaitGroup
+
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host config.SSHHost) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			// Stream prefixed lines unless the output is grouped or JSON
+			var stdout, stderr io.Writer
+			var prefixed []*prefixWriter
+			if !opts.group && !opts.asJSON {
+				prefix := fmt.Sprintf("%-*s | ", prefixWidth, host.Name)
+				stdoutPrefix := &prefixWriter{out: out, prefix: prefix, mutex: &outMutex}
+				stderrPrefix := &prefixWriter{out: out, prefix: prefix, mutex: &outMutex}
+				prefixed = append(prefixed, stdoutPrefix, stderrPrefix)
+				stdout, stderr = stdoutPrefix, stderrPrefix
+			}
+
+			result := execOnHost(ctx, host.Name, command, opts.timeout, stdout, stderr)
+			for _, w := range prefixed {
+				w.Flush()
+			}
+			results[i] = result
+
+			if opts.outDir != "" {
+				if err := saveExecOutput(opts.outDir, result); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: Could not save output of %s: %v\n", host.Name, err)
+				}
+			}
+
+			outMutex.Lock()
+			defer outMutex.Unlock()
+			switch {
+			case opts.asJSON:
+				writeExecJSON(out, result)
+			case opts.group:
+				writeExecGroup(out, result)
+			}
+		}(i, host)
+	}
+	wg.Wait()
+
+	if !opts.asJSON {
+		writeExecSummary(out, results)
+	}
+
+	for _, result := range results {
+		if result.exitCode != 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+// execOnHost runs command on a host through ssh. The output is always captured,
+// and also copied to stdout and stderr when they are set.
+func execOnHost(ctx context.Context, hostName string, command []string, timeout time.Duration, stdout, stderr io.Writer) *execResult {
+	result := &execResult{host: hostName}
+
+	var cmdStdout, cmdStderr io.Writer = &result.stdout, &result.stderr
+	if stdout != nil {
+		cmdStdout = io.MultiWriter(&result.stdout, stdout)
+	}
+	if stderr != nil {
+		cmdStderr = io.MultiWriter(&result.stderr, stderr)
+	}
+
+	run := remote.Run(ctx, configFile, hostName, command, timeout, cmdStdout, cmdStderr)
+	result.exitCode = run.ExitCode
+	result.timedOut = run.TimedOut
+	result.err = run.Err
+	result.duration = run.Duration
+	return result
+}
+
+// prefixWriter writes complete lines to out, each preceded by prefix
+type prefixWriter struct {
+	out     io.Writer
+	prefix  string
+	mutex   *sync.Mutex
+	pending []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.writeLine(w.pending[:i+1])
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes a trailing line that has no newline
+func (w *prefixWriter) Flush() {
+	if len(w.pending) > 0 {
+		w.writeLine(append(w.pending, '\n'))
+		w.pending = nil
+	}
+}
+
+func (w *prefixWriter) writeLine(line []byte) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	_, _ = io.WriteString(w.out, w.prefix)
+	_, _ = w.out.Write(line)
+}
+
+// writeExecGroup prints the output of a host as a block under a header
+func writeExecGroup(out io.Writer, result *execResult) {
+	fmt.Fprintf(out, "=== %s (%s) ===\n", result.host, describeExit(result))
+	for _, output := range [][]byte{result.stdout.Bytes(), result.stderr.Bytes()} {
+		if len(output) == 0 {
+			continue
+		}
+		_, _ = out.Write(output)
+		if output[len(output)-1] != '\n' {
+			fmt.Fprintln(out)
+		}
+	}
+	fmt.Fprintln(out)
+}
+
+// writeExecJSON writes the result of a host as one line of JSON
+func writeExecJSON(out io.Writer, result *execResult) {
+	res := execJSONResult{
+		Host:       result.host,
+		ExitCode:   result.exitCode,
+		TimedOut:   result.timedOut,
+		DurationMs: result.duration.Milliseconds(),
+		Stdout:     result.stdout.String(),
+		Stderr:     result.stderr.String(),
+		Error:      errorString(result.err),
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		return
+	}
+	_, _ = out.Write(append(b, '\n'))
+}
+
+// writeExecSummary prints how many hosts succeeded and groups the others by exit code
+func writeExecSummary(out io.Writer, results []*execResult) {
+	succeeded := 0
+	failed := make(map[string][]string)
+	for _, result := range results {
+		if result.exitCode == 0 {
+			succeeded++
+			continue
+		}
+		outcome := describeExit(result)
+		failed[outcome] = append(failed[outcome], result.host)
+	}
+
+	fmt.Fprintf(out, "\n%d/%d hosts succeeded\n", succeeded, len(results))
+
+	outcomes := make([]string, 0, len(failed))
+	for outcome := range failed {
+		outcomes = append(outcomes, outcome)
+	}
+	sort.Strings(outcomes)
+	for _, outcome := range outcomes {
+		fmt.Fprintf(out, "  %s: %s\n", outcome, strings.Join(failed[outcome], ", "))
+	}
+}
+
+// describeExit describes how the command ended on a host
+func describeExit(result *execResult) string {
+	switch {
+	case result.timedOut:
+		return "timed out"
+	case result.err != nil:
+		return "error: " + result.err.Error()
+	case result.exitCode == sshExitError:
+		return fmt.Sprintf("exit %d (ssh error)", result.exitCode)
+	}
+	return fmt.Sprintf("exit %d", result.exitCode)
+}
+
+// saveExecOutput writes the stdout and stderr of a host to <dir>/<host>.stdout and .stderr
+func saveExecOutput(dir string, result *execResult) error {
+	base := filepath.Join(dir, strings.ReplaceAll(result.host, string(filepath.Separator), "_"))
+	if err := os.WriteFile(base+".stdout", result.stdout.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(base+".stderr", result.stderr.Bytes(), 0644)
+}
+
+func init() {
+	execCmd.Flags().StringSliceVar(&execTags, "tag", nil, "Run on hosts with this tag (repeatable)")
+	execCmd.Flags().StringVar(&execFilter, "filter", "", "Run on hosts matching this search query")
+	execCmd.Flags().IntVarP(&execParallel, "parallel", "p", 10, "Maximum number of hosts running the command at once")
+	execCmd.Flags().DurationVar(&execTimeout, "timeout", 0, "Timeout for the command on each host (0 for no timeout)")
+	execCmd.Flags().BoolVar(&execGroup, "group", false, "Print the output of each host as a block once it is done")
+	execCmd.Flags().BoolVar(&execJSON, "json", false, "Output one JSON object per host (NDJSON)")
+	execCmd.Flags().StringVar(&execOutDir, "out-dir", "", "Save the stdout and stderr of each host to this directory")
+	RootCmd.AddCommand(execCmd)
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/remote"
+)
+
+// fakeSSH replaces ssh with a shell script that receives the same arguments
+func fakeSSH(t *testing.T, script string) {
+	t.Helper()
+	original := remote.Command
+	remote.Command = func(ctx context.Context, args ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", append([]string{"-c", script, "ssh"}, args...)...)
+	}
+	t.Cleanup(func() { remote.Command = original })
+}
+
+func TestExecCommandFlags(t *testing.T) {
+	if execCmd.Use != "exec [host...] -- <command>" {
+		t.Errorf("Expected Use 'exec [host...] -- <command>', got '%s'", execCmd.Use)
+	}
+
+	flags := execCmd.Flags()
+	for _, name := range []string{"tag", "filter", "parallel", "timeout", "group", "json", "out-dir"} {
+		if flags.Lookup(name) == nil {
+			t.Errorf("Expected --%s flag to be defined", name)
+		}
+	}
+}
+
+func TestRunExec(t *testing.T) {
+	// $3 is the host name after "-o BatchMode=yes"
+	fakeSSH(t, `host=$3; if [ "$host" = "bad" ]; then echo "oops" >&2; exit 3; fi; echo "hello from $host"`)
+
+	hosts := []config.SSHHost{{Name: "web-1"}, {Name: "bad"}}
+
+	var buf bytes.Buffer
+	if exitCode := runExec(context.Background(), &buf, hosts, []string{"uptime"}, execOptions{parallel: 2}); exitCode != 1 {
+		t.Errorf("Expected exit code 1 when a host fails, got %d", exitCode)
+	}
+	output := buf.String()
+	for _, expected := range []string{"web-1 | hello from web-1", "bad   | oops", "1/2 hosts succeeded", "exit 3: bad"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in output:\n%s", expected, output)
+		}
+	}
+
+	buf.Reset()
+	if exitCode := runExec(context.Background(), &buf, hosts[:1], []string{"uptime"}, execOptions{parallel: 1, group: true}); exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d", exitCode)
+	}
+	if !strings.Contains(buf.String(), "=== web-1 (exit 0) ===\nhello from web-1\n") {
+		t.Errorf("Unexpected grouped output:\n%s", buf.String())
+	}
+}
+
+func TestRunExec_JSONAndOutDir(t *testing.T) {
+	fakeSSH(t, `echo "out $3"; echo "err $3" >&2`)
+
+	dir := filepath.Join(t.TempDir(), "logs")
+	hosts := []config.SSHHost{{Name: "a"}, {Name: "b"}}
+
+	var buf bytes.Buffer
+	if exitCode := runExec(context.Background(), &buf, hosts, []string{"true"}, execOptions{parallel: 2, asJSON: true, outDir: dir}); exitCode != 0 {
+		t.Errorf("Expected exit code 0, got %d", exitCode)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 NDJSON lines, got %d", len(lines))
+	}
+	var res execJSONResult
+	if err := json.Unmarshal([]byte(lines[0]), &res); err != nil {
+		t.Fatalf("Line is not valid JSON: %v", err)
+	}
+	if res.ExitCode != 0 || res.Stdout != "out "+res.Host+"\n" || res.Stderr != "err "+res.Host+"\n" || res.Error != nil {
+		t.Errorf("Unexpected JSON result: %+v", res)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "b.stderr"))
+	if err != nil || string(data) != "err b\n" {
+		t.Errorf("Expected b.stderr to contain the host's stderr, got %q (%v)", data, err)
+	}
+}
+
+func TestRunExec_Timeout(t *testing.T) {
+	fakeSSH(t, `exec sleep 5`)
+
+	var buf bytes.Buffer
+	opts := execOptions{parallel: 1, timeout: 100 * time.Millisecond}
+	if exitCode := runExec(context.Background(), &buf, []config.SSHHost{{Name: "slow"}}, []string{"true"}, opts); exitCode != 1 {
+		t.Errorf("Expected exit code 1 on timeout, got %d", exitCode)
+	}
+	if !strings.Contains(buf.String(), "timed out: slow") {
+		t.Errorf("Expected the host to be reported as timed out:\n%s", buf.String())
+	}
+}
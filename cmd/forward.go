@@ -0,0 +1,286 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/Gu1llaum-3/sshm/internal/history"
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// forwardLocal, forwardRemote and forwardDynamic are the forwards of a saved profile
+	forwardLocal   []string
+	forwardRemote  []string
+	forwardDynamic []string
+	// forwardSave saves the forwards given with -L/-R/-D as the profile
+	forwardSave bool
+	// forwardDelete deletes the profile
+	forwardDelete bool
+	// forwardBackground starts the profile as a background tunnel
+	forwardBackground bool
+)
+
+var forwardCmd = &cobra.Command{
+	Use:   "forward <host> [profile]",
+	Short: "Connect with a saved set of port forwards",
+	Long: `Connect to a host with the forwards of a named profile, which can mix local,
+remote and dynamic forwards. Without a profile, list the profiles of the host.
+
+Profiles are saved here with --save, or from the port forwarding form of the
+TUI, and kept in forward_profiles.json in the sshm config directory.
+
+Examples:
+  sshm forward prod                                          # List the profiles of prod
+  sshm forward prod db --save -L 5432:localhost:5432 -D 1080 # Save the "db" profile
+  sshm forward prod db                                       # Connect with the db forwards
+  sshm forward prod db --background                          # Run them as a background tunnel
+  sshm forward prod db --delete                              # Delete the profile`,
+	Args:              cobra.RangeArgs(1, 2),
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	ValidArgsFunction: completeForwardArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hostName := args[0]
+		hosts, err := loadHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading SSH config file: %v\n", err)
+			os.Exit(2)
+		}
+		if _, err := selectHosts(hosts, []string{hostName}, nil, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		if len(args) == 1 {
+			if forwardSave || forwardDelete || forwardBackground {
+				fmt.Fprintln(os.Stderr, "Error: a profile name is required")
+				os.Exit(2)
+			}
+			profiles, err := tunnel.LoadProfiles(hostName)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading forward profiles: %v\n", err)
+				os.Exit(1)
+			}
+			writeForwardProfiles(cmd.OutOrStdout(), hostName, profiles)
+			return nil
+		}
+		name := args[1]
+
+		switch {
+		case forwardSave:
+			forwards, err := parseTunnelForwards(forwardLocal, forwardRemote, forwardDynamic)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(2)
+			}
+			profile := tunnel.Profile{Name: name, Forwards: forwards}
+			for _, f := range forwards {
+				for _, warning := range tunnel.Warnings(f) {
+					fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+				}
+			}
+			if err := tunnel.SaveProfile(hostName, profile); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Profile %s saved for %s: %s\n", name, hostName, profile.Ports())
+			return nil
+
+		case forwardDelete:
+			if err := tunnel.DeleteProfile(hostName, name); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Profile %s deleted for %s\n", name, hostName)
+			return nil
+		}
+
+		profiles, err := tunnel.LoadProfiles(hostName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading forward profiles: %v\n", err)
+			os.Exit(1)
+		}
+		profile, found := tunnel.FindProfile(profiles, name)
+		if !found {
+			fmt.Fprintf(os.Stderr, "Error: profile '%s' not found for host '%s'\n", name, hostName)
+			writeForwardProfiles(os.Stderr, hostName, profiles)
+			os.Exit(1)
+		}
+
+		forwards, err := checkForwardPorts(os.Stdin, os.Stderr, profile.Forwards, term.IsTerminal(os.Stdin.Fd()))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		profile.Forwards = forwards
+
+		if forwardBackground {
+			t := &tunnel.Tunnel{ID: hostName + "-" + name, Host: hostName, ConfigFile: configFile, Forwards: profile.Forwards}
+			os.Exit(runTunnelStart(cmd.OutOrStdout(), t))
+		}
+		os.Exit(runForwardSession(hostName, profile))
+		return nil
+	},
+}
+
+// writeForwardProfiles prints the forward profiles of a host
+func writeForwardProfiles(out io.Writer, hostName string, profiles []tunnel.Profile) {
+	if len(profiles) == 0 {
+		fmt.Fprintf(out, "No forward profiles for %s. Save one with 'sshm forward %s <profile> --save -L ...'.\n", hostName, hostName)
+		return
+	}
+
+	nameWidth := len("Profile")
+	for _, p := range profiles {
+		nameWidth = max(nameWidth, len(p.Name))
+	}
+	fmt.Fprintf(out, "%-*s %s\n", nameWidth, "Profile", "Forwards")
+	for _, p := range profiles {
+		fmt.Fprintf(out, "%-*s %s\n", nameWidth, p.Name, p.Ports())
+	}
+}
+
+// checkForwardPorts prints the warnings about forwards and checks that their
+// local ports are free. When a port is taken, the next free port is offered
+// if interactive, and suggested in the error otherwise.
+func checkForwardPorts(in io.Reader, out io.Writer, forwards []tunnel.Forward, interactive bool) ([]tunnel.Forward, error) {
+	checked := append([]tunnel.Forward(nil), forwards...)
+	reader := bufio.NewReader(in)
+
+	for i, f := range checked {
+		for _, warning := range tunnel.Warnings(f) {
+			fmt.Fprintf(out, "Warning: %s\n", warning)
+		}
+
+		err := tunnel.CheckPort(f)
+		if err == nil {
+			continue
+		}
+		var portErr *tunnel.PortError
+		if !errors.As(err, &portErr) || portErr.Suggested == "" {
+			return nil, err
+		}
+
+		suggested := f
+		port, claimErr := unclaimedPort(checked, i, portErr)
+		if claimErr != nil {
+			return nil, claimErr
+		}
+		suggested.LocalPort = port
+		if !interactive {
+			return nil, fmt.Errorf("%v, use %s %s", err, suggested.Flag(), suggested.Spec())
+		}
+
+		fmt.Fprintf(out, "Port %s is in use. Use port %s instead? [Y/n]: ", f.LocalPort, suggested.LocalPort)
+		response, _ := reader.ReadString('\n')
+		response = strings.ToLower(strings.TrimSpace(response))
+		if response != "" && response != "y" && response != "yes" {
+			return nil, err
+		}
+		checked[i] = suggested
+	}
+	return checked, nil
+}
+
+// unclaimedPort returns the free port suggested for forwards[i], or the next
+// free one when another forward listening here already uses it
+func unclaimedPort(forwards []tunnel.Forward, i int, portErr *tunnel.PortError) (string, error) {
+	claimed := make(map[string]bool)
+	for j, f := range forwards {
+		if j != i && f.ListensLocally() {
+			claimed[f.LocalPort] = true
+		}
+	}
+
+	port := portErr.Suggested
+	for claimed[port] {
+		from, _ := strconv.Atoi(port)
+		next, err := tunnel.NextFreePort(forwards[i].BindAddress, from+1)
+		if err != nil {
+			return "", portErr
+		}
+		port = next
+	}
+	return port, nil
+}
+
+// forwardSSHArgs returns the ssh arguments connecting to hostName with the forwards of profile
+func forwardSSHArgs(hostName string, profile tunnel.Profile) []string {
+	var args []string
+	if configFile != "" {
+		args = append(args, "-F", configFile)
+	}
+	for _, f := range profile.Forwards {
+		args = append(args, f.Args()...)
+	}
+	return append(args, hostName)
+}
+
+// runForwardSession connects to hostName in the terminal with the forwards of
+// profile and returns the exit code of ssh
+func runForwardSession(hostName string, profile tunnel.Profile) int {
+	if historyManager, err := history.NewHistoryManager(); err == nil {
+		if err := historyManager.RecordConnection(hostName); err != nil {
+			fmt.Printf("Warning: Could not record connection history: %v\n", err)
+		}
+	}
+
+	fmt.Printf("Connecting to %s with %s...\n", hostName, profile.Ports())
+	ssh := exec.Command("ssh", forwardSSHArgs(hostName, profile)...)
+	ssh.Stdin = os.Stdin
+	ssh.Stdout = os.Stdout
+	ssh.Stderr = os.Stderr
+
+	if err := ssh.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		fmt.Fprintf(os.Stderr, "Error executing ssh: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// completeForwardArgs completes host names, then the profile names of the host
+func completeForwardArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeHostNames(cmd, args, toComplete)
+	}
+	if len(args) > 1 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	profiles, err := tunnel.LoadProfiles(args[0])
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	var completions []string
+	for _, p := range profiles {
+		if strings.HasPrefix(p.Name, toComplete) {
+			completions = append(completions, p.Name+"\t"+p.Ports())
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	forwardCmd.Flags().StringArrayVarP(&forwardLocal, "local", "L", nil, "Local forward [bind_address:]port:host:hostport (with --save)")
+	forwardCmd.Flags().StringArrayVarP(&forwardRemote, "remote", "R", nil, "Remote forward [bind_address:]port:host:hostport (with --save)")
+	forwardCmd.Flags().StringArrayVarP(&forwardDynamic, "dynamic", "D", nil, "Dynamic (SOCKS) forward [bind_address:]port (with --save)")
+	forwardCmd.Flags().BoolVar(&forwardSave, "save", false, "Save the forwards given with -L/-R/-D as the profile")
+	forwardCmd.Flags().BoolVar(&forwardDelete, "delete", false, "Delete the profile")
+	forwardCmd.Flags().BoolVarP(&forwardBackground, "background", "b", false, "Start the profile as a background tunnel")
+	forwardCmd.MarkFlagsMutuallyExclusive("save", "delete", "background")
+	RootCmd.AddCommand(forwardCmd)
+}
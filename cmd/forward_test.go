@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
+)
+
+func TestForwardCommand(t *testing.T) {
+	for _, flag := range []string{"local", "remote", "dynamic", "save", "delete", "background"} {
+		if forwardCmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected --%s flag on 'forward'", flag)
+		}
+	}
+	if err := forwardCmd.Args(forwardCmd, []string{}); err == nil {
+		t.Error("Expected 'forward' to require a host")
+	}
+	if err := forwardCmd.Args(forwardCmd, []string{"prod", "db", "extra"}); err == nil {
+		t.Error("Expected 'forward' to take at most a host and a profile")
+	}
+}
+
+func TestForwardSSHArgs(t *testing.T) {
+	profile := tunnel.Profile{Name: "db", Forwards: []tunnel.Forward{
+		{Type: tunnel.Local, LocalPort: "5432", RemoteHost: "localhost", RemotePort: "5432"},
+		{Type: tunnel.Remote, LocalPort: "9000", RemoteHost: "localhost", RemotePort: "3000"},
+		{Type: tunnel.Dynamic, LocalPort: "1080"},
+	}}
+
+	got := strings.Join(forwardSSHArgs("prod", profile), " ")
+	want := "-L 5432:localhost:5432 -R 9000:localhost:3000 -D 1080 prod"
+	if got != want {
+		t.Errorf("forwardSSHArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestWriteForwardProfiles(t *testing.T) {
+	profiles := []tunnel.Profile{
+		{Name: "db", Forwards: []tunnel.Forward{{Type: tunnel.Local, LocalPort: "5432", RemoteHost: "localhost", RemotePort: "5432"}}},
+		{Name: "grafana", Forwards: []tunnel.Forward{{Type: tunnel.Local, LocalPort: "3000", RemoteHost: "grafana", RemotePort: "3000"}}},
+	}
+
+	var buf bytes.Buffer
+	writeForwardProfiles(&buf, "prod", profiles)
+	for _, expected := range []string{"db", "L 5432 → localhost:5432", "grafana", "L 3000 → grafana:3000"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %q in output:\n%s", expected, buf.String())
+		}
+	}
+
+	buf.Reset()
+	writeForwardProfiles(&buf, "prod", nil)
+	if !strings.Contains(buf.String(), "No forward profiles for prod") {
+		t.Errorf("Expected a message when there are no profiles, got %q", buf.String())
+	}
+}
+
+func TestCheckForwardPorts(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer listener.Close()
+	_, taken, _ := net.SplitHostPort(listener.Addr().String())
+
+	forwards := []tunnel.Forward{{Type: tunnel.Local, LocalPort: taken, RemoteHost: "localhost", RemotePort: "5432"}}
+
+	var out bytes.Buffer
+	if _, err := checkForwardPorts(strings.NewReader(""), &out, forwards, false); err == nil {
+		t.Error("Expected an error for a taken port when not interactive")
+	} else if !strings.Contains(err.Error(), "use -L ") {
+		t.Errorf("Expected the error to suggest a free port, got %q", err)
+	}
+
+	out.Reset()
+	checked, err := checkForwardPorts(strings.NewReader("\n"), &out, forwards, true)
+	if err != nil {
+		t.Fatalf("checkForwardPorts() error = %v", err)
+	}
+	if checked[0].LocalPort == taken {
+		t.Error("Expected the taken port to be replaced")
+	}
+	if forwards[0].LocalPort != taken {
+		t.Error("Expected the given forwards to be left unchanged")
+	}
+	if !strings.Contains(out.String(), "[Y/n]") {
+		t.Errorf("Expected a prompt, got %q", out.String())
+	}
+
+	if _, err := checkForwardPorts(strings.NewReader("n\n"), &out, forwards, true); err == nil {
+		t.Error("Expected an error when the free port is declined")
+	}
+
+	// The free port suggested is not one a later forward listens on
+	port, _ := strconv.Atoi(taken)
+	next, err := tunnel.NextFreePort("", port+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := []tunnel.Forward{forwards[0], {Type: tunnel.Dynamic, LocalPort: next}}
+	checked, err = checkForwardPorts(strings.NewReader("\n"), &out, profile, true)
+	if err != nil {
+		t.Fatalf("checkForwardPorts() error = %v", err)
+	}
+	if checked[0].LocalPort == taken || checked[0].LocalPort == next {
+		t.Errorf("Expected a port no other forward uses, got %s", checked[0].LocalPort)
+	}
+
+	out.Reset()
+	exposed := []tunnel.Forward{{Type: tunnel.Dynamic, LocalPort: "0", BindAddress: "0.0.0.0"}}
+	if _, err := checkForwardPorts(strings.NewReader(""), &out, exposed, false); err != nil {
+		t.Fatalf("checkForwardPorts() error = %v", err)
+	}
+	if !strings.Contains(out.String(), "Warning: bind address 0.0.0.0") {
+		t.Errorf("Expected a warning about the bind address, got %q", out.String())
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/hook"
+)
+
+// loadHooks returns the hooks configured for a host, along with the host.
+// Hooks are left out when the app config or the host cannot be read.
+func loadHooks(hostName string) (hook.Hooks, config.SSHHost) {
+	settings, ok := loadHookSettings()
+	if !ok {
+		return hook.Hooks{}, config.SSHHost{Name: hostName}
+	}
+
+	var host *config.SSHHost
+	var err error
+	if configFile != "" {
+		host, err = config.GetSSHHostFromFile(hostName, configFile)
+	} else {
+		host, err = config.GetSSHHost(hostName)
+	}
+	if err != nil || host == nil {
+		return hook.Hooks{}, config.SSHHost{Name: hostName}
+	}
+	return hook.ForHost(settings, *host), *host
+}
+
+// loadHookSettings reads the hook settings from the app config, and reports
+// whether any hook is configured
+func loadHookSettings() (config.HookSettings, bool) {
+	appConfig, err := config.LoadAppConfig()
+	if err != nil || appConfig == nil {
+		return config.HookSettings{}, false
+	}
+	settings := appConfig.Hooks
+	if len(settings.Global.PreConnect) == 0 && len(settings.Global.PostDisconnect) == 0 && len(settings.Tags) == 0 {
+		return config.HookSettings{}, false
+	}
+	return settings, true
+}
+
+// runPostDisconnectHooks runs the post-disconnect hooks of host once ssh
+// exited with exitCode, and returns the exit code sshm should use
+func runPostDisconnectHooks(hooks hook.Hooks, host config.SSHHost, exitCode int) int {
+	if err := hooks.RunPostDisconnect(host, exitCode, hook.Terminal()); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return exitCode
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// loadHosts parses the SSH config selected with --config, or the default one
+func loadHosts() ([]config.SSHHost, error) {
+	if configFile != "" {
+		return config.ParseSSHConfigFile(configFile)
+	}
+	return config.ParseSSHConfig()
+}
+
+// selectHosts picks the hosts targeted by a multi-host command.
+// Hosts named explicitly are always used, even when hidden; otherwise all visible hosts are
+// candidates. Candidates are then narrowed to those carrying one of tags (if any) and
+// matching query with the same rules as 'sshm search'.
+func selectHosts(hosts []config.SSHHost, names []string, tags []string, query string) ([]config.SSHHost, error) {
+	var candidates []config.SSHHost
+	if len(names) > 0 {
+		byName := make(map[string]config.SSHHost)
+		for _, host := range hosts {
+			if _, exists := byName[host.Name]; !exists {
+				byName[host.Name] = host
+			}
+		}
+		for _, name := range names {
+			host, exists := byName[name]
+			if !exists {
+				return nil, fmt.Errorf("host '%s' not found", name)
+			}
+			candidates = append(candidates, host)
+		}
+	} else {
+		candidates = config.FilterVisibleHosts(hosts)
+	}
+
+	if len(tags) > 0 {
+		var tagged []config.SSHHost
+		for _, host := range candidates {
+			if hasAnyTag(host, tags) {
+				tagged = append(tagged, host)
+			}
+		}
+		candidates = tagged
+	}
+
+	return filterHosts(candidates, query, false, false), nil
+}
+
+// hasAnyTag reports whether a host carries one of the given tags (case-insensitive)
+func hasAnyTag(host config.SSHHost, tags []string) bool {
+	for _, hostTag := range host.Tags {
+		for _, tag := range tags {
+			if strings.EqualFold(hostTag, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// completeHostNames provides shell completion of host names for commands taking hosts as arguments
+func completeHostNames(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	hosts, err := loadHosts()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	hosts = config.FilterVisibleHosts(hosts)
+
+	var completions []string
+	toCompleteLower := strings.ToLower(toComplete)
+	for _, host := range hosts {
+		if strings.HasPrefix(strings.ToLower(host.Name), toCompleteLower) {
+			completions = append(completions, host.Name)
+		}
+	}
+
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
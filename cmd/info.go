@@ -27,6 +27,7 @@ type infoResult struct {
 	ProxyJump     *string     `json:"proxy_jump"`
 	ProxyCommand  *string     `json:"proxy_command"`
 	Options       *string     `json:"options"`
+	Forwards      []string    `json:"forwards"`
 	Tags          []string    `json:"tags"`
 	RemoteCommand *string     `json:"remote_command"`
 	RequestTTY    *string     `json:"request_tty"`
@@ -135,6 +136,7 @@ func runInfo(out io.Writer, hostnameArg string, cfgFile string, pretty bool) int
 		ProxyJump:     maybeString(host.ProxyJump),
 		ProxyCommand:  maybeString(host.ProxyCommand),
 		Options:       maybeString(host.Options),
+		Forwards:      host.Forwards,
 		Tags:          host.Tags,
 		RemoteCommand: maybeString(host.RemoteCommand),
 		RequestTTY:    maybeString(host.RequestTTY),
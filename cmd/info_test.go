@@ -26,6 +26,7 @@ type infoResultForTest struct {
 	ProxyJump     *string            `json:"proxy_jump"`
 	ProxyCommand  *string            `json:"proxy_command"`
 	Options       *string            `json:"options"`
+	Forwards      []string           `json:"forwards"`
 	Tags          []string           `json:"tags"`
 	RemoteCommand *string            `json:"remote_command"`
 	RequestTTY    *string            `json:"request_tty"`
@@ -96,6 +97,7 @@ Host prod-web
     IdentityFile ~/.ssh/id_prod
     ProxyJump bastion
     ServerAliveInterval 60
+    LocalForward 5432 localhost:5432
 `
 
 	if err := os.WriteFile(cfg, []byte(cfgContent), 0600); err != nil {
@@ -155,6 +157,9 @@ Host prod-web
 	if resp.Result.ProxyJump == nil || *resp.Result.ProxyJump != "bastion" {
 		t.Fatalf("proxy_jump=%v", resp.Result.ProxyJump)
 	}
+	if len(resp.Result.Forwards) != 1 || resp.Result.Forwards[0] != "LocalForward 5432 localhost:5432" {
+		t.Fatalf("forwards=%v", resp.Result.Forwards)
+	}
 }
 
 func TestRunInfoNotFoundJSON(t *testing.T) {
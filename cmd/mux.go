@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/mux"
+
+	"github.com/spf13/cobra"
+)
+
+var muxCmd = &cobra.Command{
+	Use:   "mux",
+	Short: "Manage multiplexed (ControlMaster) connections",
+	Long: `Show and stop the ControlMaster connections that ssh shares between sessions
+to the same host.
+
+Hosts are multiplexed when their block sets ControlMaster and ControlPath,
+which the edit form of the TUI can add. The tokens of ControlPath (%h, %p, %r,
+%C...) are expanded to find each socket, and 'ssh -O check' tells whether a
+master is listening on it.
+
+Examples:
+  sshm mux list          # Show the multiplexed hosts and their masters
+  sshm mux stop db web   # Close the masters of db and web
+  sshm mux stop-all      # Close every running master`,
+}
+
+var muxListCmd = &cobra.Command{
+	Use:           "list",
+	Short:         "Show the multiplexed hosts and whether their master is running",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hosts, err := loadHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading SSH config file: %v\n", err)
+			os.Exit(2)
+		}
+		writeMasters(cmd.OutOrStdout(), mux.List(context.Background(), config.FilterVisibleHosts(hosts), configFile))
+		return nil
+	},
+}
+
+// writeMasters prints the control sockets of the multiplexed hosts
+func writeMasters(out io.Writer, masters []mux.Master) {
+	if len(masters) == 0 {
+		fmt.Fprintln(out, "No multiplexed hosts. Enable multiplexing for a host from the edit form, or set ControlMaster and ControlPath in its block.")
+		return
+	}
+
+	hostWidth := len("Host")
+	for _, m := range masters {
+		hostWidth = max(hostWidth, len(m.Host))
+	}
+	fmt.Fprintf(out, "%-*s %-8s %s\n", hostWidth, "Host", "Master", "Socket")
+	for _, m := range masters {
+		state := "-"
+		if m.Active {
+			state = "running"
+		}
+		fmt.Fprintf(out, "%-*s %-8s %s\n", hostWidth, m.Host, state, m.Socket)
+	}
+}
+
+var muxStopCmd = &cobra.Command{
+	Use:               "stop <host>...",
+	Short:             "Close the masters of hosts",
+	Args:              cobra.MinimumNArgs(1),
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	ValidArgsFunction: completeHostNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hosts, err := loadHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading SSH config file: %v\n", err)
+			os.Exit(2)
+		}
+		if _, err := selectHosts(hosts, args, nil, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		os.Exit(runMuxStop(cmd.OutOrStdout(), args))
+		return nil
+	},
+}
+
+var muxStopAllCmd = &cobra.Command{
+	Use:           "stop-all",
+	Short:         "Close every running master",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hosts, err := loadHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading SSH config file: %v\n", err)
+			os.Exit(2)
+		}
+
+		var active []string
+		for _, m := range mux.List(context.Background(), hosts, configFile) {
+			if m.Active {
+				active = append(active, m.Host)
+			}
+		}
+		if len(active) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No masters running.")
+			return nil
+		}
+		os.Exit(runMuxStop(cmd.OutOrStdout(), active))
+		return nil
+	},
+}
+
+// runMuxStop closes the masters of hostNames and returns the exit code
+func runMuxStop(out io.Writer, hostNames []string) int {
+	code := 0
+	for _, hostName := range hostNames {
+		if err := mux.Stop(hostName, configFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			code = 1
+			continue
+		}
+		fmt.Fprintf(out, "Master of %s stopped\n", hostName)
+	}
+	return code
+}
+
+func init() {
+	muxCmd.AddCommand(muxListCmd, muxStopCmd, muxStopAllCmd)
+	RootCmd.AddCommand(muxCmd)
+}
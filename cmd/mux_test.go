@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/mux"
+)
+
+func TestMuxCommands(t *testing.T) {
+	subcommands := make(map[string]bool)
+	for _, sub := range muxCmd.Commands() {
+		subcommands[sub.Name()] = true
+	}
+	for _, name := range []string{"list", "stop", "stop-all"} {
+		if !subcommands[name] {
+			t.Errorf("Expected 'mux %s' command", name)
+		}
+	}
+	if err := muxStopCmd.Args(muxStopCmd, []string{}); err == nil {
+		t.Error("Expected 'mux stop' to require a host")
+	}
+}
+
+func TestWriteMasters(t *testing.T) {
+	masters := []mux.Master{
+		{Host: "db", Socket: "/home/me/.ssh/sshm-abc", Active: true},
+		{Host: "web", Socket: "/home/me/.ssh/sshm-def"},
+	}
+
+	var buf bytes.Buffer
+	writeMasters(&buf, masters)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 lines, got:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[1], "running") || !strings.Contains(lines[1], "/home/me/.ssh/sshm-abc") {
+		t.Errorf("Expected db to be running, got %q", lines[1])
+	}
+	if strings.Contains(lines[2], "running") {
+		t.Errorf("Expected web to have no master, got %q", lines[2])
+	}
+
+	buf.Reset()
+	writeMasters(&buf, nil)
+	if !strings.Contains(buf.String(), "No multiplexed hosts") {
+		t.Errorf("Expected a message when no host is multiplexed, got %q", buf.String())
+	}
+}
@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// pingTags limits the check to hosts carrying one of these tags
+	pingTags []string
+	// pingFilter limits the check to hosts matching this search query
+	pingFilter string
+	// pingJSON outputs one JSON object per host (NDJSON)
+	pingJSON bool
+	// pingTimeout is the timeout for each host check
+	pingTimeout time.Duration
+	// pingAuth also checks that publickey authentication succeeds
+	pingAuth bool
+)
+
+type pingJSONResult struct {
+	Host       string        `json:"host"`
+	Status     string        `json:"status"`
+	DurationMs int64         `json:"duration_ms"`
+	Error      *string       `json:"error"`
+	Reason     *string       `json:"reason"`
+	Auth       *string       `json:"auth,omitempty"`
+	AuthKey    *string       `json:"auth_key,omitempty"`
+	Hops       []pingJSONHop `json:"hops,omitempty"`
+}
+
+type pingJSONHop struct {
+	Name       string  `json:"name"`
+	Address    string  `json:"address"`
+	Status     string  `json:"status"`
+	DurationMs int64   `json:"duration_ms"`
+	Error      *string `json:"error"`
+	Reason     *string `json:"reason"`
+}
+
+var pingCmd = &cobra.Command{
+	Use:   "ping [host...]",
+	Short: "Check SSH connectivity of hosts",
+	Long: `Check SSH connectivity of one or more hosts, like the 'p' key in the TUI.
+
+Without arguments, all visible hosts are checked. Hosts can be narrowed down
+with --tag and --filter, which use the same matching rules as 'sshm search'.
+
+The command exits with status 1 if any checked host is offline, which makes it
+suitable for scripts and monitoring checks.
+
+With --auth, each check also tries publickey authentication with the SSH agent
+and the host's identity files, never with a password, and reports auth_ok with
+the key that was accepted, or auth_denied. Hosts that deny authentication then
+also make the command exit with status 1.
+
+Examples:
+  sshm ping                     # Check all hosts
+  sshm ping web-1 web-2         # Check specific hosts
+  sshm ping --tag prod          # Check hosts tagged "prod"
+  sshm ping --filter db --json  # Stream results as NDJSON
+  sshm ping --tag prod --auth   # Also check that your keys are accepted`,
+	Args:              cobra.ArbitraryArgs,
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	ValidArgsFunction: completeHostNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hosts, err := loadHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading SSH config file: %v\n", err)
+			os.Exit(2)
+		}
+
+		selected, err := selectHosts(hosts, args, pingTags, pingFilter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		if len(selected) == 0 {
+			fmt.Fprintln(os.Stderr, "No hosts matched.")
+			os.Exit(2)
+		}
+
+		pingManager := connectivity.NewPingManager(pingTimeout, configFile)
+		if appConfig, err := config.LoadAppConfig(); err == nil {
+			pingManager.ApplySettings(appConfig.Ping)
+		}
+		if pingAuth {
+			pingManager.SetAuthProbe(true)
+		}
+		// Share last known statuses with the TUI
+		if err := pingManager.EnableCache(); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not load connectivity cache: %v\n", err)
+		}
+
+		exitCode := runPing(cmd.Context(), cmd.OutOrStdout(), pingManager, selected, pingJSON)
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		return nil
+	},
+}
+
+// runPing checks the given hosts, streams the results to out and returns the exit code:
+// 0 when every host is online (and, when probing authentication, accepted a key), 1 otherwise
+func runPing(ctx context.Context, out io.Writer, pingManager *connectivity.PingManager, hosts []config.SSHHost, asJSON bool) int {
+	nameWidth := 4 // "Host"
+	for _, host := range hosts {
+		if len(host.Name) > nameWidth {
+			nameWidth = len(host.Name)
+		}
+	}
+	nameWidth += 2
+
+	probeAuth := pingManager.AuthProbeEnabled()
+	if !asJSON {
+		if probeAuth {
+			fmt.Fprintf(out, "%-*s %-9s %-9s %-17s %-11s %s\n", nameWidth, "Host", "Status", "Latency", "Reason", "Auth", "Error / Key")
+			fmt.Fprintf(out, "%s %s %s %s %s %s\n", strings.Repeat("-", nameWidth), strings.Repeat("-", 9), strings.Repeat("-", 9), strings.Repeat("-", 17), strings.Repeat("-", 11), strings.Repeat("-", 11))
+		} else {
+			fmt.Fprintf(out, "%-*s %-9s %-9s %-17s %s\n", nameWidth, "Host", "Status", "Latency", "Reason", "Error")
+			fmt.Fprintf(out, "%s %s %s %s %s\n", strings.Repeat("-", nameWidth), strings.Repeat("-", 9), strings.Repeat("-", 9), strings.Repeat("-", 17), strings.Repeat("-", 5))
+		}
+	}
+
+	online, offline, noKey := 0, 0, 0
+	for result := range pingManager.PingAllHosts(ctx, hosts) {
+		if result.Status == connectivity.StatusOnline {
+			online++
+		} else {
+			offline++
+		}
+		if probeAuth && result.Status == connectivity.StatusOnline && result.Auth != connectivity.AuthOK {
+			noKey++
+		}
+
+		if asJSON {
+			writePingJSON(out, result)
+			continue
+		}
+
+		errStr, reasonStr := "-", "-"
+		if result.Error != nil {
+			errStr = result.Error.Error()
+		}
+		if result.Reason != connectivity.ReasonNone {
+			reasonStr = string(result.Reason)
+		}
+		if !probeAuth {
+			fmt.Fprintf(out, "%-*s %-9s %-9s %-17s %s\n", nameWidth, result.HostName, result.Status, formatLatency(result.Duration), reasonStr, errStr)
+			continue
+		}
+
+		authStr := "-"
+		if result.Auth != connectivity.AuthUnchecked {
+			authStr = string(result.Auth)
+		}
+		if result.AuthKey != "" {
+			errStr = "key: " + result.AuthKey
+		}
+		fmt.Fprintf(out, "%-*s %-9s %-9s %-17s %-11s %s\n", nameWidth, result.HostName, result.Status, formatLatency(result.Duration), reasonStr, authStr, errStr)
+	}
+
+	if !asJSON {
+		if probeAuth {
+			fmt.Fprintf(out, "\n%d online, %d offline, %d without accepted key\n", online, offline, noKey)
+		} else {
+			fmt.Fprintf(out, "\n%d online, %d offline\n", online, offline)
+		}
+	}
+
+	if offline > 0 || noKey > 0 {
+		return 1
+	}
+	return 0
+}
+
+// writePingJSON writes a single result as one line of JSON
+func writePingJSON(out io.Writer, result *connectivity.HostPingResult) {
+	res := pingJSONResult{
+		Host:       result.HostName,
+		Status:     result.Status.String(),
+		DurationMs: result.Duration.Milliseconds(),
+		Error:      errorString(result.Error),
+		Reason:     maybeString(string(result.Reason)),
+		Auth:       maybeString(string(result.Auth)),
+		AuthKey:    maybeString(result.AuthKey),
+	}
+	for _, hop := range result.Hops {
+		res.Hops = append(res.Hops, pingJSONHop{
+			Name:       hop.Name,
+			Address:    hop.Address,
+			Status:     hop.Status.String(),
+			DurationMs: hop.Duration.Milliseconds(),
+			Error:      errorString(hop.Error),
+			Reason:     maybeString(string(hop.Reason)),
+		})
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		return
+	}
+	_, _ = out.Write(append(b, '\n'))
+}
+
+// errorString returns the message of err, or nil when there is no error
+func errorString(err error) *string {
+	if err == nil {
+		return nil
+	}
+	msg := err.Error()
+	return &msg
+}
+
+// formatLatency formats a check duration for display
+func formatLatency(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+func init() {
+	pingCmd.Flags().StringSliceVar(&pingTags, "tag", nil, "Only check hosts with this tag (repeatable)")
+	pingCmd.Flags().StringVar(&pingFilter, "filter", "", "Only check hosts matching this search query")
+	pingCmd.Flags().BoolVar(&pingJSON, "json", false, "Output one JSON object per host (NDJSON)")
+	pingCmd.Flags().DurationVar(&pingTimeout, "timeout", 5*time.Second, "Timeout for each host check")
+	pingCmd.Flags().BoolVar(&pingAuth, "auth", false, "Also check that publickey authentication succeeds (agent and identity files, never a password)")
+	RootCmd.AddCommand(pingCmd)
+}
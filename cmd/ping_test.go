@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+)
+
+func TestPingCommand(t *testing.T) {
+	if pingCmd.Use != "ping [host...]" {
+		t.Errorf("Expected Use 'ping [host...]', got '%s'", pingCmd.Use)
+	}
+
+	found := false
+	for _, cmd := range RootCmd.Commands() {
+		if cmd.Name() == "ping" {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Error("Ping command not found in root command")
+	}
+}
+
+func TestPingCommandFlags(t *testing.T) {
+	flags := pingCmd.Flags()
+	for _, name := range []string{"tag", "filter", "json", "timeout", "auth"} {
+		if flags.Lookup(name) == nil {
+			t.Errorf("Expected --%s flag to be defined", name)
+		}
+	}
+}
+
+func TestSelectHosts(t *testing.T) {
+	hosts := []config.SSHHost{
+		{Name: "web-prod", Hostname: "10.0.0.1", Tags: []string{"web", "prod"}},
+		{Name: "web-dev", Hostname: "10.0.0.2", Tags: []string{"web", "dev"}},
+		{Name: "db-prod", Hostname: "10.0.0.3", Tags: []string{"db", "prod"}},
+		{Name: "secret", Hostname: "10.0.0.4", Tags: []string{"hidden"}},
+	}
+
+	tests := []struct {
+		name     string
+		names    []string
+		tags     []string
+		query    string
+		expected []string
+		wantErr  bool
+	}{
+		{"all visible hosts", nil, nil, "", []string{"web-prod", "web-dev", "db-prod"}, false},
+		{"by tag", nil, []string{"PROD"}, "", []string{"web-prod", "db-prod"}, false},
+		{"by tag and search", nil, []string{"prod"}, "web", []string{"web-prod"}, false},
+		{"by search", nil, nil, "dev", []string{"web-dev"}, false},
+		{"explicit hidden host", []string{"secret"}, nil, "", []string{"secret"}, false},
+		{"explicit hosts narrowed by tag", []string{"web-dev", "db-prod"}, []string{"db"}, "", []string{"db-prod"}, false},
+		{"unknown host", []string{"missing"}, nil, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected, err := selectHosts(hosts, tt.names, tt.tags, tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectHosts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(selected) != len(tt.expected) {
+				t.Fatalf("Expected %d hosts, got %d", len(tt.expected), len(selected))
+			}
+			for i, name := range tt.expected {
+				if selected[i].Name != name {
+					t.Errorf("Host %d: expected %s, got %s", i, name, selected[i].Name)
+				}
+			}
+		})
+	}
+}
+
+func TestRunPingJSON(t *testing.T) {
+	// Port 1 on localhost is refused immediately
+	hosts := []config.SSHHost{
+		{Name: "first", Hostname: "127.0.0.1", Port: "1"},
+		{Name: "second", Hostname: "127.0.0.1", Port: "1"},
+	}
+	pingManager := connectivity.NewPingManager(time.Second, "")
+	pingManager.ApplySettings(config.PingSettings{Concurrency: 2, PerJumpHostConcurrency: 1, RetryBackoffMs: 10})
+
+	var buf bytes.Buffer
+	exitCode := runPing(context.Background(), &buf, pingManager, hosts, true)
+	if exitCode != 1 {
+		t.Errorf("Expected exit code 1 when hosts are offline, got %d", exitCode)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(hosts) {
+		t.Fatalf("Expected %d NDJSON lines, got %d: %q", len(hosts), len(lines), buf.String())
+	}
+	for i, line := range lines {
+		var res pingJSONResult
+		if err := json.Unmarshal([]byte(line), &res); err != nil {
+			t.Fatalf("Line %d is not valid JSON: %v", i, err)
+		}
+		if res.Host != hosts[i].Name {
+			t.Errorf("Line %d: expected host %s, got %s", i, hosts[i].Name, res.Host)
+		}
+		if res.Status != "offline" {
+			t.Errorf("Line %d: expected status offline, got %s", i, res.Status)
+		}
+		if res.Error == nil {
+			t.Errorf("Line %d: expected error to be set", i)
+		}
+		if res.Reason == nil || *res.Reason != string(connectivity.ReasonRefused) {
+			t.Errorf("Line %d: expected reason %q, got %v", i, connectivity.ReasonRefused, res.Reason)
+		}
+	}
+}
+
+func TestRunPingTable(t *testing.T) {
+	hosts := []config.SSHHost{{Name: "closed-port", Hostname: "127.0.0.1", Port: "1"}}
+	pingManager := connectivity.NewPingManager(time.Second, "")
+	pingManager.ApplySettings(config.PingSettings{Concurrency: 1, PerJumpHostConcurrency: 1, RetryBackoffMs: 10})
+
+	var buf bytes.Buffer
+	runPing(context.Background(), &buf, pingManager, hosts, false)
+
+	output := buf.String()
+	if !strings.Contains(output, "closed-port") || !strings.Contains(output, "offline") {
+		t.Errorf("Table output should list the host and its status, got %q", output)
+	}
+	if !strings.Contains(output, string(connectivity.ReasonRefused)) {
+		t.Errorf("Table output should show the failure reason, got %q", output)
+	}
+	if !strings.Contains(output, "0 online, 1 offline") {
+		t.Errorf("Table output should end with a summary, got %q", output)
+	}
+}
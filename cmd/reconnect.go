@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+// reconnectTag is the host tag that turns on reconnection for every connection to the host
+const reconnectTag = "reconnect"
+
+// reconnectSession runs the session again when the connection drops
+var reconnectSession bool
+
+// reconnectPolicy is how many times and after how long a dropped session is started again
+type reconnectPolicy struct {
+	maxAttempts  int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+}
+
+// newReconnectPolicy returns the policy of the reconnect settings
+func newReconnectPolicy(settings config.ReconnectSettings) reconnectPolicy {
+	return reconnectPolicy{
+		maxAttempts:  settings.MaxAttempts,
+		initialDelay: time.Duration(settings.InitialDelaySeconds) * time.Second,
+		maxDelay:     time.Duration(settings.MaxDelaySeconds) * time.Second,
+	}
+}
+
+// delay returns the wait before reconnection n, doubled on each one
+func (p reconnectPolicy) delay(n int) time.Duration {
+	d := p.initialDelay
+	for i := 1; i < n && d < p.maxDelay; i++ {
+		d *= 2
+	}
+	return min(d, p.maxDelay)
+}
+
+// loadReconnectSettings reads the reconnect settings from the app config
+func loadReconnectSettings() config.ReconnectSettings {
+	appConfig, err := config.LoadAppConfig()
+	if err != nil || appConfig == nil {
+		return config.GetDefaultAppConfig().Reconnect
+	}
+	return appConfig.Reconnect
+}
+
+// shouldReconnect reports whether the session to a host is started again when
+// the connection drops: with --reconnect, or for hosts tagged "reconnect"
+func shouldReconnect(hostName string) bool {
+	if reconnectSession {
+		return true
+	}
+
+	var host *config.SSHHost
+	var err error
+	if configFile != "" {
+		host, err = config.GetSSHHostFromFile(hostName, configFile)
+	} else {
+		host, err = config.GetSSHHost(hostName)
+	}
+	if err != nil || host == nil {
+		return false
+	}
+	for _, tag := range host.Tags {
+		if strings.EqualFold(tag, reconnectTag) {
+			return true
+		}
+	}
+	return false
+}
+
+// runWithReconnect runs a session, and runs it again while it ends with the
+// status ssh uses for its own errors, such as a dropped connection. A session
+// ending otherwise, as on logout, is not started again. recordAttempt gets the
+// exit code of each run. It returns the exit code of the last run.
+func runWithReconnect(ctx context.Context, out io.Writer, hostName string, policy reconnectPolicy, run func() int, recordAttempt func(attempt, exitCode int)) int {
+	for attempt := 1; ; attempt++ {
+		exitCode := run()
+		recordAttempt(attempt, exitCode)
+		if exitCode != sshExitError {
+			return exitCode
+		}
+
+		if attempt > policy.maxAttempts {
+			fmt.Fprintf(out, "Connection to %s lost, giving up after %d reconnection attempts.\n", hostName, policy.maxAttempts)
+			return exitCode
+		}
+		if err := countdown(ctx, out, hostName, policy.delay(attempt), attempt, policy.maxAttempts); err != nil {
+			fmt.Fprintln(out, "Reconnection cancelled.")
+			return exitCode
+		}
+	}
+}
+
+// countdown waits before reconnection n, showing the seconds left
+func countdown(ctx context.Context, out io.Writer, hostName string, delay time.Duration, n, maxAttempts int) error {
+	deadline := time.Now().Add(delay)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			fmt.Fprintln(out)
+			return nil
+		}
+		seconds := int((remaining + time.Second - 1) / time.Second)
+		fmt.Fprintf(out, "\rConnection to %s lost. Reconnecting in %ds (attempt %d/%d, Ctrl+C to cancel)...  ", hostName, seconds, n, maxAttempts)
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(out)
+			return ctx.Err()
+		case <-time.After(min(remaining, time.Second)):
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+func TestReconnectDelay(t *testing.T) {
+	policy := newReconnectPolicy(config.ReconnectSettings{MaxAttempts: 5, InitialDelaySeconds: 1, MaxDelaySeconds: 5})
+	var got []string
+	for n := 1; n <= 5; n++ {
+		got = append(got, policy.delay(n).String())
+	}
+	if strings.Join(got, " ") != "1s 2s 4s 5s 5s" {
+		t.Errorf("Unexpected delays %q", got)
+	}
+}
+
+func TestRunWithReconnect(t *testing.T) {
+	if RootCmd.Flags().Lookup("reconnect") == nil {
+		t.Error("Expected --reconnect flag on the root command")
+	}
+	policy := reconnectPolicy{maxAttempts: 2, initialDelay: time.Millisecond, maxDelay: 5 * time.Millisecond}
+
+	tests := []struct {
+		name     string
+		codes    []int
+		wantCode int
+		wantRuns int
+		wantOut  string
+	}{
+		{"logout", []int{0}, 0, 1, ""},
+		{"failing last command", []int{1}, 1, 1, ""},
+		{"dropped then logout", []int{255, 255, 0}, 0, 3, "attempt 2/2"},
+		{"gives up", []int{255, 255, 255, 0}, 255, 3, "giving up after 2 reconnection attempts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			var recorded []int
+			runs := 0
+			code := runWithReconnect(context.Background(), &out, "flaky", policy, func() int {
+				runs++
+				return tt.codes[runs-1]
+			}, func(attempt, exitCode int) {
+				if attempt != len(recorded)+1 {
+					t.Errorf("Expected attempt %d, got %d", len(recorded)+1, attempt)
+				}
+				recorded = append(recorded, exitCode)
+			})
+
+			if code != tt.wantCode || runs != tt.wantRuns {
+				t.Errorf("runWithReconnect() = %d after %d runs, want %d after %d", code, runs, tt.wantCode, tt.wantRuns)
+			}
+			if len(recorded) != runs {
+				t.Errorf("Expected every attempt to be recorded, got %v", recorded)
+			}
+			if !strings.Contains(out.String(), tt.wantOut) {
+				t.Errorf("Expected %q in output:\n%s", tt.wantOut, out.String())
+			}
+		})
+	}
+
+	// Cancelling the countdown stops reconnecting
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var out bytes.Buffer
+	runs := 0
+	slow := reconnectPolicy{maxAttempts: 5, initialDelay: time.Hour, maxDelay: time.Hour}
+	code := runWithReconnect(ctx, &out, "flaky", slow, func() int { runs++; return 255 }, func(int, int) {})
+	if code != 255 || runs != 1 || !strings.Contains(out.String(), "Reconnection cancelled") {
+		t.Errorf("Expected the reconnection to be cancelled, got %d after %d runs:\n%s", code, runs, out.String())
+	}
+}
@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/recording"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// recordingsHost lists only the recordings of this host
+	recordingsHost string
+	// playSpeed speeds up the playback of a recording
+	playSpeed float64
+	// playIdleLimit shortens pauses longer than this during playback
+	playIdleLimit time.Duration
+)
+
+var recordingsCmd = &cobra.Command{
+	Use:   "recordings",
+	Short: "List and play recorded sessions",
+	Long: `List and play sessions recorded with 'sshm --record <host>' or to hosts
+tagged "record".
+
+Recordings are asciicast v2 files, which can also be played with asciinema.
+They are kept in the recordings/ directory of the sshm config directory unless
+"recording.directory" is set in the application configuration, where
+"recording.retention_days" and "recording.max_recordings" limit how many are kept.
+
+Examples:
+  sshm recordings list                     # List all recordings
+  sshm recordings list --host prod-db      # List the recordings of a host
+  sshm recordings play 20261018-153000     # Play a recording
+  sshm recordings play 20261018 --speed 4  # Play the only recording of a day, 4 times faster`,
+}
+
+var recordingsListCmd = &cobra.Command{
+	Use:           "list",
+	Short:         "List recorded sessions, most recent first",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := recordingsDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		recordings, err := recording.List(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading recordings: %v\n", err)
+			os.Exit(1)
+		}
+		writeRecordings(cmd.OutOrStdout(), recordings, recordingsHost)
+		return nil
+	},
+}
+
+var recordingsPlayCmd = &cobra.Command{
+	Use:           "play <id>",
+	Short:         "Play a recorded session in the terminal",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := recordingsDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		rec, err := recording.Find(dir, args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Stop playing on ctrl+c
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		err = recording.Play(ctx, cmd.OutOrStdout(), rec.Path, playSpeed, playIdleLimit)
+		fmt.Fprintln(cmd.OutOrStdout())
+		if err != nil && !errors.Is(err, ctx.Err()) {
+			fmt.Fprintf(os.Stderr, "Error playing recording: %v\n", err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+// recordingsDir returns the recordings directory set in the application configuration
+func recordingsDir() (string, error) {
+	return recording.Dir(loadRecordingSettings())
+}
+
+// loadRecordingSettings returns the recording settings, or the defaults if the
+// application configuration cannot be read
+func loadRecordingSettings() config.RecordingSettings {
+	appConfig, err := config.LoadAppConfig()
+	if err != nil || appConfig == nil {
+		return config.GetDefaultAppConfig().Recording
+	}
+	return appConfig.Recording
+}
+
+// writeRecordings prints recordings as a table, only those of host when it is set
+func writeRecordings(out io.Writer, recordings []recording.Recording, host string) {
+	var shown []recording.Recording
+	for _, rec := range recordings {
+		if host == "" || rec.Host == host {
+			shown = append(shown, rec)
+		}
+	}
+	if len(shown) == 0 {
+		fmt.Fprintln(out, "No recordings found.")
+		return
+	}
+
+	idWidth, hostWidth := len("ID"), len("Host")
+	for _, rec := range shown {
+		idWidth = max(idWidth, len(rec.ID))
+		hostWidth = max(hostWidth, len(rec.Host))
+	}
+
+	fmt.Fprintf(out, "%-*s %-*s %-19s %-10s %s\n", idWidth, "ID", hostWidth, "Host", "Started", "Duration", "Size")
+	for _, rec := range shown {
+		fmt.Fprintf(out, "%-*s %-*s %-19s %-10s %s\n", idWidth, rec.ID, hostWidth, rec.Host,
+			rec.Started.Format("2006-01-02 15:04:05"), rec.Duration.Round(time.Second), formatRecordingSize(rec.Size))
+	}
+}
+
+// formatRecordingSize formats the size of a recording file
+func formatRecordingSize(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// shouldRecord reports whether a connection to hostName is recorded, because
+// --record was given or the host is tagged for recording
+func shouldRecord(hostName string) bool {
+	if !recordSession {
+		var host *config.SSHHost
+		var err error
+		if configFile != "" {
+			host, err = config.GetSSHHostFromFile(hostName, configFile)
+		} else {
+			host, err = config.GetSSHHost(hostName)
+		}
+		if err != nil || host == nil || !recording.Enabled(host.Tags) {
+			return false
+		}
+	}
+
+	if !recording.Supported {
+		fmt.Fprintln(os.Stderr, "Warning: session recording is not supported on this platform, connecting without recording")
+		return false
+	}
+	return true
+}
+
+// runRecordedSession runs ssh with args under a PTY, records the session and
+// returns the exit code of ssh. The connection is not made if it cannot be recorded.
+func runRecordedSession(hostName string, args []string) int {
+	settings := loadRecordingSettings()
+	dir, err := recording.Dir(settings)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not create the recordings directory: %v\n", err)
+		return 1
+	}
+
+	session := recording.NewCommand(exec.Command("ssh", args...), dir, hostName)
+	err = session.Run()
+
+	if session.Path() != "" {
+		fmt.Printf("Session recorded to %s\n", session.Path())
+	}
+	if _, pruneErr := recording.Prune(dir, settings); pruneErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not delete old recordings: %v\n", pruneErr)
+	}
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		fmt.Fprintf(os.Stderr, "Error recording the session: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func init() {
+	recordingsListCmd.Flags().StringVar(&recordingsHost, "host", "", "Only list the recordings of this host")
+	_ = recordingsListCmd.RegisterFlagCompletionFunc("host", completeHostNames)
+	recordingsPlayCmd.Flags().Float64Var(&playSpeed, "speed", 1, "Playback speed factor")
+	recordingsPlayCmd.Flags().DurationVar(&playIdleLimit, "idle-limit", 2*time.Second, "Shorten pauses longer than this (0 keeps them)")
+	recordingsCmd.AddCommand(recordingsListCmd, recordingsPlayCmd)
+	RootCmd.AddCommand(recordingsCmd)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/recording"
+)
+
+func TestRecordingsCommands(t *testing.T) {
+	subcommands := make(map[string]bool)
+	for _, sub := range recordingsCmd.Commands() {
+		subcommands[sub.Name()] = true
+	}
+	for _, name := range []string{"list", "play"} {
+		if !subcommands[name] {
+			t.Errorf("Expected 'recordings %s' command", name)
+		}
+	}
+
+	if recordingsPlayCmd.Flags().Lookup("speed") == nil || recordingsPlayCmd.Flags().Lookup("idle-limit") == nil {
+		t.Error("Expected --speed and --idle-limit flags on 'recordings play'")
+	}
+	if RootCmd.Flags().Lookup("record") == nil {
+		t.Error("Expected --record flag on the root command")
+	}
+}
+
+func TestWriteRecordings(t *testing.T) {
+	started := time.Date(2026, 10, 18, 15, 30, 0, 0, time.Local)
+	recordings := []recording.Recording{
+		{ID: "20261018-153000-prod-db", Host: "prod-db", Started: started, Duration: 95 * time.Second, Size: 2048},
+		{ID: "20261018-120000-web-1", Host: "web-1", Started: started.Add(-3 * time.Hour), Size: 100},
+	}
+
+	var buf bytes.Buffer
+	writeRecordings(&buf, recordings, "prod-db")
+	output := buf.String()
+	for _, expected := range []string{"20261018-153000-prod-db", "2026-10-18 15:30:00", "1m35s", "2.0 KB"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in output:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "web-1") {
+		t.Errorf("Expected only the recordings of prod-db, got:\n%s", output)
+	}
+
+	buf.Reset()
+	writeRecordings(&buf, nil, "")
+	if !strings.Contains(buf.String(), "No recordings found.") {
+		t.Errorf("Expected a message when there are no recordings, got %q", buf.String())
+	}
+}
@@ -1,17 +1,22 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
+	"os/signal"
 	"strings"
 	"syscall"
 
+	"github.com/Gu1llaum-3/sshm/internal/adhoc"
 	"github.com/Gu1llaum-3/sshm/internal/config"
 	"github.com/Gu1llaum-3/sshm/internal/history"
+	"github.com/Gu1llaum-3/sshm/internal/hook"
 	"github.com/Gu1llaum-3/sshm/internal/ui"
 
+	"github.com/charmbracelet/x/term"
 	"github.com/spf13/cobra"
 )
 
@@ -30,6 +35,9 @@ var searchMode bool
 // noUpdateCheck disables the async update check in the TUI
 var noUpdateCheck bool
 
+// recordSession records the session to an asciicast file
+var recordSession bool
+
 // RootCmd is the base command when called without any subcommands
 var RootCmd = &cobra.Command{
 	Use:   "sshm [host] [command...]",
@@ -40,6 +48,7 @@ Main usage:
   Running 'sshm' (without arguments) opens the interactive TUI window to browse, search, and connect to your SSH hosts graphically.
   Running 'sshm <host>' connects directly to the specified host and records the connection in your history.
   Running 'sshm <host> <command>' executes the command on the remote host and returns the output.
+  Running 'sshm user@host[:port]' or 'sshm ssh://user@host[:port]' connects to a host that is not in your config yet.
 
 You can also use sshm in CLI mode for other operations like adding, editing, or searching hosts.
 
@@ -50,7 +59,11 @@ Examples:
   sshm prod-server               # Connect to host interactively
   sshm prod-server uptime        # Execute 'uptime' on remote host
   sshm prod-server ls -la /var   # Execute command with arguments
-  sshm -t prod-server sudo reboot # Force TTY for interactive commands`,
+  sshm -t prod-server sudo reboot # Force TTY for interactive commands
+  sshm --record prod-server      # Record the session (see 'sshm recordings')
+  sshm --reconnect prod-server   # Reconnect when the connection drops
+  sshm deploy@10.0.0.5:2222      # Connect to a host not in the config
+  sshm --no-interactive prd-db   # Suggest hosts and exit on a typo instead of asking`,
 	Version:       AppVersion,
 	Args:          cobra.ArbitraryArgs,
 	SilenceUsage:  true,
@@ -165,19 +178,34 @@ func connectToHost(hostName string, remoteCommand []string) {
 	}
 
 	if !hostFound {
-		fmt.Printf("Error: Host '%s' not found in SSH configuration.\n", hostName)
-		fmt.Println("Use 'sshm' to see available hosts.")
+		if target, err := adhoc.Parse(hostName); err == nil {
+			connectToTarget(target, remoteCommand)
+			return
+		}
+
+		// Offer the hosts that may have been meant
+		var suggestions []hostSuggestion
+		if hosts, err := loadHosts(); err == nil {
+			suggestions = rankHosts(config.FilterVisibleHosts(hosts), hostName)
+		}
+		interactive := !noInteractive && term.IsTerminal(os.Stdin.Fd())
+		picked, ok := pickSuggestion(os.Stdin, os.Stdout, hostName, suggestions, interactive)
+		if !ok {
+			os.Exit(1)
+		}
+		hostName = picked
+	}
+
+	hooks, host := loadHooks(hostName)
+	if err := hooks.RunPreConnect(host, hook.Terminal()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	historyManager, err := history.NewHistoryManager()
-	if err != nil {
+	if historyManager, err := history.NewHistoryManager(); err != nil {
 		fmt.Printf("Warning: Could not initialize connection history: %v\n", err)
-	} else {
-		err = historyManager.RecordConnection(hostName)
-		if err != nil {
-			fmt.Printf("Warning: Could not record connection history: %v\n", err)
-		}
+	} else if err := historyManager.RecordConnection(hostName); err != nil {
+		fmt.Printf("Warning: Could not record connection history: %v\n", err)
 	}
 
 	var args []string
@@ -192,36 +220,84 @@ func connectToHost(hostName string, remoteCommand []string) {
 
 	args = append(args, hostName)
 
+	record := shouldRecord(hostName)
+
 	if len(remoteCommand) > 0 {
 		args = append(args, remoteCommand...)
+	} else if record {
+		fmt.Printf("Connecting to %s (recording)...\n", hostName)
 	} else {
 		fmt.Printf("Connecting to %s...\n", hostName)
 	}
 
-	sshPath, lookErr := exec.LookPath("ssh")
-	if lookErr == nil {
-		argv := append([]string{"ssh"}, args...)
-		// On Unix, Exec replaces the process and never returns on success.
-		// On Windows, Exec is not supported and returns an error; fall through to the exec.Command fallback.
-		_ = syscall.Exec(sshPath, argv, os.Environ())
+	// Recording, reconnecting and post-disconnect hooks need sshm to outlive ssh
+	if !record && !shouldReconnect(hostName) && len(hooks.PostDisconnect) == 0 {
+		if sshPath, err := exec.LookPath("ssh"); err == nil {
+			argv := append([]string{"ssh"}, args...)
+			// On Unix, Exec replaces the process and never returns on success.
+			// On Windows, Exec is not supported and returns an error; fall through to runSession.
+			_ = syscall.Exec(sshPath, argv, os.Environ())
+		}
 	}
 
-	// Fallback for Windows or if LookPath failed
+	os.Exit(runSession(hostName, args, hooks, host))
+}
+
+// runSession runs ssh with args for the host called name, recording the
+// session and reconnecting when the connection drops as configured for it,
+// each attempt being kept in history. It runs the post-disconnect hooks of
+// host once the session ends, and returns the exit code to exit with.
+func runSession(name string, args []string, hooks hook.Hooks, host config.SSHHost) int {
+	record := shouldRecord(name)
+	session := func() int {
+		if record {
+			return runRecordedSession(name, args)
+		}
+		return runSSH(args)
+	}
+
+	var exitCode int
+	if shouldReconnect(name) {
+		historyManager, err := history.NewHistoryManager()
+		if err != nil {
+			fmt.Printf("Warning: Could not initialize connection history: %v\n", err)
+		}
+
+		// Stay around ssh to start it again when the connection drops
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		exitCode = runWithReconnect(ctx, os.Stdout, name, newReconnectPolicy(loadReconnectSettings()), session, func(attempt, exitCode int) {
+			if historyManager == nil {
+				return
+			}
+			if err := historyManager.RecordAttempt(name, attempt, exitCode); err != nil {
+				fmt.Printf("Warning: Could not record connection history: %v\n", err)
+			}
+		})
+		stop()
+	} else {
+		exitCode = session()
+	}
+	return runPostDisconnectHooks(hooks, host, exitCode)
+}
+
+// runSSH runs ssh with args attached to the terminal and returns its exit code
+func runSSH(args []string) int {
 	sshCmd := exec.Command("ssh", args...)
 	sshCmd.Stdin = os.Stdin
 	sshCmd.Stdout = os.Stdout
 	sshCmd.Stderr = os.Stderr
 
-	err = sshCmd.Run()
+	err := sshCmd.Run()
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
-				os.Exit(status.ExitStatus())
+				return status.ExitStatus()
 			}
 		}
 		fmt.Printf("Error executing SSH command: %v\n", err)
-		os.Exit(1)
+		return 1
 	}
+	return 0
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -244,6 +320,9 @@ func Execute() {
 func init() {
 	RootCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "SSH config file to use (default: ~/.ssh/config)")
 	RootCmd.Flags().BoolVarP(&forceTTY, "tty", "t", false, "Force pseudo-TTY allocation (useful for interactive remote commands)")
+	RootCmd.Flags().BoolVar(&recordSession, "record", false, "Record the session to an asciicast file")
+	RootCmd.Flags().BoolVar(&reconnectSession, "reconnect", false, "Reconnect with increasing delays when the connection drops")
+	RootCmd.Flags().BoolVar(&noInteractive, "no-interactive", false, "Never prompt: list the hosts that may have been meant and exit when a host is not found")
 	RootCmd.PersistentFlags().BoolVarP(&searchMode, "search", "s", false, "Focus on search input at startup")
 	RootCmd.PersistentFlags().BoolVar(&noUpdateCheck, "no-update-check", false, "Disable automatic update check")
 
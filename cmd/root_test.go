@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+
+	"github.com/spf13/cobra"
 )
 
 func TestRootCommand(t *testing.T) {
@@ -45,7 +47,7 @@ func TestRootCommandFlags(t *testing.T) {
 func TestRootCommandSubcommands(t *testing.T) {
 	// Test that all expected subcommands are registered
 	// Note: completion and help are automatically added by Cobra and may not always appear in Commands()
-	expectedCommands := []string{"add", "edit", "search", "info"}
+	expectedCommands := []string{"add", "edit", "search", "info", "ping", "doctor", "scan", "exec", "broadcast", "cp", "recordings", "tunnel", "forward", "mux", "run"}
 
 	commands := RootCmd.Commands()
 	commandNames := make(map[string]bool)
@@ -153,3 +155,28 @@ func TestVersionVariable(t *testing.T) {
 		t.Logf("AppVersion is set to '%s' (expected 'dev' for development)", AppVersion)
 	}
 }
+
+func TestSubcommandFlagsDoNotShadowRootFlags(t *testing.T) {
+	// A local flag named like a persistent root flag would change the meaning
+	// of that flag depending on the subcommand
+	rootFlags := []string{"config", "search", "no-update-check"}
+
+	var check func(cmd *cobra.Command)
+	check = func(cmd *cobra.Command) {
+		local := cmd.LocalNonPersistentFlags()
+		for _, name := range rootFlags {
+			if local.Lookup(name) != nil {
+				t.Errorf("'%s' defines --%s, which shadows the root flag", cmd.CommandPath(), name)
+			}
+			if shorthand := RootCmd.PersistentFlags().Lookup(name).Shorthand; shorthand != "" && local.ShorthandLookup(shorthand) != nil {
+				t.Errorf("'%s' defines -%s, which shadows the root flag", cmd.CommandPath(), shorthand)
+			}
+		}
+		for _, sub := range cmd.Commands() {
+			check(sub)
+		}
+	}
+	for _, cmd := range RootCmd.Commands() {
+		check(cmd)
+	}
+}
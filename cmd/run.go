@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/remote"
+	"github.com/Gu1llaum-3/sshm/internal/snippet"
+
+	"github.com/spf13/cobra"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run <host> [snippet]",
+	Short: "Run a command snippet on a host",
+	Long: `Run a snippet, a saved command, on a host over ssh. Without a snippet name,
+list the snippets available for the host.
+
+Snippets are kept in snippets.json in the sshm config directory, as a list of
+objects with a name and a command. A snippet applies to every host unless it
+sets "hosts" (names or glob patterns) or "tags"; a scoped snippet replaces a
+global one of the same name. Set "tty" for interactive commands.
+
+The command may refer to the host with {{.Name}}, {{.Hostname}}, {{.User}},
+{{.Port}} and {{.Tags}}.
+
+  [
+    {"name": "disk", "command": "df -h", "description": "Disk usage"},
+    {"name": "logs", "command": "sudo journalctl -fu nginx", "tags": ["web"], "tty": true},
+    {"name": "health", "command": "curl -s http://{{.Hostname}}:8080/health", "hosts": ["api-*"]}
+  ]
+
+Examples:
+  sshm run web-1          # List the snippets for web-1
+  sshm run web-1 logs     # Run the logs snippet on web-1`,
+	Args:              cobra.RangeArgs(1, 2),
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	ValidArgsFunction: completeRunArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		hosts, err := loadHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading SSH config file: %v\n", err)
+			os.Exit(2)
+		}
+		selected, err := selectHosts(hosts, args[:1], nil, "")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		host := selected[0]
+
+		snippets, err := snippet.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		snippets = snippet.ForHost(snippets, host)
+
+		if len(args) == 1 {
+			writeSnippets(cmd.OutOrStdout(), host.Name, snippets)
+			return nil
+		}
+
+		s, ok := snippet.Find(snippets, args[1])
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: snippet '%s' not found for host '%s'\n", args[1], host.Name)
+			os.Exit(2)
+		}
+		os.Exit(runSnippet(cmd.OutOrStdout(), host, s))
+		return nil
+	},
+}
+
+// runSnippet runs a snippet on host with the terminal attached and returns the
+// exit code of the command
+func runSnippet(out io.Writer, host config.SSHHost, s snippet.Snippet) int {
+	args, err := s.SSHArgs(host, configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	sshCmd := remote.Command(context.Background(), args...)
+	sshCmd.Stdin = os.Stdin
+	sshCmd.Stdout = out
+	sshCmd.Stderr = os.Stderr
+
+	err = sshCmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		return exitErr.ExitCode()
+	}
+	fmt.Fprintf(os.Stderr, "Error executing SSH command: %v\n", err)
+	return 1
+}
+
+// writeSnippets prints the snippets available for a host
+func writeSnippets(out io.Writer, hostName string, snippets []snippet.Snippet) {
+	if len(snippets) == 0 {
+		path, _ := snippet.Path()
+		fmt.Fprintf(out, "No snippets for %s. Add them to %s (see 'sshm run --help').\n", hostName, path)
+		return
+	}
+
+	nameWidth := len("Snippet")
+	for _, s := range snippets {
+		nameWidth = max(nameWidth, len(s.Name))
+	}
+	fmt.Fprintf(out, "%-*s %s\n", nameWidth, "Snippet", "Command")
+	for _, s := range snippets {
+		line := s.Command
+		if s.Description != "" {
+			line = s.Description + " (" + s.Command + ")"
+		}
+		fmt.Fprintf(out, "%-*s %s\n", nameWidth, s.Name, line)
+	}
+}
+
+// completeRunArgs completes the host, then the snippets available for it
+func completeRunArgs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) == 0 {
+		return completeHostNames(cmd, args, toComplete)
+	}
+	if len(args) > 1 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+
+	hosts, err := loadHosts()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	selected, err := selectHosts(hosts, args[:1], nil, "")
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	snippets, err := snippet.Load()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var completions []string
+	for _, s := range snippet.ForHost(snippets, selected[0]) {
+		if strings.HasPrefix(s.Name, toComplete) {
+			completions = append(completions, s.Name)
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	RootCmd.AddCommand(runCmd)
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/snippet"
+)
+
+func TestRunSnippet(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ssh is a shell script")
+	}
+	// The remote command is the last argument, after "--"
+	fakeSSH(t, `echo "$@"; eval "exit \${$#}"`)
+
+	host := config.SSHHost{Name: "web", Hostname: "web.example.com"}
+
+	var buf bytes.Buffer
+	if code := runSnippet(&buf, host, snippet.Snippet{Name: "ok", Command: "0"}); code != 0 {
+		t.Errorf("Expected exit code 0, got %d", code)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "web -- 0" {
+		t.Errorf("Unexpected ssh arguments %q", got)
+	}
+
+	buf.Reset()
+	if code := runSnippet(&buf, host, snippet.Snippet{Name: "fail", Command: "3", TTY: true}); code != 3 {
+		t.Errorf("Expected the exit code of the command, got %d", code)
+	}
+	if got := strings.TrimSpace(buf.String()); got != "-t web -- 3" {
+		t.Errorf("Expected a terminal for a tty snippet, got %q", got)
+	}
+}
+
+func TestWriteSnippets(t *testing.T) {
+	snippets := []snippet.Snippet{
+		{Name: "disk", Command: "df -h", Description: "Disk usage"},
+		{Name: "uptime", Command: "uptime"},
+	}
+
+	var buf bytes.Buffer
+	writeSnippets(&buf, "web", snippets)
+	for _, expected := range []string{"disk", "Disk usage (df -h)", "uptime"} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("Expected %q in output:\n%s", expected, buf.String())
+		}
+	}
+
+	buf.Reset()
+	writeSnippets(&buf, "web", nil)
+	if !strings.Contains(buf.String(), "No snippets for web") {
+		t.Errorf("Expected a message when there are no snippets, got %q", buf.String())
+	}
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// scanTags selects hosts carrying one of these tags
+	scanTags []string
+	// scanFilter selects hosts matching this search query
+	scanFilter string
+	// scanJSON outputs one JSON object per host (NDJSON)
+	scanJSON bool
+	// scanTimeout is the timeout for each host scan
+	scanTimeout time.Duration
+)
+
+type scanJSONResult struct {
+	Host     string                       `json:"host"`
+	Address  *string                      `json:"address"`
+	Banner   *string                      `json:"banner"`
+	HostKeys []string                     `json:"host_keys"`
+	KEX      []string                     `json:"kex"`
+	Ciphers  []string                     `json:"ciphers"`
+	MACs     []string                     `json:"macs"`
+	Weak     []connectivity.WeakAlgorithm `json:"weak"`
+	Error    *string                      `json:"error"`
+	Reason   *string                      `json:"reason"`
+}
+
+// hostScan is the outcome of scanning one host
+type hostScan struct {
+	host config.SSHHost
+	scan *connectivity.ServerScan
+	err  error
+}
+
+var scanCmd = &cobra.Command{
+	Use:   "scan [host...]",
+	Short: "Fingerprint SSH servers and audit their algorithms",
+	Long: `Fingerprint SSH servers and audit the algorithms they offer.
+
+For each host, the server version banner and the host key types, key exchange
+methods, ciphers and MACs it offers are recorded. Deprecated algorithms are
+flagged: ssh-rsa (SHA-1) and DSA host keys, SHA-1 and 1024-bit key exchanges,
+CBC and RC4 ciphers, and MD5 or truncated MACs.
+
+Results are stored and shown in the host info view of the TUI. No
+authentication takes place on the scanned servers.
+
+The command exits with status 1 if a host could not be scanned or offers
+deprecated algorithms.
+
+Examples:
+  sshm scan web-1              # Scan a host
+  sshm scan --tag prod         # Scan hosts tagged "prod"
+  sshm scan --tag prod --json  # Stream results as NDJSON`,
+	Args:              cobra.ArbitraryArgs,
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	ValidArgsFunction: completeHostNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && len(scanTags) == 0 && scanFilter == "" {
+			fmt.Fprintln(os.Stderr, "Error: specify hosts to scan, or select them with --tag or --filter")
+			os.Exit(2)
+		}
+
+		hosts, err := loadHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading SSH config file: %v\n", err)
+			os.Exit(2)
+		}
+
+		selected, err := selectHosts(hosts, args, scanTags, scanFilter)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		if len(selected) == 0 {
+			fmt.Fprintln(os.Stderr, "No hosts matched.")
+			os.Exit(2)
+		}
+
+		pingManager := connectivity.NewPingManager(scanTimeout, configFile)
+		concurrency := config.GetDefaultPingSettings().Concurrency
+		if appConfig, err := config.LoadAppConfig(); err == nil {
+			concurrency = appConfig.Ping.Concurrency
+		}
+
+		results := scanHosts(cmd.Context(), pingManager, selected, concurrency)
+
+		var scans []*connectivity.ServerScan
+		for _, result := range results {
+			if result.scan != nil {
+				scans = append(scans, result.scan)
+			}
+		}
+		if err := connectivity.SaveScans(scans); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not store scan results: %v\n", err)
+		}
+
+		exitCode := writeScanResults(cmd.OutOrStdout(), results, scanJSON)
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+		return nil
+	},
+}
+
+// scanHosts scans hosts with at most concurrency scans at once and returns the results in host order
+func scanHosts(ctx context.Context, pingManager *connectivity.PingManager, hosts []config.SSHHost, concurrency int) []hostScan {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make([]hostScan, len(hosts))
+	limit := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Add(1)
+		go func(i int, host config.SSHHost) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			scan, err := pingManager.ScanHost(ctx, host)
+			results[i] = hostScan{host: host, scan: scan, err: err}
+		}(i, host)
+	}
+	wg.Wait()
+	return results
+}
+
+// writeScanResults prints scan results and returns the exit code:
+// 0 when every host was scanned and none offers deprecated algorithms, 1 otherwise
+func writeScanResults(out io.Writer, results []hostScan, asJSON bool) int {
+	exitCode := 0
+	for _, result := range results {
+		if result.err != nil || len(result.scan.Weak) > 0 {
+			exitCode = 1
+		}
+
+		if asJSON {
+			writeScanJSON(out, result)
+			continue
+		}
+
+		if result.err != nil {
+			reason := connectivity.ClassifyError(result.err)
+			fmt.Fprintf(out, "%s\n  error      %v", result.host.Name, result.err)
+			if reason != connectivity.ReasonNone {
+				fmt.Fprintf(out, " (%s)", reason)
+			}
+			fmt.Fprint(out, "\n\n")
+			continue
+		}
+
+		scan := result.scan
+		fmt.Fprintf(out, "%s (%s)\n", scan.Host, scan.Address)
+		fmt.Fprintf(out, "  banner     %s\n", scan.Banner)
+		fmt.Fprintf(out, "  host keys  %s\n", strings.Join(scan.HostKeys, ", "))
+		fmt.Fprintf(out, "  kex        %s\n", strings.Join(scan.KEX, ", "))
+		fmt.Fprintf(out, "  ciphers    %s\n", strings.Join(scan.Ciphers, ", "))
+		fmt.Fprintf(out, "  macs       %s\n", strings.Join(scan.MACs, ", "))
+		if len(scan.Weak) == 0 {
+			fmt.Fprintln(out, "  weak       none")
+		}
+		for _, weak := range scan.Weak {
+			fmt.Fprintf(out, "  weak       %s %s: %s\n", strings.ReplaceAll(weak.Kind, "_", " "), weak.Name, weak.Reason)
+		}
+		fmt.Fprintln(out)
+	}
+	return exitCode
+}
+
+// writeScanJSON writes a single scan result as one line of JSON
+func writeScanJSON(out io.Writer, result hostScan) {
+	res := scanJSONResult{
+		Host:   result.host.Name,
+		Error:  errorString(result.err),
+		Reason: maybeString(string(connectivity.ClassifyError(result.err))),
+	}
+	if scan := result.scan; scan != nil {
+		res.Address = maybeString(scan.Address)
+		res.Banner = maybeString(scan.Banner)
+		res.HostKeys = scan.HostKeys
+		res.KEX = scan.KEX
+		res.Ciphers = scan.Ciphers
+		res.MACs = scan.MACs
+		res.Weak = scan.Weak
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		return
+	}
+	_, _ = out.Write(append(b, '\n'))
+}
+
+func init() {
+	scanCmd.Flags().StringSliceVar(&scanTags, "tag", nil, "Scan hosts with this tag (repeatable)")
+	scanCmd.Flags().StringVar(&scanFilter, "filter", "", "Scan hosts matching this search query")
+	scanCmd.Flags().BoolVar(&scanJSON, "json", false, "Output one JSON object per host (NDJSON)")
+	scanCmd.Flags().DurationVar(&scanTimeout, "timeout", 5*time.Second, "Timeout for each host scan")
+	RootCmd.AddCommand(scanCmd)
+}
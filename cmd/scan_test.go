@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+)
+
+func TestScanCommandFlags(t *testing.T) {
+	if scanCmd.Use != "scan [host...]" {
+		t.Errorf("Expected Use 'scan [host...]', got '%s'", scanCmd.Use)
+	}
+
+	flags := scanCmd.Flags()
+	for _, name := range []string{"tag", "filter", "json", "timeout"} {
+		if flags.Lookup(name) == nil {
+			t.Errorf("Expected --%s flag to be defined", name)
+		}
+	}
+}
+
+func TestWriteScanResults(t *testing.T) {
+	clean := hostScan{
+		host: config.SSHHost{Name: "clean"},
+		scan: &connectivity.ServerScan{Host: "clean", Address: "10.0.0.1:22", Banner: "SSH-2.0-OpenSSH_9.6", HostKeys: []string{"ssh-ed25519"}},
+	}
+	weak := hostScan{
+		host: config.SSHHost{Name: "legacy"},
+		scan: &connectivity.ServerScan{
+			Host:   "legacy",
+			Banner: "SSH-2.0-OpenSSH_5.3",
+			Weak:   []connectivity.WeakAlgorithm{{Kind: "cipher", Name: "aes128-cbc", Reason: "CBC mode"}},
+		},
+	}
+	failed := hostScan{host: config.SSHHost{Name: "down"}, err: errors.New("connection refused")}
+
+	var buf bytes.Buffer
+	if exitCode := writeScanResults(&buf, []hostScan{clean}, false); exitCode != 0 {
+		t.Errorf("Expected exit code 0 for a clean scan, got %d", exitCode)
+	}
+	if !strings.Contains(buf.String(), "SSH-2.0-OpenSSH_9.6") || !strings.Contains(buf.String(), "weak       none") {
+		t.Errorf("Unexpected text output: %q", buf.String())
+	}
+
+	buf.Reset()
+	if exitCode := writeScanResults(&buf, []hostScan{clean, weak, failed}, true); exitCode != 1 {
+		t.Errorf("Expected exit code 1 with weak algorithms or errors, got %d", exitCode)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 NDJSON lines, got %d", len(lines))
+	}
+	var res scanJSONResult
+	if err := json.Unmarshal([]byte(lines[1]), &res); err != nil {
+		t.Fatalf("Line is not valid JSON: %v", err)
+	}
+	if res.Host != "legacy" || len(res.Weak) != 1 || res.Weak[0].Name != "aes128-cbc" {
+		t.Errorf("Unexpected JSON result: %+v", res)
+	}
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+// noInteractive makes sshm print suggestions instead of asking which host was meant
+var noInteractive bool
+
+// maxSuggestions is how many hosts are suggested for an unknown one
+const maxSuggestions = 5
+
+// hostSuggestion is a host that may be the one meant, the lower the score the closer
+type hostSuggestion struct {
+	host  config.SSHHost
+	score int
+	near  bool // the name is within a few edits of the query
+}
+
+// rankHosts returns the hosts close to query, closest first. Names, hostnames
+// and tags are compared by edit distance and substring match, a match on the
+// name counting more than one on the hostname, itself more than one on a tag.
+func rankHosts(hosts []config.SSHHost, query string) []hostSuggestion {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+	maxEdits := max(1, len([]rune(query))/3)
+
+	var suggestions []hostSuggestion
+	for _, host := range hosts {
+		best := -1
+		near := false
+		match := func(field string, penalty int) {
+			field = strings.ToLower(field)
+			if field == "" {
+				return
+			}
+			score := -1
+			if d := editDistance(query, field); d <= maxEdits {
+				score = d + penalty
+			} else if strings.Contains(field, query) || (len(field) >= 3 && strings.Contains(query, field)) {
+				score = maxEdits + 1 + penalty
+			}
+			if score >= 0 && (best < 0 || score < best) {
+				best = score
+			}
+		}
+
+		match(host.Name, 0)
+		near = best >= 0 && best <= maxEdits
+		match(host.Hostname, 1)
+		for _, tag := range host.Tags {
+			match(tag, 2)
+		}
+		if best >= 0 {
+			suggestions = append(suggestions, hostSuggestion{host: host, score: best, near: near})
+		}
+	}
+
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].score != suggestions[j].score {
+			return suggestions[i].score < suggestions[j].score
+		}
+		return suggestions[i].host.Name < suggestions[j].host.Name
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
+
+// strongMatch reports whether the first suggestion is clearly the host meant:
+// a name within a few edits of the query, closer than the other suggestions
+func strongMatch(suggestions []hostSuggestion) bool {
+	if len(suggestions) == 0 || !suggestions[0].near {
+		return false
+	}
+	return len(suggestions) == 1 || suggestions[0].score < suggestions[1].score
+}
+
+// editDistance returns the number of insertions, deletions, substitutions and
+// transpositions of adjacent characters turning a into b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+	return prev[len(rb)]
+}
+
+// pickSuggestion tells that hostName is not in the config and offers the
+// hosts that may have been meant: a confirmation for a strong match, a
+// numbered choice otherwise. Without interaction, suggestions are only
+// listed. It returns the host picked, if any.
+func pickSuggestion(in io.Reader, out io.Writer, hostName string, suggestions []hostSuggestion, interactive bool) (string, bool) {
+	if len(suggestions) == 0 {
+		fmt.Fprintf(out, "Error: Host '%s' not found in SSH configuration.\n", hostName)
+		fmt.Fprintln(out, "Use 'sshm' to see available hosts.")
+		return "", false
+	}
+
+	if !interactive {
+		fmt.Fprintf(out, "Error: Host '%s' not found in SSH configuration.\n", hostName)
+		fmt.Fprintln(out, "Did you mean:")
+		writeSuggestions(out, suggestions, false)
+		return "", false
+	}
+
+	reader := bufio.NewReader(in)
+	fmt.Fprintf(out, "Host '%s' not found in SSH configuration.\n", hostName)
+
+	if strongMatch(suggestions) {
+		name := suggestions[0].host.Name
+		fmt.Fprintf(out, "Connect to %s? [Y/n]: ", name)
+		response, _ := reader.ReadString('\n')
+		response = strings.ToLower(strings.TrimSpace(response))
+		if response != "" && response != "y" && response != "yes" {
+			return "", false
+		}
+		return name, true
+	}
+
+	fmt.Fprintln(out, "Did you mean:")
+	writeSuggestions(out, suggestions, true)
+	fmt.Fprintf(out, "Connect to [1-%d, Enter to cancel]: ", len(suggestions))
+	response, _ := reader.ReadString('\n')
+	n, err := strconv.Atoi(strings.TrimSpace(response))
+	if err != nil || n < 1 || n > len(suggestions) {
+		return "", false
+	}
+	return suggestions[n-1].host.Name, true
+}
+
+// writeSuggestions lists suggested hosts with their hostname, numbered for a choice
+func writeSuggestions(out io.Writer, suggestions []hostSuggestion, numbered bool) {
+	nameWidth := 0
+	for _, s := range suggestions {
+		nameWidth = max(nameWidth, len(s.host.Name))
+	}
+	for i, s := range suggestions {
+		prefix := "  "
+		if numbered {
+			prefix = fmt.Sprintf("  %d) ", i+1)
+		}
+		line := fmt.Sprintf("%s%-*s  %s", prefix, nameWidth, s.host.Name, s.host.Hostname)
+		fmt.Fprintln(out, strings.TrimRight(line, " "))
+	}
+}
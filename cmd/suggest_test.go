@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"prod-db", "prod-db", 0},
+		{"prd-db", "prod-db", 1},
+		{"prdo-db", "prod-db", 1},
+		{"web", "web-01", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func suggestionNames(suggestions []hostSuggestion) []string {
+	var names []string
+	for _, s := range suggestions {
+		names = append(names, s.host.Name)
+	}
+	return names
+}
+
+func TestRankHosts(t *testing.T) {
+	hosts := []config.SSHHost{
+		{Name: "prod-db", Hostname: "db1.example.com", Tags: []string{"database"}},
+		{Name: "prod-web", Hostname: "web1.example.com", Tags: []string{"web"}},
+		{Name: "staging-web", Hostname: "web2.example.com", Tags: []string{"web"}},
+		{Name: "backup", Hostname: "nas.local"},
+	}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"prd-db", "prod-db"},
+		{"PROD-DB2", "prod-db"},
+		{"web", "prod-web staging-web"},
+		{"nas.locla", "backup"},
+		{"databse", "prod-db"},
+		{"zzz", ""},
+	}
+	for _, tt := range tests {
+		got := strings.Join(suggestionNames(rankHosts(hosts, tt.query)), " ")
+		if got != tt.want {
+			t.Errorf("rankHosts(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+
+	if !strongMatch(rankHosts(hosts, "prd-db")) {
+		t.Error("Expected prd-db to be a strong match for prod-db")
+	}
+	// Matches on a hostname, a tag or part of a name are offered to pick from
+	for _, query := range []string{"web", "nas.locla", "databse", "staging"} {
+		if strongMatch(rankHosts(hosts, query)) {
+			t.Errorf("Expected %s not to be a strong match", query)
+		}
+	}
+}
+
+func TestPickSuggestion(t *testing.T) {
+	one := []hostSuggestion{{host: config.SSHHost{Name: "prod-db", Hostname: "db1.example.com"}, score: 1, near: true}}
+	several := []hostSuggestion{
+		{host: config.SSHHost{Name: "prod-web", Hostname: "web1.example.com"}, score: 2},
+		{host: config.SSHHost{Name: "staging-web", Hostname: "web2.example.com"}, score: 2},
+	}
+
+	var out bytes.Buffer
+	picked, ok := pickSuggestion(strings.NewReader("\n"), &out, "prd-db", one, true)
+	if !ok || picked != "prod-db" {
+		t.Errorf("pickSuggestion() = %q, %v, want prod-db", picked, ok)
+	}
+	if !strings.Contains(out.String(), "Connect to prod-db? [Y/n]") {
+		t.Errorf("Expected a confirmation, got %q", out.String())
+	}
+	if _, ok := pickSuggestion(strings.NewReader("n\n"), &out, "prd-db", one, true); ok {
+		t.Error("Expected a declined suggestion not to be picked")
+	}
+
+	// A single host matched by a tag is picked by number
+	out.Reset()
+	tagged := []hostSuggestion{{host: config.SSHHost{Name: "prod-db", Hostname: "db1.example.com"}, score: 4}}
+	picked, ok = pickSuggestion(strings.NewReader("1\n"), &out, "databse", tagged, true)
+	if !ok || picked != "prod-db" || !strings.Contains(out.String(), "  1) prod-db") {
+		t.Errorf("Expected prod-db to be picked from a numbered list, got %q, %v (%q)", picked, ok, out.String())
+	}
+
+	out.Reset()
+	picked, ok = pickSuggestion(strings.NewReader("2\n"), &out, "web", several, true)
+	if !ok || picked != "staging-web" {
+		t.Errorf("pickSuggestion() = %q, %v, want staging-web", picked, ok)
+	}
+	if !strings.Contains(out.String(), "  2) staging-web  web2.example.com") {
+		t.Errorf("Expected numbered suggestions, got %q", out.String())
+	}
+	for _, input := range []string{"\n", "3\n", "x\n", ""} {
+		if _, ok := pickSuggestion(strings.NewReader(input), &out, "web", several, true); ok {
+			t.Errorf("Expected input %q to cancel", input)
+		}
+	}
+
+	out.Reset()
+	if _, ok := pickSuggestion(strings.NewReader("y\n"), &out, "prd-db", one, false); ok {
+		t.Error("Expected no host to be picked without interaction")
+	}
+	want := "Error: Host 'prd-db' not found in SSH configuration.\nDid you mean:\n  prod-db  db1.example.com\n"
+	if out.String() != want {
+		t.Errorf("Expected the suggestions to be listed, got %q", out.String())
+	}
+
+	out.Reset()
+	if _, ok := pickSuggestion(strings.NewReader(""), &out, "zzz", nil, true); ok {
+		t.Error("Expected no host to be picked without suggestions")
+	}
+	if !strings.Contains(out.String(), "Use 'sshm' to see available hosts.") {
+		t.Errorf("Unexpected output %q", out.String())
+	}
+}
@@ -0,0 +1,399 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// tunnelLocal, tunnelRemote and tunnelDynamic are the forwards of a new tunnel
+	tunnelLocal   []string
+	tunnelRemote  []string
+	tunnelDynamic []string
+	// tunnelName names a new tunnel instead of <host>-<port>
+	tunnelName string
+	// tunnelStopAll stops every tunnel
+	tunnelStopAll bool
+	// tunnelFollow keeps printing the log of a tunnel as it grows
+	tunnelFollow bool
+)
+
+// tunnelStartWait is how long 'tunnel start' waits to report whether ssh connected
+const tunnelStartWait = 5 * time.Second
+
+var tunnelCmd = &cobra.Command{
+	Use:   "tunnel",
+	Short: "Run port forwards in the background",
+	Long: `Run port forwards as background ssh processes that are restarted when the
+connection drops.
+
+Each tunnel is kept up by a supervisor process running 'ssh -N' with the
+forwards. Its state and log are kept in the tunnels/ directory of the sshm
+config directory.
+
+Examples:
+  sshm tunnel start db -L 5432:localhost:5432      # Forward local port 5432
+  sshm tunnel start web -R 9000:localhost:3000     # Forward port 9000 of web here
+  sshm tunnel start bastion -D 1080 --name socks   # SOCKS proxy named "socks"
+  sshm tunnel list                                 # Show tunnels with ports and uptime
+  sshm tunnel logs db-5432 -f                      # Follow the log of a tunnel
+  sshm tunnel stop db-5432                         # Stop a tunnel
+  sshm tunnel stop --all                           # Stop all tunnels`,
+}
+
+var tunnelStartCmd = &cobra.Command{
+	Use:               "start <host> [-L spec]... [-R spec]... [-D spec]...",
+	Short:             "Start a background tunnel to a host",
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	ValidArgsFunction: completeHostNames,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		forwards, err := parseTunnelForwards(tunnelLocal, tunnelRemote, tunnelDynamic)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		hosts, err := loadHosts()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading SSH config file: %v\n", err)
+			os.Exit(2)
+		}
+		if _, err := selectHosts(hosts, args, nil, ""); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		forwards, err = checkForwardPorts(os.Stdin, os.Stderr, forwards, term.IsTerminal(os.Stdin.Fd()))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		t := &tunnel.Tunnel{ID: tunnelName, Host: args[0], ConfigFile: configFile, Forwards: forwards}
+		os.Exit(runTunnelStart(cmd.OutOrStdout(), t))
+		return nil
+	},
+}
+
+// runTunnelStart starts t in the background, reports whether ssh connected and
+// returns the exit code
+func runTunnelStart(out io.Writer, t *tunnel.Tunnel) int {
+	dir, err := tunnel.Dir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: could not find the sshm executable: %v\n", err)
+		return 1
+	}
+
+	// The supervisor may outlive the working directory
+	if t.ConfigFile != "" {
+		if abs, err := filepath.Abs(t.ConfigFile); err == nil {
+			t.ConfigFile = abs
+		}
+	}
+
+	if err := tunnel.Start(dir, t, executable); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(out, "Tunnel %s started: %s\n", t.ID, t.Ports())
+
+	state, err := tunnel.WaitStarted(dir, t.ID, tunnelStartWait)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	reportTunnelStart(out, state, dir)
+	return 0
+}
+
+// reportTunnelStart tells whether a new tunnel connected
+func reportTunnelStart(out io.Writer, t *tunnel.Tunnel, dir string) {
+	switch t.State {
+	case tunnel.StateConnected:
+		fmt.Fprintln(out, "Connected.")
+	case tunnel.StateStarting, tunnel.StateConnecting:
+		fmt.Fprintf(out, "Still connecting, see 'sshm tunnel list' for its state.\n")
+	case tunnel.StateReconnecting:
+		fmt.Fprintf(out, "Warning: ssh exited (%s), retrying in the background.\n", t.LastError)
+		fmt.Fprintf(out, "See %s or 'sshm tunnel logs %s'.\n", tunnel.LogPath(dir, t.ID), t.ID)
+	default:
+		fmt.Fprintf(out, "Warning: the tunnel is %s, see 'sshm tunnel logs %s'.\n", t.State, t.ID)
+	}
+}
+
+var tunnelStopCmd = &cobra.Command{
+	Use:               "stop <id|host>...",
+	Short:             "Stop background tunnels",
+	Long:              "Stop tunnels by ID, or all the tunnels of a host when given a host name.",
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	ValidArgsFunction: completeTunnelIDs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 && !tunnelStopAll {
+			fmt.Fprintln(os.Stderr, "Error: give tunnel IDs or host names, or --all")
+			os.Exit(2)
+		}
+		dir, err := tunnel.Dir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(runTunnelStop(cmd.OutOrStdout(), dir, args, tunnelStopAll))
+		return nil
+	},
+}
+
+// runTunnelStop stops the tunnels matching args, or all of them, and returns the exit code
+func runTunnelStop(out io.Writer, dir string, args []string, all bool) int {
+	tunnels, err := tunnel.List(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading tunnels: %v\n", err)
+		return 1
+	}
+
+	var ids []string
+	if all {
+		for _, t := range tunnels {
+			ids = append(ids, t.ID)
+		}
+	} else {
+		for _, arg := range args {
+			matched := false
+			for _, t := range tunnels {
+				if t.ID == arg || (t.Host == arg && !containsTunnelID(tunnels, arg)) {
+					ids = append(ids, t.ID)
+					matched = true
+				}
+			}
+			if !matched {
+				fmt.Fprintf(os.Stderr, "Error: no tunnel matches '%s'\n", arg)
+				return 1
+			}
+		}
+	}
+	if len(ids) == 0 {
+		fmt.Fprintln(out, "No tunnels running.")
+		return 0
+	}
+
+	code := 0
+	for _, id := range ids {
+		if err := tunnel.Stop(dir, id); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			code = 1
+			continue
+		}
+		fmt.Fprintf(out, "Tunnel %s stopped\n", id)
+	}
+	return code
+}
+
+// containsTunnelID reports whether a tunnel has this ID
+func containsTunnelID(tunnels []tunnel.Tunnel, id string) bool {
+	for _, t := range tunnels {
+		if t.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+var tunnelListCmd = &cobra.Command{
+	Use:           "list",
+	Short:         "List background tunnels with their ports and uptime",
+	Args:          cobra.NoArgs,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := tunnel.Dir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		tunnels, err := tunnel.List(dir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading tunnels: %v\n", err)
+			os.Exit(1)
+		}
+		writeTunnels(cmd.OutOrStdout(), tunnels, time.Now())
+		return nil
+	},
+}
+
+// writeTunnels prints tunnels as a table
+func writeTunnels(out io.Writer, tunnels []tunnel.Tunnel, now time.Time) {
+	if len(tunnels) == 0 {
+		fmt.Fprintln(out, "No tunnels running.")
+		return
+	}
+
+	idWidth, hostWidth := len("ID"), len("Host")
+	for _, t := range tunnels {
+		idWidth = max(idWidth, len(t.ID))
+		hostWidth = max(hostWidth, len(t.Host))
+	}
+
+	fmt.Fprintf(out, "%-*s %-*s %-12s %-8s %-8s %s\n", idWidth, "ID", hostWidth, "Host", "State", "Uptime", "Restarts", "Forwards")
+	for _, t := range tunnels {
+		fmt.Fprintf(out, "%-*s %-*s %-12s %-8s %-8d %s\n", idWidth, t.ID, hostWidth, t.Host,
+			t.State, tunnel.FormatUptime(t.Uptime(now)), t.Restarts, t.Ports())
+		if t.State != tunnel.StateConnected && t.LastError != "" {
+			fmt.Fprintf(out, "%-*s   last error: %s\n", idWidth, "", t.LastError)
+		}
+	}
+}
+
+var tunnelLogsCmd = &cobra.Command{
+	Use:               "logs <id>",
+	Short:             "Show the log of a tunnel",
+	Args:              cobra.ExactArgs(1),
+	SilenceUsage:      true,
+	SilenceErrors:     true,
+	ValidArgsFunction: completeTunnelIDs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := tunnel.Dir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		// Stop following on ctrl+c
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		if err := printTunnelLog(ctx, cmd.OutOrStdout(), tunnel.LogPath(dir, args[0]), tunnelFollow); err != nil {
+			if os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "Error: no log for tunnel '%s'\n", args[0])
+			} else {
+				fmt.Fprintf(os.Stderr, "Error reading the log: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+// printTunnelLog copies a log file to out, then keeps copying what is appended
+// to it until ctx is done when follow is set
+func printTunnelLog(ctx context.Context, out io.Writer, path string, follow bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for {
+		if _, err := io.Copy(out, file); err != nil {
+			return err
+		}
+		if !follow {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// tunnelRunCmd is the supervisor process started by 'tunnel start'
+var tunnelRunCmd = &cobra.Command{
+	Use:           "run <id>",
+	Short:         "Supervise a tunnel (used internally by 'tunnel start')",
+	Args:          cobra.ExactArgs(1),
+	Hidden:        true,
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := tunnel.Dir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if err := tunnel.Supervise(ctx, dir, args[0], os.Stderr); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+// parseTunnelForwards parses the -L, -R and -D specs of a tunnel
+func parseTunnelForwards(locals, remotes, dynamics []string) ([]tunnel.Forward, error) {
+	var forwards []tunnel.Forward
+	for _, group := range []struct {
+		forwardType string
+		specs       []string
+	}{
+		{tunnel.Local, locals},
+		{tunnel.Remote, remotes},
+		{tunnel.Dynamic, dynamics},
+	} {
+		for _, spec := range group.specs {
+			f, err := tunnel.ParseForward(group.forwardType, spec)
+			if err != nil {
+				return nil, err
+			}
+			forwards = append(forwards, f)
+		}
+	}
+	if len(forwards) == 0 {
+		return nil, fmt.Errorf("at least one forward is required (-L, -R or -D)")
+	}
+	return forwards, nil
+}
+
+// completeTunnelIDs completes the IDs of known tunnels
+func completeTunnelIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	dir, err := tunnel.Dir()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+	tunnels, err := tunnel.List(dir)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveError
+	}
+
+	var completions []string
+	for _, t := range tunnels {
+		if strings.HasPrefix(t.ID, toComplete) {
+			completions = append(completions, t.ID+"\t"+t.Ports())
+		}
+	}
+	return completions, cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	tunnelStartCmd.Flags().StringArrayVarP(&tunnelLocal, "local", "L", nil, "Local forward [bind_address:]port:host:hostport")
+	tunnelStartCmd.Flags().StringArrayVarP(&tunnelRemote, "remote", "R", nil, "Remote forward [bind_address:]port:host:hostport")
+	tunnelStartCmd.Flags().StringArrayVarP(&tunnelDynamic, "dynamic", "D", nil, "Dynamic (SOCKS) forward [bind_address:]port")
+	tunnelStartCmd.Flags().StringVar(&tunnelName, "name", "", "Name of the tunnel (default <host>-<port>)")
+	tunnelStopCmd.Flags().BoolVar(&tunnelStopAll, "all", false, "Stop all tunnels")
+	tunnelLogsCmd.Flags().BoolVarP(&tunnelFollow, "follow", "f", false, "Keep printing the log as it grows")
+	tunnelCmd.AddCommand(tunnelStartCmd, tunnelStopCmd, tunnelListCmd, tunnelLogsCmd, tunnelRunCmd)
+	RootCmd.AddCommand(tunnelCmd)
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
+)
+
+func TestTunnelCommands(t *testing.T) {
+	subcommands := make(map[string]bool)
+	for _, sub := range tunnelCmd.Commands() {
+		subcommands[sub.Name()] = true
+	}
+	for _, name := range []string{"start", "stop", "list", "logs", "run"} {
+		if !subcommands[name] {
+			t.Errorf("Expected 'tunnel %s' command", name)
+		}
+	}
+	if !tunnelRunCmd.Hidden {
+		t.Error("Expected 'tunnel run' to be hidden")
+	}
+
+	for _, flag := range []string{"local", "remote", "dynamic", "name"} {
+		if tunnelStartCmd.Flags().Lookup(flag) == nil {
+			t.Errorf("Expected --%s flag on 'tunnel start'", flag)
+		}
+	}
+	if tunnelStartCmd.Flags().ShorthandLookup("L") == nil {
+		t.Error("Expected -L shorthand on 'tunnel start'")
+	}
+}
+
+func TestParseTunnelForwards(t *testing.T) {
+	forwards, err := parseTunnelForwards([]string{"5432:localhost:5432"}, []string{"9000:localhost:3000"}, []string{"1080"})
+	if err != nil {
+		t.Fatalf("parseTunnelForwards() error = %v", err)
+	}
+	if len(forwards) != 3 || forwards[0].Type != tunnel.Local || forwards[1].Type != tunnel.Remote || forwards[2].Type != tunnel.Dynamic {
+		t.Errorf("Expected local, remote and dynamic forwards, got %+v", forwards)
+	}
+
+	if _, err := parseTunnelForwards(nil, nil, nil); err == nil {
+		t.Error("Expected an error without forwards")
+	}
+	if _, err := parseTunnelForwards([]string{"5432"}, nil, nil); err == nil {
+		t.Error("Expected an error for an incomplete local forward")
+	}
+}
+
+func TestWriteTunnels(t *testing.T) {
+	now := time.Now()
+	tunnels := []tunnel.Tunnel{
+		{ID: "db-5432", Host: "db", State: tunnel.StateConnected, ConnectedAt: now.Add(-90 * time.Second),
+			Forwards: []tunnel.Forward{{Type: tunnel.Local, LocalPort: "5432", RemoteHost: "localhost", RemotePort: "5432"}}},
+		{ID: "socks", Host: "bastion", State: tunnel.StateReconnecting, Restarts: 2, LastError: "Connection refused",
+			Forwards: []tunnel.Forward{{Type: tunnel.Dynamic, LocalPort: "1080"}}},
+	}
+
+	var buf bytes.Buffer
+	writeTunnels(&buf, tunnels, now)
+	output := buf.String()
+	for _, expected := range []string{"db-5432", "connected", "1m30s", "L 5432 → localhost:5432", "reconnecting", "D 1080 (SOCKS)", "last error: Connection refused"} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Expected %q in output:\n%s", expected, output)
+		}
+	}
+
+	buf.Reset()
+	writeTunnels(&buf, nil, now)
+	if !strings.Contains(buf.String(), "No tunnels running.") {
+		t.Errorf("Expected a message when there are no tunnels, got %q", buf.String())
+	}
+}
+
+func TestRunTunnelStop(t *testing.T) {
+	dir := t.TempDir()
+	for _, tn := range []*tunnel.Tunnel{
+		{ID: "db-5432", Host: "db", State: tunnel.StateStopped},
+		{ID: "db-6379", Host: "db", State: tunnel.StateStopped},
+		{ID: "web-8080", Host: "web", State: tunnel.StateStopped},
+	} {
+		if err := tunnel.Save(dir, tn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if code := runTunnelStop(&buf, dir, []string{"missing"}, false); code != 1 {
+		t.Errorf("Expected exit code 1 for an unknown tunnel, got %d", code)
+	}
+
+	buf.Reset()
+	if code := runTunnelStop(&buf, dir, []string{"db"}, false); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if !strings.Contains(buf.String(), "db-5432 stopped") || !strings.Contains(buf.String(), "db-6379 stopped") {
+		t.Errorf("Expected both tunnels of db to be stopped, got:\n%s", buf.String())
+	}
+
+	tunnels, _ := tunnel.List(dir)
+	if len(tunnels) != 1 || tunnels[0].ID != "web-8080" {
+		t.Errorf("Expected only web-8080 to remain, got %+v", tunnels)
+	}
+}
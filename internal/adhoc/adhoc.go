@@ -0,0 +1,159 @@
+package adhoc
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/validation"
+)
+
+// Target is a host given on the spot as user@host[:port] or
+// ssh://[user@]host[:port] rather than by a name of the SSH config
+type Target struct {
+	User string
+	Host string
+	Port string
+}
+
+// Parse parses an ad-hoc target. Plain host names are not targets: they are
+// looked up in the SSH config instead.
+func Parse(s string) (Target, error) {
+	s = strings.TrimSpace(s)
+
+	var t Target
+	if strings.HasPrefix(s, "ssh://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return Target{}, fmt.Errorf("invalid ssh URI '%s': %w", s, err)
+		}
+		if (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+			return Target{}, fmt.Errorf("invalid ssh URI '%s': only ssh://[user@]host[:port] is supported", s)
+		}
+		if u.User != nil {
+			t.User = u.User.Username()
+			if t.User == "" {
+				return Target{}, fmt.Errorf("invalid ssh URI '%s': empty user", s)
+			}
+		}
+		t.Host, t.Port = u.Hostname(), u.Port()
+	} else {
+		at := strings.LastIndex(s, "@")
+		if at < 0 {
+			return Target{}, fmt.Errorf("'%s' is not a target, expected user@host[:port] or ssh://[user@]host[:port]", s)
+		}
+		t.User = s[:at]
+		if t.User == "" {
+			return Target{}, fmt.Errorf("invalid target '%s': empty user", s)
+		}
+		var err error
+		if t.Host, t.Port, err = splitHostPort(s[at+1:]); err != nil {
+			return Target{}, fmt.Errorf("invalid target '%s': %w", s, err)
+		}
+	}
+
+	if strings.ContainsAny(t.User, " \t@:/") {
+		return Target{}, fmt.Errorf("invalid user '%s'", t.User)
+	}
+	if t.Host == "" || strings.HasPrefix(t.Host, "-") ||
+		(!validation.ValidateHostname(t.Host) && !validation.ValidateIP(t.Host)) {
+		return Target{}, fmt.Errorf("invalid host in '%s'", s)
+	}
+	if t.Port == "22" {
+		t.Port = ""
+	}
+	if !validation.ValidatePort(t.Port) {
+		return Target{}, fmt.Errorf("invalid port '%s', must be between 1 and 65535", t.Port)
+	}
+	return t, nil
+}
+
+// splitHostPort splits host[:port], host being a name, an IPv4 address or an
+// IPv6 address, in brackets when a port follows it
+func splitHostPort(s string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(s, "["):
+		end := strings.Index(s, "]")
+		if end < 0 {
+			return "", "", fmt.Errorf("missing ']' in '%s'", s)
+		}
+		host, rest := s[1:end], s[end+1:]
+		if rest == "" {
+			return host, "", nil
+		}
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", fmt.Errorf("unexpected '%s' after the address", rest)
+		}
+		return host, rest[1:], nil
+	case strings.Count(s, ":") == 1:
+		host, port, _ := strings.Cut(s, ":")
+		return host, port, nil
+	}
+	// No port, or an IPv6 address without brackets
+	return s, "", nil
+}
+
+// String returns the target as user@host[:port], as it is kept in history
+func (t Target) String() string {
+	var b strings.Builder
+	if t.User != "" {
+		b.WriteString(t.User + "@")
+	}
+	if t.Port == "" {
+		b.WriteString(t.Host)
+	} else {
+		b.WriteString(net.JoinHostPort(t.Host, t.Port))
+	}
+	return b.String()
+}
+
+// Args returns the ssh arguments connecting to the target. The host follows
+// "--" so that it is never taken for an option.
+func (t Target) Args() []string {
+	var args []string
+	if t.Port != "" {
+		args = append(args, "-p", t.Port)
+	}
+	if t.User != "" {
+		args = append(args, "-l", t.User)
+	}
+	return append(args, "--", t.Host)
+}
+
+// SSHHost returns the target as a host of the SSH config called alias
+func (t Target) SSHHost(alias string) config.SSHHost {
+	return config.SSHHost{
+		Name:     alias,
+		Hostname: t.Host,
+		User:     t.User,
+		Port:     t.Port,
+	}
+}
+
+// SuggestAlias returns a name for the target that none of hosts uses: the
+// first label of its host name, or its address with dashes
+func (t Target) SuggestAlias(hosts []config.SSHHost) string {
+	alias := t.Host
+	if validation.ValidateIP(alias) {
+		alias = strings.NewReplacer(".", "-", ":", "-").Replace(strings.Trim(alias, ":"))
+	} else if label, _, found := strings.Cut(alias, "."); found && label != "" {
+		alias = label
+	}
+
+	taken := make(map[string]bool)
+	for _, host := range hosts {
+		taken[host.Name] = true
+	}
+	if !taken[alias] {
+		return alias
+	}
+	for i := 2; ; i++ {
+		candidate := alias + "-" + strconv.Itoa(i)
+		if !taken[candidate] {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package adhoc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Target
+		str   string
+	}{
+		{"deploy@db.example.com", Target{User: "deploy", Host: "db.example.com"}, "deploy@db.example.com"},
+		{"deploy@db.example.com:2222", Target{User: "deploy", Host: "db.example.com", Port: "2222"}, "deploy@db.example.com:2222"},
+		{"root@10.0.0.5:22", Target{User: "root", Host: "10.0.0.5"}, "root@10.0.0.5"},
+		{"admin@[fe80::1]:2200", Target{User: "admin", Host: "fe80::1", Port: "2200"}, "admin@[fe80::1]:2200"},
+		{"admin@::1", Target{User: "admin", Host: "::1"}, "admin@::1"},
+		{"ssh://deploy@web.example.com:2022", Target{User: "deploy", Host: "web.example.com", Port: "2022"}, "deploy@web.example.com:2022"},
+		{"ssh://web.example.com/", Target{Host: "web.example.com"}, "web.example.com"},
+		{"ssh://me@[::1]:22", Target{User: "me", Host: "::1"}, "me@::1"},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) error = %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+		if got.String() != tt.str {
+			t.Errorf("Parse(%q).String() = %q, want %q", tt.input, got.String(), tt.str)
+		}
+	}
+
+	for _, invalid := range []string{
+		"prod-server",
+		"@host",
+		"user@",
+		"user@host:99999",
+		"user@host:abc",
+		"user@bad host",
+		"user@[::1",
+		"ssh://host/path",
+		"ssh://host?x=1",
+		"user@-oProxyCommand=x",
+		"user@-oProxyCommand%h",
+		"ssh://-oProxyCommand%h",
+	} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("Parse(%q) expected an error", invalid)
+		}
+	}
+}
+
+func TestArgs(t *testing.T) {
+	target := Target{User: "deploy", Host: "db.example.com", Port: "2222"}
+	if got := strings.Join(target.Args(), " "); got != "-p 2222 -l deploy -- db.example.com" {
+		t.Errorf("Args() = %q", got)
+	}
+	if got := strings.Join((Target{Host: "db"}).Args(), " "); got != "-- db" {
+		t.Errorf("Args() = %q", got)
+	}
+
+	host := target.SSHHost("db")
+	if host.Name != "db" || host.Hostname != "db.example.com" || host.User != "deploy" || host.Port != "2222" {
+		t.Errorf("SSHHost() = %+v", host)
+	}
+}
+
+func TestSuggestAlias(t *testing.T) {
+	hosts := []config.SSHHost{{Name: "db"}, {Name: "db-2"}}
+
+	tests := []struct {
+		target Target
+		want   string
+	}{
+		{Target{Host: "web.example.com"}, "web"},
+		{Target{Host: "db.example.com"}, "db-3"},
+		{Target{Host: "10.0.0.5"}, "10-0-0-5"},
+		{Target{Host: "fe80::1"}, "fe80--1"},
+		{Target{Host: "localhost"}, "localhost"},
+	}
+	for _, tt := range tests {
+		if got := tt.target.SuggestAlias(hosts); got != tt.want {
+			t.Errorf("SuggestAlias(%s) = %q, want %q", tt.target.Host, got, tt.want)
+		}
+	}
+}
@@ -16,10 +16,83 @@ type KeyBindings struct {
 	DisableEscQuit bool `json:"disable_esc_quit"`
 }
 
+// PingSettings represents the configurable behavior of connectivity checks
+type PingSettings struct {
+	// Concurrency - maximum number of hosts checked at the same time
+	Concurrency int `json:"concurrency"`
+
+	// PerJumpHostConcurrency - maximum number of simultaneous checks going through the same jump host
+	PerJumpHostConcurrency int `json:"per_jump_host_concurrency"`
+
+	// Retries - number of extra attempts for hosts found offline
+	Retries int `json:"retries"`
+
+	// RetryBackoffMs - delay before the first retry in milliseconds, doubled on each attempt
+	RetryBackoffMs int `json:"retry_backoff_ms"`
+
+	// MonitorIntervalSeconds - delay between sweeps when monitor mode is enabled in the TUI
+	MonitorIntervalSeconds int `json:"monitor_interval_seconds"`
+
+	// CacheTTLSeconds - age after which last known statuses are refreshed when the TUI starts
+	CacheTTLSeconds int `json:"cache_ttl_seconds"`
+
+	// AuthProbe - if true, checks also try publickey authentication with the agent and identity files
+	AuthProbe bool `json:"auth_probe"`
+}
+
+// RecordingSettings represents where session recordings are kept and for how long
+type RecordingSettings struct {
+	// Directory - where recordings are written (default: recordings/ in the sshm config directory)
+	Directory string `json:"directory,omitempty"`
+
+	// RetentionDays - recordings older than this many days are deleted, 0 keeps them forever
+	RetentionDays int `json:"retention_days"`
+
+	// MaxRecordings - only this many of the most recent recordings are kept, 0 means no limit
+	MaxRecordings int `json:"max_recordings"`
+}
+
+// ReconnectSettings represents how sessions are started again after the connection drops
+type ReconnectSettings struct {
+	// MaxAttempts - number of reconnections tried before giving up
+	MaxAttempts int `json:"max_attempts"`
+
+	// InitialDelaySeconds - delay before the first reconnection, doubled on each attempt
+	InitialDelaySeconds int `json:"initial_delay_seconds"`
+
+	// MaxDelaySeconds - longest delay between two reconnections
+	MaxDelaySeconds int `json:"max_delay_seconds"`
+}
+
+// HookCommands represents local shell commands run around connections
+type HookCommands struct {
+	// PreConnect - run before connecting, a failing command aborts the connection
+	PreConnect []string `json:"pre_connect,omitempty"`
+
+	// PostDisconnect - run once the connection is closed
+	PostDisconnect []string `json:"post_disconnect,omitempty"`
+}
+
+// HookSettings represents the hooks run around connections, for all hosts and per tag
+type HookSettings struct {
+	// Global - hooks run for every host, before those of its tags
+	Global HookCommands `json:"global"`
+
+	// Tags - hooks run for the hosts carrying a tag
+	Tags map[string]HookCommands `json:"tags,omitempty"`
+
+	// TimeoutSeconds - time after which a hook command is stopped
+	TimeoutSeconds int `json:"timeout_seconds"`
+}
+
 // AppConfig represents the main application configuration
 type AppConfig struct {
-	CheckForUpdates *bool       `json:"check_for_updates,omitempty"`
-	KeyBindings     KeyBindings `json:"key_bindings"`
+	CheckForUpdates *bool             `json:"check_for_updates,omitempty"`
+	KeyBindings     KeyBindings       `json:"key_bindings"`
+	Ping            PingSettings      `json:"ping"`
+	Recording       RecordingSettings `json:"recording"`
+	Reconnect       ReconnectSettings `json:"reconnect"`
+	Hooks           HookSettings      `json:"hooks"`
 }
 
 // IsUpdateCheckEnabled returns true if the update check is enabled (default: true)
@@ -38,10 +111,41 @@ func GetDefaultKeyBindings() KeyBindings {
 	}
 }
 
+// GetDefaultPingSettings returns the default connectivity check settings
+func GetDefaultPingSettings() PingSettings {
+	return PingSettings{
+		Concurrency:            32, // Keeps file descriptor usage low on large configs
+		PerJumpHostConcurrency: 4,  // Avoids tripping fail2ban on shared bastions
+		Retries:                1,
+		RetryBackoffMs:         500,
+		MonitorIntervalSeconds: 30,
+		CacheTTLSeconds:        300,
+	}
+}
+
+// GetDefaultReconnectSettings returns the default reconnection settings
+func GetDefaultReconnectSettings() ReconnectSettings {
+	return ReconnectSettings{
+		MaxAttempts:         5,
+		InitialDelaySeconds: 1,
+		MaxDelaySeconds:     60,
+	}
+}
+
+// GetDefaultHookSettings returns the default hook settings, with no hooks
+func GetDefaultHookSettings() HookSettings {
+	return HookSettings{
+		TimeoutSeconds: 30,
+	}
+}
+
 // GetDefaultAppConfig returns the default application configuration
 func GetDefaultAppConfig() AppConfig {
 	return AppConfig{
 		KeyBindings: GetDefaultKeyBindings(),
+		Ping:        GetDefaultPingSettings(),
+		Reconnect:   GetDefaultReconnectSettings(),
+		Hooks:       GetDefaultHookSettings(),
 	}
 }
 
@@ -134,6 +238,42 @@ func mergeWithDefaults(config AppConfig) AppConfig {
 		config.KeyBindings.QuitKeys = defaults.KeyBindings.QuitKeys
 	}
 
+	// Non-positive limits are not meaningful, use defaults
+	// Retries are left untouched since 0 is a valid value
+	if config.Ping.Concurrency <= 0 {
+		config.Ping.Concurrency = defaults.Ping.Concurrency
+	}
+	if config.Ping.PerJumpHostConcurrency <= 0 {
+		config.Ping.PerJumpHostConcurrency = defaults.Ping.PerJumpHostConcurrency
+	}
+	if config.Ping.RetryBackoffMs <= 0 {
+		config.Ping.RetryBackoffMs = defaults.Ping.RetryBackoffMs
+	}
+	if config.Ping.MonitorIntervalSeconds <= 0 {
+		config.Ping.MonitorIntervalSeconds = defaults.Ping.MonitorIntervalSeconds
+	}
+	if config.Ping.CacheTTLSeconds <= 0 {
+		config.Ping.CacheTTLSeconds = defaults.Ping.CacheTTLSeconds
+	}
+
+	// Recording limits of 0 mean no limit, negative values are treated the same way
+	config.Recording.RetentionDays = max(0, config.Recording.RetentionDays)
+	config.Recording.MaxRecordings = max(0, config.Recording.MaxRecordings)
+
+	if config.Reconnect.MaxAttempts <= 0 {
+		config.Reconnect.MaxAttempts = defaults.Reconnect.MaxAttempts
+	}
+	if config.Reconnect.InitialDelaySeconds <= 0 {
+		config.Reconnect.InitialDelaySeconds = defaults.Reconnect.InitialDelaySeconds
+	}
+	if config.Reconnect.MaxDelaySeconds < config.Reconnect.InitialDelaySeconds {
+		config.Reconnect.MaxDelaySeconds = max(defaults.Reconnect.MaxDelaySeconds, config.Reconnect.InitialDelaySeconds)
+	}
+
+	if config.Hooks.TimeoutSeconds <= 0 {
+		config.Hooks.TimeoutSeconds = defaults.Hooks.TimeoutSeconds
+	}
+
 	return config
 }
 
@@ -152,4 +292,4 @@ func (kb *KeyBindings) ShouldQuitOnKey(key string) bool {
 	}
 
 	return false
-}
\ No newline at end of file
+}
@@ -181,6 +181,68 @@ func TestMergeWithDefaults(t *testing.T) {
 	}
 }
 
+func TestMergeWithDefaultsPingSettings(t *testing.T) {
+	// Config files written before ping settings existed have a zero-valued section
+	merged := mergeWithDefaults(AppConfig{})
+	defaults := GetDefaultPingSettings()
+
+	if merged.Ping.Concurrency != defaults.Concurrency {
+		t.Errorf("Expected default concurrency %d, got %d", defaults.Concurrency, merged.Ping.Concurrency)
+	}
+	if merged.Ping.PerJumpHostConcurrency != defaults.PerJumpHostConcurrency {
+		t.Errorf("Expected default per-jump-host concurrency %d, got %d", defaults.PerJumpHostConcurrency, merged.Ping.PerJumpHostConcurrency)
+	}
+	if merged.Ping.RetryBackoffMs != defaults.RetryBackoffMs {
+		t.Errorf("Expected default retry backoff %d, got %d", defaults.RetryBackoffMs, merged.Ping.RetryBackoffMs)
+	}
+	if merged.Ping.MonitorIntervalSeconds != defaults.MonitorIntervalSeconds {
+		t.Errorf("Expected default monitor interval %d, got %d", defaults.MonitorIntervalSeconds, merged.Ping.MonitorIntervalSeconds)
+	}
+	if merged.Ping.CacheTTLSeconds != defaults.CacheTTLSeconds {
+		t.Errorf("Expected default cache TTL %d, got %d", defaults.CacheTTLSeconds, merged.Ping.CacheTTLSeconds)
+	}
+	if merged.Ping.Retries != 0 {
+		t.Errorf("Expected explicit 0 retries to be preserved, got %d", merged.Ping.Retries)
+	}
+
+	custom := mergeWithDefaults(AppConfig{Ping: PingSettings{Concurrency: 8, PerJumpHostConcurrency: 2, Retries: 3}})
+	if custom.Ping.Concurrency != 8 || custom.Ping.PerJumpHostConcurrency != 2 || custom.Ping.Retries != 3 {
+		t.Errorf("Custom ping settings should be preserved, got %+v", custom.Ping)
+	}
+}
+
+func TestMergeWithDefaultsRecordingSettings(t *testing.T) {
+	merged := mergeWithDefaults(AppConfig{Recording: RecordingSettings{RetentionDays: -1, MaxRecordings: -5}})
+	if merged.Recording.RetentionDays != 0 || merged.Recording.MaxRecordings != 0 {
+		t.Errorf("Expected negative recording limits to mean no limit, got %+v", merged.Recording)
+	}
+
+	custom := mergeWithDefaults(AppConfig{Recording: RecordingSettings{Directory: "~/casts", RetentionDays: 90, MaxRecordings: 500}})
+	if custom.Recording.Directory != "~/casts" || custom.Recording.RetentionDays != 90 || custom.Recording.MaxRecordings != 500 {
+		t.Errorf("Custom recording settings should be preserved, got %+v", custom.Recording)
+	}
+}
+
+func TestMergeWithDefaultsReconnectSettings(t *testing.T) {
+	merged := mergeWithDefaults(AppConfig{Reconnect: ReconnectSettings{MaxAttempts: -1, InitialDelaySeconds: 120}})
+	if merged.Reconnect.MaxAttempts != GetDefaultReconnectSettings().MaxAttempts {
+		t.Errorf("Expected the default number of attempts, got %d", merged.Reconnect.MaxAttempts)
+	}
+	if merged.Reconnect.InitialDelaySeconds != 120 || merged.Reconnect.MaxDelaySeconds != 120 {
+		t.Errorf("Expected the longest delay to be at least the first one, got %+v", merged.Reconnect)
+	}
+}
+
+func TestMergeWithDefaultsHookSettings(t *testing.T) {
+	merged := mergeWithDefaults(AppConfig{Hooks: HookSettings{Global: HookCommands{PreConnect: []string{"vpn up"}}}})
+	if merged.Hooks.TimeoutSeconds != GetDefaultHookSettings().TimeoutSeconds {
+		t.Errorf("Expected the default hook timeout, got %d", merged.Hooks.TimeoutSeconds)
+	}
+	if len(merged.Hooks.Global.PreConnect) != 1 {
+		t.Errorf("Hooks should be preserved, got %+v", merged.Hooks)
+	}
+}
+
 func TestSaveAndLoadAppConfigIntegration(t *testing.T) {
 	// Create a temporary directory for testing
 	tempDir, err := os.MkdirTemp("", "sshm_test")
@@ -242,4 +304,4 @@ func TestSaveAndLoadAppConfigIntegration(t *testing.T) {
 	if loadedConfig.IsUpdateCheckEnabled() {
 		t.Error("IsUpdateCheckEnabled should return false when CheckForUpdates is false")
 	}
-}
\ No newline at end of file
+}
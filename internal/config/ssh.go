@@ -33,8 +33,9 @@ type SSHHost struct {
 	ProxyJump     string
 	ProxyCommand  string
 	Options       string
-	RemoteCommand string // Command to execute after SSH connection
-	RequestTTY    string // Request TTY (yes, no, force, auto)
+	RemoteCommand string   // Command to execute after SSH connection
+	RequestTTY    string   // Request TTY (yes, no, force, auto)
+	Forwards      []string // LocalForward, RemoteForward and DynamicForward directives (e.g. "LocalForward 8080 localhost:80")
 	Tags          []string
 	SourceFile    string // Path to the config file where this host is defined
 	LineNumber    int    // Line number in the source file where this host block starts (1-indexed)
@@ -361,6 +362,10 @@ func parseSSHConfigFileWithProcessedFiles(configPath string, processedFiles map[
 			if currentHost != nil {
 				currentHost.RequestTTY = value
 			}
+		case "localforward", "remoteforward", "dynamicforward":
+			if currentHost != nil {
+				currentHost.Forwards = append(currentHost.Forwards, parts[0]+" "+value)
+			}
 		default:
 			// Handle other SSH options
 			if currentHost != nil && strings.TrimSpace(line) != "" {
@@ -659,6 +664,13 @@ func AddSSHHostToFile(host SSHHost, configPath string) error {
 		}
 	}
 
+	for _, forward := range host.Forwards {
+		_, err = file.WriteString(fmt.Sprintf("    %s\n", forward))
+		if err != nil {
+			return err
+		}
+	}
+
 	// Write SSH options
 	if host.Options != "" {
 		// Split options by newlines and write each one
@@ -1115,6 +1127,9 @@ func UpdateSSHHostInFile(oldName string, newHost SSHHost, configPath string) err
 						if newHost.RequestTTY != "" {
 							newLines = append(newLines, "    RequestTTY "+newHost.RequestTTY)
 						}
+						for _, forward := range newHost.Forwards {
+							newLines = append(newLines, "    "+forward)
+						}
 						// Write SSH options
 						if newHost.Options != "" {
 							options := strings.Split(newHost.Options, "\n")
@@ -1172,6 +1187,9 @@ func UpdateSSHHostInFile(oldName string, newHost SSHHost, configPath string) err
 						if newHost.RequestTTY != "" {
 							newLines = append(newLines, "    RequestTTY "+newHost.RequestTTY)
 						}
+						for _, forward := range newHost.Forwards {
+							newLines = append(newLines, "    "+forward)
+						}
 						// Write SSH options
 						if newHost.Options != "" {
 							options := strings.Split(newHost.Options, "\n")
@@ -1265,6 +1283,9 @@ func UpdateSSHHostInFile(oldName string, newHost SSHHost, configPath string) err
 					if newHost.RequestTTY != "" {
 						newLines = append(newLines, "    RequestTTY "+newHost.RequestTTY)
 					}
+					for _, forward := range newHost.Forwards {
+						newLines = append(newLines, "    "+forward)
+					}
 					// Write SSH options
 					if newHost.Options != "" {
 						options := strings.Split(newHost.Options, "\n")
@@ -1322,6 +1343,9 @@ func UpdateSSHHostInFile(oldName string, newHost SSHHost, configPath string) err
 					if newHost.RequestTTY != "" {
 						newLines = append(newLines, "    RequestTTY "+newHost.RequestTTY)
 					}
+					for _, forward := range newHost.Forwards {
+						newLines = append(newLines, "    "+forward)
+					}
 					// Write SSH options
 					if newHost.Options != "" {
 						options := strings.Split(newHost.Options, "\n")
@@ -1884,6 +1908,9 @@ func UpdateMultiHostBlock(originalHosts, newHosts []string, commonProperties SSH
 					if commonProperties.RequestTTY != "" {
 						newLines = append(newLines, "    RequestTTY "+commonProperties.RequestTTY)
 					}
+					for _, forward := range commonProperties.Forwards {
+						newLines = append(newLines, "    "+forward)
+					}
 
 					// Write SSH options
 					if commonProperties.Options != "" {
@@ -1973,6 +2000,9 @@ func UpdateMultiHostBlock(originalHosts, newHosts []string, commonProperties SSH
 				if commonProperties.RequestTTY != "" {
 					newLines = append(newLines, "    RequestTTY "+commonProperties.RequestTTY)
 				}
+				for _, forward := range commonProperties.Forwards {
+					newLines = append(newLines, "    "+forward)
+				}
 
 				// Write SSH options
 				if commonProperties.Options != "" {
@@ -2005,3 +2035,37 @@ func UpdateMultiHostBlock(originalHosts, newHosts []string, commonProperties SSH
 	newContent := strings.Join(newLines, "\n")
 	return os.WriteFile(configPath, []byte(newContent), 0600)
 }
+
+// AddForwardDirective adds a LocalForward, RemoteForward or DynamicForward
+// directive to the block of a host, in the file where the host is defined
+func AddForwardDirective(hostName, directive, configPath string) error {
+	var host *SSHHost
+	var err error
+	if configPath != "" {
+		host, err = GetSSHHostFromFile(hostName, configPath)
+	} else {
+		host, err = GetSSHHost(hostName)
+	}
+	if err != nil {
+		return err
+	}
+
+	targetFile := host.SourceFile
+	if targetFile == "" {
+		targetFile = configPath
+	}
+
+	directive = strings.Join(strings.Fields(directive), " ")
+	for _, forward := range host.Forwards {
+		if strings.EqualFold(strings.Join(strings.Fields(forward), " "), directive) {
+			return fmt.Errorf("host '%s' already has %s", hostName, directive)
+		}
+	}
+	host.Forwards = append(host.Forwards, directive)
+
+	isMulti, hostNames, err := IsPartOfMultiHostDeclaration(hostName, targetFile)
+	if err == nil && isMulti {
+		return UpdateMultiHostBlock(hostNames, hostNames, *host, targetFile)
+	}
+	return UpdateSSHHostInFile(hostName, *host, targetFile)
+}
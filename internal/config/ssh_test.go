@@ -1786,3 +1786,61 @@ Host "quoted1" "quoted2"
 		}
 	}
 }
+
+func TestForwardDirectives(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("APPDATA", tempDir)
+
+	configFile := filepath.Join(tempDir, "config")
+	configContent := `Host db
+    HostName db.example.com
+    LocalForward 5432 localhost:5432
+    Compression yes
+
+Host web1 web2
+    HostName web.example.com
+`
+	if err := os.WriteFile(configFile, []byte(configContent), 0600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	host, err := GetSSHHostFromFile("db", configFile)
+	if err != nil {
+		t.Fatalf("GetSSHHostFromFile() error = %v", err)
+	}
+	if len(host.Forwards) != 1 || host.Forwards[0] != "LocalForward 5432 localhost:5432" {
+		t.Errorf("Forwards = %v, want the LocalForward directive", host.Forwards)
+	}
+	if host.Options != "Compression yes" {
+		t.Errorf("Options = %q, want forwards kept out of the options", host.Options)
+	}
+
+	if err := AddForwardDirective("db", "DynamicForward 1080", configFile); err != nil {
+		t.Fatalf("AddForwardDirective() error = %v", err)
+	}
+	if err := AddForwardDirective("db", "localforward  5432 localhost:5432", configFile); err == nil {
+		t.Error("Expected an error when adding a forward the host already has")
+	}
+	if err := AddForwardDirective("web2", "RemoteForward 9000 localhost:3000", configFile); err != nil {
+		t.Fatalf("AddForwardDirective() error = %v", err)
+	}
+
+	hosts, err := ParseSSHConfigFile(configFile)
+	if err != nil {
+		t.Fatalf("ParseSSHConfigFile() error = %v", err)
+	}
+	want := map[string][]string{
+		"db":   {"LocalForward 5432 localhost:5432", "DynamicForward 1080"},
+		"web1": {"RemoteForward 9000 localhost:3000"},
+		"web2": {"RemoteForward 9000 localhost:3000"},
+	}
+	for _, host := range hosts {
+		if strings.Join(host.Forwards, "|") != strings.Join(want[host.Name], "|") {
+			t.Errorf("%s forwards = %v, want %v", host.Name, host.Forwards, want[host.Name])
+		}
+	}
+	if len(hosts) != 3 {
+		t.Errorf("Expected the multi-host block to be kept, got %d hosts", len(hosts))
+	}
+}
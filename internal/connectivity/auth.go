@@ -0,0 +1,195 @@
+package connectivity
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// defaultIdentityFiles lists the identity files ssh tries when none is configured
+var defaultIdentityFiles = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// authMethods returns the non-interactive publickey auth methods available for a host:
+// the keys held by the SSH agent (SSH_AUTH_SOCK) followed by the host's IdentityFile
+// and the default identity files. The returned function releases the agent connection.
+func authMethods(host config.SSHHost) ([]ssh.AuthMethod, func()) {
+	return recordedAuthMethods(host, nil)
+}
+
+// recordedAuthMethods is like authMethods, and notes in recorder (when set)
+// which key signed the authentication request
+func recordedAuthMethods(host config.SSHHost, recorder *authKeyRecorder) ([]ssh.AuthMethod, func()) {
+	var methods []ssh.AuthMethod
+	cleanup := func() {}
+
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			agentClient := agent.NewClient(conn)
+			methods = append(methods, ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+				signers, err := agentClient.Signers()
+				if err != nil || recorder == nil {
+					return signers, err
+				}
+				for i, signer := range signers {
+					signers[i] = recorder.wrap(signer, agentKeyLabel(signer))
+				}
+				return signers, nil
+			}))
+			cleanup = func() { conn.Close() }
+		}
+	}
+
+	var signers []ssh.Signer
+	for _, identity := range loadIdentities(host) {
+		signer := identity.signer
+		if recorder != nil {
+			signer = recorder.wrap(signer, identity.path)
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+
+	return methods, cleanup
+}
+
+// identity is a private key loaded from an identity file
+type identity struct {
+	path   string
+	signer ssh.Signer
+}
+
+// loadIdentities loads the unencrypted private keys usable for a host.
+// Keys protected by a passphrase are skipped since we never prompt.
+func loadIdentities(host config.SSHHost) []identity {
+	var identities []identity
+	for _, path := range identityFilePaths(host) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			continue
+		}
+		identities = append(identities, identity{path: path, signer: signer})
+	}
+	return identities
+}
+
+// agentKeyLabel describes a key held by the SSH agent by its comment, or its fingerprint
+func agentKeyLabel(signer ssh.Signer) string {
+	if key, ok := signer.PublicKey().(*agent.Key); ok && key.Comment != "" {
+		return "agent: " + key.Comment
+	}
+	return "agent: " + ssh.FingerprintSHA256(signer.PublicKey())
+}
+
+// authKeyRecorder remembers which key last signed an authentication request.
+// The SSH client only signs with keys the server accepts, so after a successful
+// authentication this is the key that was used.
+type authKeyRecorder struct {
+	mutex sync.Mutex
+	last  string
+}
+
+func (r *authKeyRecorder) record(label string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.last = label
+}
+
+// Last returns the label of the key that signed last
+func (r *authKeyRecorder) Last() string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.last
+}
+
+// wrap returns a signer that records label whenever it signs, keeping the
+// algorithm negotiation capabilities of the original signer
+func (r *authKeyRecorder) wrap(signer ssh.Signer, label string) ssh.Signer {
+	base := &recordingSigner{Signer: signer, label: label, recorder: r}
+	algorithmSigner, ok := signer.(ssh.AlgorithmSigner)
+	if !ok {
+		return base
+	}
+	withAlgorithms := &recordingAlgorithmSigner{recordingSigner: base, algorithmSigner: algorithmSigner}
+	if multi, ok := signer.(ssh.MultiAlgorithmSigner); ok {
+		return &recordingMultiAlgorithmSigner{recordingAlgorithmSigner: withAlgorithms, algorithms: multi.Algorithms()}
+	}
+	return withAlgorithms
+}
+
+// recordingSigner notes its label in a recorder before signing
+type recordingSigner struct {
+	ssh.Signer
+	label    string
+	recorder *authKeyRecorder
+}
+
+func (s *recordingSigner) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	s.recorder.record(s.label)
+	return s.Signer.Sign(rand, data)
+}
+
+type recordingAlgorithmSigner struct {
+	*recordingSigner
+	algorithmSigner ssh.AlgorithmSigner
+}
+
+func (s *recordingAlgorithmSigner) SignWithAlgorithm(rand io.Reader, data []byte, algorithm string) (*ssh.Signature, error) {
+	s.recorder.record(s.label)
+	return s.algorithmSigner.SignWithAlgorithm(rand, data, algorithm)
+}
+
+type recordingMultiAlgorithmSigner struct {
+	*recordingAlgorithmSigner
+	algorithms []string
+}
+
+func (s *recordingMultiAlgorithmSigner) Algorithms() []string {
+	return s.algorithms
+}
+
+// identityFilePaths returns the identity files to try for a host, most specific first
+func identityFilePaths(host config.SSHHost) []string {
+	var paths []string
+	if host.Identity != "" {
+		paths = append(paths, expandHome(strings.Trim(host.Identity, `"`)))
+	}
+
+	sshDir, err := config.GetSSHDirectory()
+	if err != nil {
+		return paths
+	}
+	for _, name := range defaultIdentityFiles {
+		path := filepath.Join(sshDir, name)
+		if len(paths) > 0 && paths[0] == path {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// expandHome expands a leading ~ to the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+}
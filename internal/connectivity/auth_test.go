@@ -0,0 +1,84 @@
+package connectivity
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// writeTestIdentity writes an unencrypted ed25519 identity file and returns its public key
+func writeTestIdentity(t *testing.T, path string) ssh.PublicKey {
+	t.Helper()
+
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatalf("Failed to create key directory: %v", err)
+	}
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatalf("Failed to convert public key: %v", err)
+	}
+	return sshPub
+}
+
+func TestPingHost_AuthProbe(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	keyPath := filepath.Join(home, ".ssh", "id_ed25519")
+	pub := writeTestIdentity(t, keyPath)
+
+	accepting := startTestSSHServer(t, newTestSigner(t), pub)
+	rejecting := startTestSSHServer(t, newTestSigner(t))
+
+	pm := NewPingManager(5*time.Second, "")
+	pm.SetAuthProbe(true)
+
+	hostname, port, _ := net.SplitHostPort(accepting)
+	result := pm.PingHost(context.Background(), config.SSHHost{Name: "ok", Hostname: hostname, Port: port, User: "test"})
+	if result.Auth != AuthOK || result.AuthKey != keyPath {
+		t.Errorf("Expected %s with key %s, got %s with %q (%v)", AuthOK, keyPath, result.Auth, result.AuthKey, result.Error)
+	}
+	if result.Status != StatusOnline || result.Reason != ReasonNone {
+		t.Errorf("Expected online without reason, got %s with %q", result.Status, result.Reason)
+	}
+
+	hostname, port, _ = net.SplitHostPort(rejecting)
+	result = pm.PingHost(context.Background(), config.SSHHost{Name: "denied", Hostname: hostname, Port: port, User: "test"})
+	if result.Auth != AuthDenied || result.AuthKey != "" {
+		t.Errorf("Expected %s without key, got %s with %q", AuthDenied, result.Auth, result.AuthKey)
+	}
+	if result.Status != StatusOnline {
+		t.Errorf("Hosts denying authentication are still reachable, got %s", result.Status)
+	}
+
+	// Without the probe, authentication is not attempted
+	pm.SetAuthProbe(false)
+	hostname, port, _ = net.SplitHostPort(accepting)
+	result = pm.PingHost(context.Background(), config.SSHHost{Name: "ok", Hostname: hostname, Port: port, User: "test"})
+	if result.Auth != AuthUnchecked || result.Reason != ReasonNone {
+		t.Errorf("Expected unchecked auth and no reason, got %s with %q", result.Auth, result.Reason)
+	}
+}
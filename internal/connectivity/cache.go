@@ -0,0 +1,161 @@
+package connectivity
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+// CachedStatus is the last known connectivity status of a host, as persisted on disk
+type CachedStatus struct {
+	Status     PingStatus    `json:"status"`
+	DurationMs int64         `json:"duration_ms"`
+	Error      string        `json:"error,omitempty"`
+	Reason     FailureReason `json:"reason,omitempty"`
+	CheckedAt  time.Time     `json:"checked_at"`
+}
+
+// Age returns how long ago the status was checked
+func (c CachedStatus) Age() time.Duration {
+	return time.Since(c.CheckedAt)
+}
+
+// statusCache is the on-disk format of the connectivity cache
+type statusCache struct {
+	Hosts map[string]CachedStatus `json:"hosts"`
+}
+
+// MarshalText encodes the status by name so the cache stays readable
+func (s PingStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a status name, unknown names map to StatusUnknown
+func (s *PingStatus) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "online":
+		*s = StatusOnline
+	case "offline":
+		*s = StatusOffline
+	default:
+		*s = StatusUnknown
+	}
+	return nil
+}
+
+// EnableCache loads the last known statuses from the sshm config directory
+// and persists the results of every completed sweep there
+func (pm *PingManager) EnableCache() error {
+	configDir, err := config.GetSSHMConfigDir()
+	if err != nil {
+		return err
+	}
+	return pm.enableCacheAt(filepath.Join(configDir, "ping_cache.json"))
+}
+
+// enableCacheAt enables the connectivity cache stored at the given path
+func (pm *PingManager) enableCacheAt(path string) error {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	pm.cachePath = path
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		// A missing cache is not an error, it will be created on the first sweep
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var cache statusCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return err
+	}
+	for hostName, status := range cache.Hosts {
+		pm.lastKnown[hostName] = status
+	}
+	return nil
+}
+
+// SaveCache writes the last known statuses to disk, if the cache is enabled
+func (pm *PingManager) SaveCache() error {
+	pm.mutex.RLock()
+	path := pm.cachePath
+	data, err := json.MarshalIndent(statusCache{Hosts: pm.lastKnown}, "", "  ")
+	pm.mutex.RUnlock()
+
+	if path == "" {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	// The CLI and the TUI both write the cache, replace it in one step
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// rememberStatus updates the last known status of a host (caller holds the lock)
+func (pm *PingManager) rememberStatus(result *HostPingResult) {
+	cached := CachedStatus{
+		Status:     result.Status,
+		DurationMs: result.Duration.Milliseconds(),
+		Reason:     result.Reason,
+		CheckedAt:  time.Now(),
+	}
+	if result.Error != nil {
+		cached.Error = result.Error.Error()
+	}
+	pm.lastKnown[result.HostName] = cached
+}
+
+// GetLastKnown returns the last known status of a host from the cache or a previous sweep
+func (pm *PingManager) GetLastKnown(hostName string) (CachedStatus, bool) {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	status, exists := pm.lastKnown[hostName]
+	return status, exists
+}
+
+// StaleHosts returns the hosts whose last known status is older than ttl.
+// Hosts that were never checked are not included.
+func (pm *PingManager) StaleHosts(hosts []config.SSHHost, ttl time.Duration) []config.SSHHost {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	var stale []config.SSHHost
+	for _, host := range hosts {
+		if status, exists := pm.lastKnown[host.Name]; exists && status.Age() >= ttl {
+			stale = append(stale, host)
+		}
+	}
+	return stale
+}
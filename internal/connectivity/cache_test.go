@@ -0,0 +1,81 @@
+package connectivity
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+func TestPingManager_CacheRoundTrip(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "ping_cache.json")
+
+	pm := NewPingManager(1*time.Second, "")
+	pm.ApplySettings(config.PingSettings{Concurrency: 1, PerJumpHostConcurrency: 1, RetryBackoffMs: 10})
+	if err := pm.enableCacheAt(cachePath); err != nil {
+		t.Fatalf("enableCacheAt() on a missing file should not fail, got %v", err)
+	}
+
+	// Port 1 on localhost is refused immediately
+	hosts := []config.SSHHost{{Name: "refused", Hostname: "127.0.0.1", Port: "1"}}
+	for range pm.PingAllHosts(context.Background(), hosts) {
+	}
+
+	if _, err := os.Stat(cachePath); err != nil {
+		t.Fatalf("Expected cache file to be written after a sweep: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(cachePath)); len(entries) != 1 {
+		t.Errorf("Expected only the cache file once written, got %d files", len(entries))
+	}
+
+	// A new manager starts with the persisted status, but no live result
+	reloaded := NewPingManager(1*time.Second, "")
+	if err := reloaded.enableCacheAt(cachePath); err != nil {
+		t.Fatalf("enableCacheAt() error = %v", err)
+	}
+	if status := reloaded.GetStatus("refused"); status != StatusUnknown {
+		t.Errorf("Expected no live status after reload, got %v", status)
+	}
+	lastKnown, ok := reloaded.GetLastKnown("refused")
+	if !ok {
+		t.Fatal("Expected last known status after reload")
+	}
+	if lastKnown.Status != StatusOffline {
+		t.Errorf("Expected last known status offline, got %v", lastKnown.Status)
+	}
+	if lastKnown.Error == "" {
+		t.Error("Expected last known error to be persisted")
+	}
+}
+
+func TestPingManager_StaleHosts(t *testing.T) {
+	pm := NewPingManager(1*time.Second, "")
+	pm.lastKnown["fresh"] = CachedStatus{Status: StatusOnline, CheckedAt: time.Now()}
+	pm.lastKnown["old"] = CachedStatus{Status: StatusOnline, CheckedAt: time.Now().Add(-time.Hour)}
+
+	hosts := []config.SSHHost{{Name: "fresh"}, {Name: "old"}, {Name: "never"}}
+	stale := pm.StaleHosts(hosts, 5*time.Minute)
+
+	if len(stale) != 1 || stale[0].Name != "old" {
+		t.Errorf("Expected only 'old' to be stale, got %v", stale)
+	}
+}
+
+func TestPingStatus_TextRoundTrip(t *testing.T) {
+	for _, status := range []PingStatus{StatusOnline, StatusOffline, StatusUnknown} {
+		text, err := status.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText() error = %v", err)
+		}
+		var decoded PingStatus
+		if err := decoded.UnmarshalText(text); err != nil {
+			t.Fatalf("UnmarshalText() error = %v", err)
+		}
+		if decoded != status {
+			t.Errorf("Round trip of %v gave %v", status, decoded)
+		}
+	}
+}
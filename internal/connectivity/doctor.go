@@ -0,0 +1,388 @@
+package connectivity
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// StepStatus is the outcome of a single diagnostic step
+type StepStatus string
+
+const (
+	StepPass StepStatus = "pass"
+	StepFail StepStatus = "fail"
+	StepSkip StepStatus = "skip"
+)
+
+// Diagnostic step names, in the order they are run
+const (
+	StepJump   = "jump"
+	StepDNS    = "dns"
+	StepTCP    = "tcp"
+	StepBanner = "banner"
+	StepKEX    = "kex"
+	StepAuth   = "auth"
+)
+
+// DiagnosticStep is the result of one step of a connection diagnosis
+type DiagnosticStep struct {
+	Name     string
+	Target   string
+	Status   StepStatus
+	Detail   string
+	Error    error
+	Reason   FailureReason
+	Fix      string
+	Duration time.Duration
+}
+
+// Diagnosis is the step-by-step result of diagnosing the connection to a host
+type Diagnosis struct {
+	Host  string
+	Steps []DiagnosticStep
+}
+
+// OK reports whether no step failed
+func (d *Diagnosis) OK() bool {
+	for _, step := range d.Steps {
+		if step.Status == StepFail {
+			return false
+		}
+	}
+	return true
+}
+
+// diagnosis accumulates steps while a diagnosis runs
+type diagnosis struct {
+	Diagnosis
+	hostname string
+	port     string
+}
+
+func (d *diagnosis) pass(name, target, detail string, duration time.Duration) {
+	d.Steps = append(d.Steps, DiagnosticStep{Name: name, Target: target, Status: StepPass, Detail: detail, Duration: duration})
+}
+
+func (d *diagnosis) fail(name, target string, err error, duration time.Duration) {
+	reason := ClassifyError(err)
+	d.Steps = append(d.Steps, DiagnosticStep{
+		Name:     name,
+		Target:   target,
+		Status:   StepFail,
+		Error:    err,
+		Reason:   reason,
+		Fix:      suggestFix(name, reason, d.hostname, d.port),
+		Duration: duration,
+	})
+}
+
+func (d *diagnosis) skip(names []string, detail string) {
+	for _, name := range names {
+		d.Steps = append(d.Steps, DiagnosticStep{Name: name, Status: StepSkip, Detail: detail})
+	}
+}
+
+// Diagnose walks through every stage of an SSH connection to a host: ProxyJump hops,
+// DNS resolution, TCP connection to each address, SSH banner, key exchange and
+// authentication with the agent or identity files. A failed step skips the ones after it.
+func (pm *PingManager) Diagnose(ctx context.Context, host config.SSHHost) *Diagnosis {
+	hostname := host.Hostname
+	if hostname == "" {
+		hostname = host.Name
+	}
+	port := host.Port
+	if port == "" {
+		port = "22"
+	}
+	d := &diagnosis{Diagnosis: Diagnosis{Host: host.Name}, hostname: hostname, port: port}
+
+	if host.ProxyCommand != "" {
+		d.skip([]string{StepDNS, StepTCP, StepBanner, StepKEX, StepAuth},
+			"host uses ProxyCommand, run 'ssh -vvv "+host.Name+"' to diagnose it")
+		return &d.Diagnosis
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, pm.timeout)
+	defer cancel()
+
+	// Walk the ProxyJump chain, the target is then dialed through the last hop
+	var via *ssh.Client
+	if host.ProxyJump != "" {
+		clients, ok := pm.diagnoseJumps(ctx, d, host.ProxyJump)
+		defer func() {
+			for i := len(clients) - 1; i >= 0; i-- {
+				clients[i].Close()
+			}
+		}()
+		if !ok {
+			d.skip([]string{StepDNS, StepTCP, StepBanner, StepKEX, StepAuth}, "jump host not reachable")
+			return &d.Diagnosis
+		}
+		via = clients[len(clients)-1]
+	}
+
+	conn := diagnoseDial(ctx, d, via)
+	if conn == nil {
+		d.skip([]string{StepBanner, StepKEX, StepAuth}, "no TCP connection")
+		return &d.Diagnosis
+	}
+	defer conn.Close()
+
+	// Handshakes through a jump host don't support deadlines, abort them on cancellation
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	conn, ok := diagnoseBanner(d, conn)
+	if !ok {
+		d.skip([]string{StepKEX, StepAuth}, "no SSH banner")
+		return &d.Diagnosis
+	}
+
+	diagnoseHandshake(d, conn, host, pm.hostKeyCallback(host.Name))
+	return &d.Diagnosis
+}
+
+// diagnoseJumps dials each ProxyJump hop in turn and returns the clients opened so far,
+// the last one leading to the target
+func (pm *PingManager) diagnoseJumps(ctx context.Context, d *diagnosis, proxyJump string) ([]*ssh.Client, bool) {
+	jumps, err := pm.resolveJumpChain(proxyJump, 0)
+	if err != nil || len(jumps) == 0 {
+		if err == nil {
+			err = errors.New("no jump host to dial")
+		}
+		d.Steps = append(d.Steps, DiagnosticStep{
+			Name:   StepJump,
+			Target: proxyJump,
+			Status: StepFail,
+			Error:  err,
+			Fix:    "The chain uses ProxyCommand or nests too deeply, run 'ssh -vvv' to diagnose it",
+		})
+		return nil, false
+	}
+
+	var clients []*ssh.Client
+	var via *ssh.Client
+	for _, jump := range jumps {
+		start := time.Now()
+		auth, closeAuth := authMethods(jump.host)
+		client, err := dialSSH(ctx, via, jump.address, &ssh.ClientConfig{
+			User:            jump.user,
+			Auth:            auth,
+			HostKeyCallback: pm.hostKeyCallback(jump.name),
+			Timeout:         pm.timeout,
+		})
+		closeAuth()
+
+		target := jump.name + " (" + jump.address + ")"
+		if err != nil {
+			hostname, port, _ := net.SplitHostPort(jump.address)
+			reason := ClassifyError(err)
+			d.Steps = append(d.Steps, DiagnosticStep{
+				Name:     StepJump,
+				Target:   target,
+				Status:   StepFail,
+				Error:    err,
+				Reason:   reason,
+				Fix:      suggestFix(StepJump, reason, hostname, port),
+				Duration: time.Since(start),
+			})
+			return clients, false
+		}
+
+		d.pass(StepJump, target, "authenticated as "+jump.user, time.Since(start))
+		clients = append(clients, client)
+		via = client
+	}
+	return clients, true
+}
+
+// diagnoseDial resolves the target and opens a TCP connection to the first address that answers
+func diagnoseDial(ctx context.Context, d *diagnosis, via *ssh.Client) net.Conn {
+	if via != nil {
+		d.skip([]string{StepDNS}, "resolved by the jump host")
+
+		address := net.JoinHostPort(d.hostname, d.port)
+		start := time.Now()
+		conn, err := dialTCP(ctx, via, address)
+		if err != nil {
+			d.fail(StepTCP, address, err, time.Since(start))
+			return nil
+		}
+		d.pass(StepTCP, address, "connected through jump host", time.Since(start))
+		return conn
+	}
+
+	var addresses []string
+	if ip := net.ParseIP(d.hostname); ip != nil {
+		d.skip([]string{StepDNS}, d.hostname+" is an IP address")
+		addresses = []string{d.hostname}
+	} else {
+		start := time.Now()
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, d.hostname)
+		if err != nil {
+			d.fail(StepDNS, d.hostname, err, time.Since(start))
+			d.skip([]string{StepTCP}, "hostname not resolved")
+			return nil
+		}
+		for _, ip := range ips {
+			addresses = append(addresses, ip.String())
+		}
+		d.pass(StepDNS, d.hostname, strings.Join(addresses, ", "), time.Since(start))
+	}
+
+	// Try every address so that a broken IPv6 or round-robin record shows up. Each
+	// gets its share of the time left, so a blackholed one doesn't use it all up.
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: dialTimeout(ctx, len(addresses))}
+	for _, ip := range addresses {
+		address := net.JoinHostPort(ip, d.port)
+		start := time.Now()
+		c, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			d.fail(StepTCP, address, err, time.Since(start))
+			continue
+		}
+		d.pass(StepTCP, address, "connected", time.Since(start))
+		if conn == nil {
+			conn = c
+		} else {
+			c.Close()
+		}
+	}
+	return conn
+}
+
+// dialTimeout splits the time left before the deadline of ctx between n addresses,
+// returning 0 (no timeout of its own) when ctx has no deadline
+func dialTimeout(ctx context.Context, n int) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok || n == 0 {
+		return 0
+	}
+	return time.Until(deadline) / time.Duration(n)
+}
+
+// bannerConn replays the bytes read while looking for the SSH banner to the handshake
+type bannerConn struct {
+	net.Conn
+	reader io.Reader
+}
+
+func (c *bannerConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// diagnoseBanner reads the SSH identification string sent by the server
+func diagnoseBanner(d *diagnosis, conn net.Conn) (net.Conn, bool) {
+	start := time.Now()
+	target := conn.RemoteAddr().String()
+	reader := bufio.NewReader(conn)
+
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	defer conn.SetReadDeadline(time.Time{})
+
+	banner, err := readBanner(reader)
+	if err != nil {
+		d.fail(StepBanner, target, &handshakeError{err: err}, time.Since(start))
+		return nil, false
+	}
+	d.pass(StepBanner, target, banner, time.Since(start))
+
+	// Hand the banner back to the SSH client, which reads it again
+	return &bannerConn{Conn: conn, reader: io.MultiReader(strings.NewReader(banner+"\r\n"), reader)}, true
+}
+
+// diagnoseHandshake negotiates keys with the server, then authenticates with the agent or
+// identity files. The host key callback marks the end of the key exchange.
+func diagnoseHandshake(d *diagnosis, conn net.Conn, host config.SSHHost, verify ssh.HostKeyCallback) {
+	target := conn.RemoteAddr().String()
+	user := host.User
+	if user == "" {
+		user = currentUsername()
+	}
+
+	recorder := &authKeyRecorder{}
+	auth, closeAuth := recordedAuthMethods(host, recorder)
+	defer closeAuth()
+
+	var kexDone time.Time
+	var hostKey ssh.PublicKey
+	start := time.Now()
+	sshConn, _, _, err := ssh.NewClientConn(conn, net.JoinHostPort(d.hostname, d.port), &ssh.ClientConfig{
+		User: user,
+		Auth: auth,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			kexDone = time.Now()
+			hostKey = key
+			return verify(hostname, remote, key)
+		},
+	})
+	if sshConn != nil {
+		sshConn.Close()
+	}
+	if err != nil {
+		err = &handshakeError{err: err}
+	}
+
+	// Without a host key the exchange itself failed
+	if hostKey == nil {
+		d.fail(StepKEX, target, err, time.Since(start))
+		d.skip([]string{StepAuth}, "key exchange failed")
+		return
+	}
+	if ClassifyError(err) == ReasonHostKeyMismatch {
+		d.fail(StepKEX, target, err, kexDone.Sub(start))
+		d.skip([]string{StepAuth}, "host key rejected")
+		return
+	}
+	d.pass(StepKEX, target, hostKey.Type()+" "+ssh.FingerprintSHA256(hostKey), kexDone.Sub(start))
+
+	if len(auth) == 0 {
+		d.Steps = append(d.Steps, DiagnosticStep{
+			Name:   StepAuth,
+			Target: user,
+			Status: StepFail,
+			Error:  errors.New("no SSH agent and no unencrypted identity file available"),
+			Reason: ReasonAuthRequired,
+			Fix:    suggestFix(StepAuth, ReasonAuthRequired, d.hostname, d.port),
+		})
+		return
+	}
+	if err != nil {
+		d.fail(StepAuth, user, err, time.Since(kexDone))
+		return
+	}
+	d.pass(StepAuth, user, "authenticated with "+recorder.Last(), time.Since(kexDone))
+}
+
+// suggestFix returns a suggested fix for a failed step
+func suggestFix(step string, reason FailureReason, hostname, port string) string {
+	switch reason {
+	case ReasonDNSFailure:
+		return "Check the spelling of the Hostname, or your DNS resolver and VPN"
+	case ReasonRefused:
+		return fmt.Sprintf("Check that sshd is running and listening on port %s, or fix the Port setting", port)
+	case ReasonTimeout:
+		return "Check that the host is up and that no firewall or security group drops the traffic"
+	case ReasonUnreachable:
+		return "Check your network connection, VPN and routes to the host"
+	case ReasonHostKeyMismatch:
+		return fmt.Sprintf("If the host was reinstalled, remove its old key with 'ssh-keygen -R %s'; otherwise the connection may be intercepted", hostname)
+	case ReasonAuthRequired:
+		return "Load your key into the agent with 'ssh-add', or set IdentityFile; check authorized_keys on the server"
+	}
+	if step == StepBanner {
+		return "The service on this port may not be SSH, or sshd dropped the connection (MaxStartups, fail2ban, TCP wrappers)"
+	}
+	return "Run 'ssh -vvv' against the host for protocol details"
+}
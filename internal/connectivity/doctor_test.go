@@ -0,0 +1,97 @@
+package connectivity
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+// stepStatuses maps each step name to its status (the last one when a step repeats)
+func stepStatuses(d *Diagnosis) map[string]StepStatus {
+	statuses := make(map[string]StepStatus)
+	for _, step := range d.Steps {
+		statuses[step.Name] = step.Status
+	}
+	return statuses
+}
+
+func TestDiagnose_AuthRejected(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	address := startTestSSHServer(t, newTestSigner(t))
+	hostname, port, _ := net.SplitHostPort(address)
+
+	pm := NewPingManager(5*time.Second, "")
+	d := pm.Diagnose(context.Background(), config.SSHHost{Name: "local", Hostname: hostname, Port: port})
+
+	expected := map[string]StepStatus{
+		StepDNS:    StepSkip,
+		StepTCP:    StepPass,
+		StepBanner: StepPass,
+		StepKEX:    StepPass,
+		StepAuth:   StepFail,
+	}
+	statuses := stepStatuses(d)
+	for name, status := range expected {
+		if statuses[name] != status {
+			t.Errorf("Step %s: expected %s, got %s", name, status, statuses[name])
+		}
+	}
+	if d.OK() {
+		t.Error("Diagnosis with a failed step should not be OK")
+	}
+
+	auth := d.Steps[len(d.Steps)-1]
+	if auth.Reason != ReasonAuthRequired || auth.Fix == "" {
+		t.Errorf("Auth step should carry a reason and a fix, got %+v", auth)
+	}
+}
+
+func TestDiagnose_Refused(t *testing.T) {
+	pm := NewPingManager(5*time.Second, "")
+	d := pm.Diagnose(context.Background(), config.SSHHost{Name: "closed", Hostname: "127.0.0.1", Port: "1"})
+
+	statuses := stepStatuses(d)
+	if statuses[StepTCP] != StepFail {
+		t.Fatalf("Expected tcp step to fail, got %s", statuses[StepTCP])
+	}
+	for _, name := range []string{StepBanner, StepKEX, StepAuth} {
+		if statuses[name] != StepSkip {
+			t.Errorf("Step %s should be skipped after a TCP failure, got %s", name, statuses[name])
+		}
+	}
+
+	for _, step := range d.Steps {
+		if step.Name == StepTCP && (step.Reason != ReasonRefused || step.Fix == "") {
+			t.Errorf("TCP step should be refused with a fix, got %+v", step)
+		}
+	}
+}
+
+func TestDiagnose_ProxyCommand(t *testing.T) {
+	pm := NewPingManager(time.Second, "")
+	d := pm.Diagnose(context.Background(), config.SSHHost{Name: "proxied", ProxyCommand: "nc %h %p"})
+
+	for _, step := range d.Steps {
+		if step.Status != StepSkip {
+			t.Errorf("Step %s should be skipped for ProxyCommand hosts, got %s", step.Name, step.Status)
+		}
+	}
+}
+
+func TestDialTimeout(t *testing.T) {
+	if got := dialTimeout(context.Background(), 3); got != 0 {
+		t.Errorf("Expected no timeout without a deadline, got %s", got)
+	}
+
+	// Each address gets its share of the time left
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if got := dialTimeout(ctx, 3); got <= 900*time.Millisecond || got > time.Second {
+		t.Errorf("Expected about a second per address, got %s", got)
+	}
+}
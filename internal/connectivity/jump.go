@@ -0,0 +1,278 @@
+package connectivity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// maxJumpDepth limits nested ProxyJump resolution to guard against loops
+const maxJumpDepth = 8
+
+// HopResult represents the outcome of reaching a single hop of a ProxyJump chain
+type HopResult struct {
+	Name     string
+	Address  string
+	Status   PingStatus
+	Error    error
+	Reason   FailureReason
+	Duration time.Duration
+}
+
+// jumpHop describes a resolved jump host ready to be dialed
+type jumpHop struct {
+	name    string
+	address string
+	user    string
+	host    config.SSHHost
+}
+
+// errJumpUnsupported signals that a chain cannot be handled natively
+// and the external ssh command should be used instead
+var errJumpUnsupported = errors.New("jump chain not supported natively")
+
+// parseJumpSpec splits a single ProxyJump entry ([user@]host[:port] or ssh://...) into its parts
+func parseJumpSpec(spec string) (user, host, port string) {
+	spec = strings.TrimPrefix(strings.TrimSpace(spec), "ssh://")
+
+	if at := strings.LastIndex(spec, "@"); at != -1 {
+		user = spec[:at]
+		spec = spec[at+1:]
+	}
+
+	if h, p, err := net.SplitHostPort(spec); err == nil {
+		return user, h, p
+	}
+	return user, strings.Trim(spec, "[]"), ""
+}
+
+// resolveJumpChain turns a ProxyJump value into the ordered list of hops to dial,
+// using the SSH config to resolve aliases and nested jumps
+func (pm *PingManager) resolveJumpChain(proxyJump string, depth int) ([]jumpHop, error) {
+	if depth > maxJumpDepth {
+		return nil, errJumpUnsupported
+	}
+	if strings.EqualFold(strings.TrimSpace(proxyJump), "none") {
+		return nil, nil
+	}
+
+	var hops []jumpHop
+	for _, spec := range strings.Split(proxyJump, ",") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		specUser, specHost, specPort := parseJumpSpec(spec)
+
+		host := config.SSHHost{Name: specHost}
+		if configured, ok := pm.lookupHost(specHost); ok {
+			host = *configured
+		}
+		if host.ProxyCommand != "" {
+			return nil, errJumpUnsupported
+		}
+		if host.ProxyJump != "" {
+			nested, err := pm.resolveJumpChain(host.ProxyJump, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			hops = append(hops, nested...)
+		}
+
+		hostname := host.Hostname
+		if hostname == "" {
+			hostname = specHost
+		}
+		port := specPort
+		if port == "" {
+			port = host.Port
+		}
+		if port == "" {
+			port = "22"
+		}
+		if specUser != "" {
+			host.User = specUser
+		}
+		if host.User == "" {
+			host.User = currentUsername()
+		}
+
+		hops = append(hops, jumpHop{
+			name:    specHost,
+			address: net.JoinHostPort(hostname, port),
+			user:    host.User,
+			host:    host,
+		})
+	}
+
+	return hops, nil
+}
+
+// lookupHost finds a host by name in the SSH config used by the manager
+func (pm *PingManager) lookupHost(name string) (*config.SSHHost, bool) {
+	var host *config.SSHHost
+	var err error
+	if pm.configFile != "" {
+		host, err = config.GetSSHHostFromFile(name, pm.configFile)
+	} else {
+		host, err = config.GetSSHHost(name)
+	}
+	if err != nil {
+		return nil, false
+	}
+	return host, true
+}
+
+// pingThroughJumps checks a host reachable through ProxyJump using the native SSH client.
+// It returns false when the chain cannot be handled natively (nested ProxyCommand,
+// no usable keys or rejected authentication) so the caller can fall back to the ssh binary.
+func (pm *PingManager) pingThroughJumps(ctx context.Context, host config.SSHHost, start time.Time) (*HostPingResult, bool) {
+	jumps, err := pm.resolveJumpChain(host.ProxyJump, 0)
+	if err != nil || len(jumps) == 0 {
+		return nil, false
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, pm.timeout)
+	defer cancel()
+
+	var clients []*ssh.Client
+	defer func() {
+		for i := len(clients) - 1; i >= 0; i-- {
+			clients[i].Close()
+		}
+	}()
+
+	var via *ssh.Client
+	var hops []HopResult
+	for _, jump := range jumps {
+		hopStart := time.Now()
+
+		auth, closeAuth := authMethods(jump.host)
+		if len(auth) == 0 {
+			closeAuth()
+			return nil, false
+		}
+
+		client, err := dialSSH(pingCtx, via, jump.address, &ssh.ClientConfig{
+			User:            jump.user,
+			Auth:            auth,
+			HostKeyCallback: pm.hostKeyCallback(jump.name),
+			Timeout:         pm.timeout,
+		})
+		closeAuth()
+
+		hop := HopResult{Name: jump.name, Address: jump.address, Duration: time.Since(hopStart)}
+		if err != nil {
+			if ClassifyError(err) == ReasonAuthRequired {
+				return nil, false
+			}
+			hop.Status = StatusOffline
+			hop.Error = err
+			hop.Reason = ClassifyError(err)
+			hops = append(hops, hop)
+			return pm.finishPing(&HostPingResult{
+				HostName: host.Name,
+				Status:   StatusOffline,
+				Error:    fmt.Errorf("jump host %s: %w", jump.name, err),
+				Duration: time.Since(start),
+				Hops:     hops,
+			}), true
+		}
+
+		hop.Status = StatusOnline
+		hops = append(hops, hop)
+		clients = append(clients, client)
+		via = client
+	}
+
+	// Determine the actual hostname and port of the final target
+	hostname := host.Hostname
+	if hostname == "" {
+		hostname = host.Name
+	}
+	port := host.Port
+	if port == "" {
+		port = "22"
+	}
+	address := net.JoinHostPort(hostname, port)
+
+	hopStart := time.Now()
+	conn, err := dialTCP(pingCtx, via, address)
+	if err != nil {
+		hops = append(hops, HopResult{Name: host.Name, Address: address, Status: StatusOffline, Error: err, Reason: ClassifyError(err), Duration: time.Since(hopStart)})
+		return pm.finishPing(&HostPingResult{HostName: host.Name, Status: StatusOffline, Error: err, Duration: time.Since(start), Hops: hops}), true
+	}
+	defer conn.Close()
+
+	// As for direct hosts, only check that SSH answers on the target. A handshake
+	// aborted by the deadline is reported as a timeout, not as a handshake failure.
+	auth, authKey, err := pm.handshake(pingCtx, conn, address, host)
+
+	status := StatusOnline
+	if err != nil && isConnectionError(err) {
+		status = StatusOffline
+	}
+	hops = append(hops, HopResult{Name: host.Name, Address: address, Status: status, Error: err, Reason: ClassifyError(err), Duration: time.Since(hopStart)})
+
+	return pm.finishPing(&HostPingResult{
+		HostName: host.Name,
+		Status:   status,
+		Error:    err,
+		Duration: time.Since(start),
+		Hops:     hops,
+		Auth:     auth,
+		AuthKey:  authKey,
+	}), true
+}
+
+// dialTCP opens a TCP connection to addr, directly or tunnelled through an SSH client
+func dialTCP(ctx context.Context, via *ssh.Client, addr string) (net.Conn, error) {
+	if via == nil {
+		dialer := &net.Dialer{}
+		return dialer.DialContext(ctx, "tcp", addr)
+	}
+	return via.DialContext(ctx, "tcp", addr)
+}
+
+// dialSSH opens an authenticated SSH client to addr, directly or through an existing client
+func dialSSH(ctx context.Context, via *ssh.Client, addr string, cfg *ssh.ClientConfig) (*ssh.Client, error) {
+	conn, err := dialTCP(ctx, via, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tunnelled connections don't support deadlines, so abort the handshake on cancellation
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, cfg)
+	if !stop() {
+		if err == nil {
+			c.Close()
+		}
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, &handshakeError{err: err}
+	}
+
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// currentUsername returns the local user name, which ssh uses when no User is configured
+func currentUsername() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return os.Getenv("USERNAME")
+}
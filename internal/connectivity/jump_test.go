@@ -0,0 +1,234 @@
+package connectivity
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestParseJumpSpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		wantUser string
+		wantHost string
+		wantPort string
+	}{
+		{"bastion", "", "bastion", ""},
+		{"admin@bastion", "admin", "bastion", ""},
+		{"admin@bastion:2222", "admin", "bastion", "2222"},
+		{"ssh://admin@bastion:2222", "admin", "bastion", "2222"},
+		{"[2001:db8::1]:22", "", "2001:db8::1", "22"},
+		{" bastion.example.com ", "", "bastion.example.com", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			user, host, port := parseJumpSpec(tt.spec)
+			if user != tt.wantUser || host != tt.wantHost || port != tt.wantPort {
+				t.Errorf("parseJumpSpec(%q) = (%q, %q, %q), want (%q, %q, %q)",
+					tt.spec, user, host, port, tt.wantUser, tt.wantHost, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestResolveJumpChain(t *testing.T) {
+	tempDir := t.TempDir()
+	configFile := filepath.Join(tempDir, "config")
+	content := `Host outer
+    HostName outer.example.com
+    User jumper
+    Port 2200
+
+Host inner
+    HostName 10.0.0.5
+    ProxyJump outer
+
+Host scripted
+    HostName 10.0.0.6
+    ProxyCommand nc %h %p
+`
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	pm := NewPingManager(time.Second, configFile)
+
+	hops, err := pm.resolveJumpChain("inner,ops@plain:2022", 0)
+	if err != nil {
+		t.Fatalf("resolveJumpChain() error = %v", err)
+	}
+
+	want := []struct{ name, address, user string }{
+		{"outer", "outer.example.com:2200", "jumper"},
+		{"inner", "10.0.0.5:22", currentUsername()},
+		{"plain", "plain:2022", "ops"},
+	}
+	if len(hops) != len(want) {
+		t.Fatalf("Expected %d hops, got %d: %+v", len(want), len(hops), hops)
+	}
+	for i, w := range want {
+		if hops[i].name != w.name || hops[i].address != w.address || hops[i].user != w.user {
+			t.Errorf("hop %d = {%s %s %s}, want {%s %s %s}", i,
+				hops[i].name, hops[i].address, hops[i].user, w.name, w.address, w.user)
+		}
+	}
+
+	if _, err := pm.resolveJumpChain("scripted", 0); err != errJumpUnsupported {
+		t.Errorf("Expected errJumpUnsupported for a jump host using ProxyCommand, got %v", err)
+	}
+
+	if hops, err := pm.resolveJumpChain("none", 0); err != nil || len(hops) != 0 {
+		t.Errorf("Expected no hops for ProxyJump none, got %v (err %v)", hops, err)
+	}
+}
+
+func TestResolveJumpChain_Loop(t *testing.T) {
+	tempDir := t.TempDir()
+	configFile := filepath.Join(tempDir, "config")
+	content := `Host a
+    ProxyJump b
+
+Host b
+    ProxyJump a
+`
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	pm := NewPingManager(time.Second, configFile)
+	if _, err := pm.resolveJumpChain("a", 0); err != errJumpUnsupported {
+		t.Errorf("Expected errJumpUnsupported for a ProxyJump loop, got %v", err)
+	}
+}
+
+// startTestBastion runs an SSH server on localhost that forwards direct-tcpip
+// channels for the authorized key, like a jump host
+func startTestBastion(t *testing.T, authorized ssh.PublicKey) string {
+	t.Helper()
+
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("denied")
+		},
+	}
+	serverConfig.AddHostKey(newTestSigner(t))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	forward := func(newChannel ssh.NewChannel) {
+		var target struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}
+		if ssh.Unmarshal(newChannel.ExtraData(), &target) != nil {
+			newChannel.Reject(ssh.ConnectionFailed, "bad request")
+			return
+		}
+		conn, err := net.Dial("tcp", net.JoinHostPort(target.Host, strconv.Itoa(int(target.Port))))
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			return
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			conn.Close()
+			return
+		}
+		go ssh.DiscardRequests(requests)
+		go func() {
+			defer channel.Close()
+			_, _ = io.Copy(channel, conn)
+		}()
+		go func() {
+			defer conn.Close()
+			_, _ = io.Copy(conn, channel)
+		}()
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				sshConn, channels, requests, err := ssh.NewServerConn(conn, serverConfig)
+				if err != nil {
+					return
+				}
+				defer sshConn.Close()
+				go ssh.DiscardRequests(requests)
+				for newChannel := range channels {
+					if newChannel.ChannelType() != "direct-tcpip" {
+						newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+						continue
+					}
+					go forward(newChannel)
+				}
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestPingThroughJumps_TargetTimeout(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SSH_AUTH_SOCK", "")
+
+	keyPath := filepath.Join(home, ".ssh", "id_ed25519")
+	bastionHost, bastionPort, _ := net.SplitHostPort(startTestBastion(t, writeTestIdentity(t, keyPath)))
+
+	configFile := filepath.Join(home, "config")
+	content := fmt.Sprintf(`Host bastion
+    HostName %s
+    Port %s
+    User jumper
+    IdentityFile %s
+    UserKnownHostsFile /dev/null
+    GlobalKnownHostsFile /dev/null
+`, bastionHost, bastionPort, keyPath)
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	// The target accepts connections through the bastion but never answers the handshake
+	hostname, port, _ := net.SplitHostPort(startHungListener(t))
+	host := config.SSHHost{Name: "slow", Hostname: hostname, Port: port, ProxyJump: "bastion"}
+
+	pm := NewPingManager(time.Second, configFile)
+	result, ok := pm.pingThroughJumps(context.Background(), host, time.Now())
+	if !ok {
+		t.Fatal("Expected the chain to be handled natively")
+	}
+	if result.Status != StatusOffline || result.Reason != ReasonTimeout {
+		t.Errorf("Expected offline with %q, got %s with %q (%v)", ReasonTimeout, result.Status, result.Reason, result.Error)
+	}
+	if len(result.Hops) != 2 || result.Hops[0].Status != StatusOnline || result.Hops[1].Reason != ReasonTimeout {
+		t.Errorf("Expected the bastion online and the target timing out, got %+v", result.Hops)
+	}
+}
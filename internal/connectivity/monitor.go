@@ -0,0 +1,100 @@
+package connectivity
+
+import (
+	"time"
+)
+
+// HistorySize is the number of samples kept per host for monitoring
+const HistorySize = 20
+
+// PingSample represents one completed connectivity check
+type PingSample struct {
+	Time     time.Time
+	Status   PingStatus
+	Duration time.Duration
+}
+
+// sampleRing is a fixed-size ring buffer of ping samples
+type sampleRing struct {
+	samples [HistorySize]PingSample
+	next    int
+	count   int
+}
+
+// add appends a sample, overwriting the oldest one when the buffer is full
+func (r *sampleRing) add(sample PingSample) {
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % HistorySize
+	if r.count < HistorySize {
+		r.count++
+	}
+}
+
+// list returns the samples from oldest to newest
+func (r *sampleRing) list() []PingSample {
+	samples := make([]PingSample, 0, r.count)
+	start := (r.next - r.count + HistorySize) % HistorySize
+	for i := 0; i < r.count; i++ {
+		samples = append(samples, r.samples[(start+i)%HistorySize])
+	}
+	return samples
+}
+
+// recordSample adds the final result of a sweep to the host history and last known statuses.
+// Transient states and cancelled checks are not recorded.
+func (pm *PingManager) recordSample(result *HostPingResult) {
+	if result.Status != StatusOnline && result.Status != StatusOffline {
+		return
+	}
+
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	ring, exists := pm.history[result.HostName]
+	if !exists {
+		ring = &sampleRing{}
+		pm.history[result.HostName] = ring
+	}
+	ring.add(PingSample{
+		Time:     time.Now(),
+		Status:   result.Status,
+		Duration: result.Duration,
+	})
+	pm.rememberStatus(result)
+}
+
+// GetHistory returns the recorded samples for a host, oldest first
+func (pm *PingManager) GetHistory(hostName string) []PingSample {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	if ring, exists := pm.history[hostName]; exists {
+		return ring.list()
+	}
+	return nil
+}
+
+// GetUptime returns the percentage of recorded checks where the host was online
+func (pm *PingManager) GetUptime(hostName string) (float64, bool) {
+	samples := pm.GetHistory(hostName)
+	if len(samples) == 0 {
+		return 0, false
+	}
+
+	online := 0
+	for _, sample := range samples {
+		if sample.Status == StatusOnline {
+			online++
+		}
+	}
+	return float64(online) * 100 / float64(len(samples)), true
+}
+
+// StatusChanged reports whether the host status differs between the last two checks
+func (pm *PingManager) StatusChanged(hostName string) bool {
+	samples := pm.GetHistory(hostName)
+	if len(samples) < 2 {
+		return false
+	}
+	return samples[len(samples)-1].Status != samples[len(samples)-2].Status
+}
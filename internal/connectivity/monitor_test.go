@@ -0,0 +1,58 @@
+package connectivity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSampleRing_Wraparound(t *testing.T) {
+	var ring sampleRing
+	for i := 0; i < HistorySize+5; i++ {
+		ring.add(PingSample{Duration: time.Duration(i)})
+	}
+
+	samples := ring.list()
+	if len(samples) != HistorySize {
+		t.Fatalf("Expected %d samples, got %d", HistorySize, len(samples))
+	}
+	// The oldest samples are overwritten, the rest stay in order
+	for i, sample := range samples {
+		if want := time.Duration(i + 5); sample.Duration != want {
+			t.Errorf("Sample %d: expected duration %v, got %v", i, want, sample.Duration)
+		}
+	}
+}
+
+func TestPingManager_History(t *testing.T) {
+	pm := NewPingManager(1*time.Second, "")
+
+	if samples := pm.GetHistory("web"); samples != nil {
+		t.Errorf("Expected no history for unknown host, got %v", samples)
+	}
+	if _, ok := pm.GetUptime("web"); ok {
+		t.Error("Expected no uptime for unknown host")
+	}
+
+	// Connecting states are transient and must not be recorded
+	pm.recordSample(&HostPingResult{HostName: "web", Status: StatusConnecting})
+	pm.recordSample(&HostPingResult{HostName: "web", Status: StatusOnline, Duration: 10 * time.Millisecond})
+	pm.recordSample(&HostPingResult{HostName: "web", Status: StatusOnline, Duration: 20 * time.Millisecond})
+	pm.recordSample(&HostPingResult{HostName: "web", Status: StatusOnline, Duration: 30 * time.Millisecond})
+
+	if got := len(pm.GetHistory("web")); got != 3 {
+		t.Errorf("Expected 3 samples, got %d", got)
+	}
+	if pm.StatusChanged("web") {
+		t.Error("Expected no status change between two online checks")
+	}
+
+	pm.recordSample(&HostPingResult{HostName: "web", Status: StatusOffline, Duration: time.Second})
+
+	if !pm.StatusChanged("web") {
+		t.Error("Expected status change after going offline")
+	}
+	uptime, ok := pm.GetUptime("web")
+	if !ok || uptime != 75 {
+		t.Errorf("Expected 75%% uptime, got %v (ok=%v)", uptime, ok)
+	}
+}
@@ -1,12 +1,14 @@
 package connectivity
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"net"
 	"os/exec"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Gu1llaum-3/sshm/internal/config"
@@ -38,12 +40,25 @@ func (s PingStatus) String() string {
 	return "unknown"
 }
 
+// AuthStatus is the outcome of the optional authentication probe
+type AuthStatus string
+
+const (
+	AuthUnchecked AuthStatus = ""
+	AuthOK        AuthStatus = "auth_ok"
+	AuthDenied    AuthStatus = "auth_denied"
+)
+
 // HostPingResult represents the result of pinging a host
 type HostPingResult struct {
 	HostName string
 	Status   PingStatus
 	Error    error
+	Reason   FailureReason // Why the check did not complete a full SSH session, if it didn't
 	Duration time.Duration
+	Hops     []HopResult // Per-hop details when the host was reached through ProxyJump
+	Auth     AuthStatus  // Outcome of the authentication probe, when enabled
+	AuthKey  string      // Key that authenticated, when known
 }
 
 // PingManager manages SSH connectivity checks for multiple hosts
@@ -52,6 +67,16 @@ type PingManager struct {
 	mutex      sync.RWMutex
 	timeout    time.Duration
 	configFile string
+	settings   config.PingSettings
+	history    map[string]*sampleRing
+	lastKnown  map[string]CachedStatus
+	cachePath  string
+	authProbe  atomic.Bool
+
+	// Host key callbacks, by host name and by set of known_hosts files
+	knownHostsMutex sync.Mutex
+	hostKeys        map[string]ssh.HostKeyCallback
+	knownHosts      map[string]ssh.HostKeyCallback
 }
 
 // NewPingManager creates a new ping manager with the specified timeout
@@ -60,9 +85,32 @@ func NewPingManager(timeout time.Duration, configFile string) *PingManager {
 		results:    make(map[string]*HostPingResult),
 		timeout:    timeout,
 		configFile: configFile,
+		settings:   config.GetDefaultPingSettings(),
+		history:    make(map[string]*sampleRing),
+		lastKnown:  make(map[string]CachedStatus),
+		hostKeys:   make(map[string]ssh.HostKeyCallback),
+		knownHosts: make(map[string]ssh.HostKeyCallback),
 	}
 }
 
+// ApplySettings configures concurrency limits and retries used by PingAllHosts
+func (pm *PingManager) ApplySettings(settings config.PingSettings) {
+	pm.settings = settings
+	pm.authProbe.Store(settings.AuthProbe)
+}
+
+// SetAuthProbe enables or disables the authentication probe. When enabled, checks
+// authenticate with the SSH agent and identity files (never with a password) and
+// report whether a key was accepted.
+func (pm *PingManager) SetAuthProbe(enabled bool) {
+	pm.authProbe.Store(enabled)
+}
+
+// AuthProbeEnabled reports whether checks probe authentication
+func (pm *PingManager) AuthProbeEnabled() bool {
+	return pm.authProbe.Load()
+}
+
 // GetStatus returns the current status for a host
 func (pm *PingManager) GetStatus(hostName string) PingStatus {
 	pm.mutex.RLock()
@@ -85,15 +133,28 @@ func (pm *PingManager) GetResult(hostName string) (*HostPingResult, bool) {
 
 // updateStatus updates the status for a host
 func (pm *PingManager) updateStatus(hostName string, status PingStatus, err error, duration time.Duration) {
-	pm.mutex.Lock()
-	defer pm.mutex.Unlock()
-
-	pm.results[hostName] = &HostPingResult{
+	pm.storeResult(&HostPingResult{
 		HostName: hostName,
 		Status:   status,
 		Error:    err,
+		Reason:   ClassifyError(err),
 		Duration: duration,
-	}
+	})
+}
+
+// finishPing classifies the error of a completed check, records the result and returns it
+func (pm *PingManager) finishPing(result *HostPingResult) *HostPingResult {
+	result.Reason = ClassifyError(result.Error)
+	pm.storeResult(result)
+	return result
+}
+
+// storeResult saves the latest result for a host
+func (pm *PingManager) storeResult(result *HostPingResult) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	pm.results[result.HostName] = result
 }
 
 // PingHost performs an SSH connectivity check for a single host
@@ -103,10 +164,14 @@ func (pm *PingManager) PingHost(ctx context.Context, host config.SSHHost) *HostP
 	// Mark as connecting
 	pm.updateStatus(host.Name, StatusConnecting, nil, 0)
 
-	// If the host uses a ProxyJump or ProxyCommand, we need to use the external SSH command
-	// because implementing jump host support with pure Go ssh library requires
-	// handling authentication for the jump host, which is complex and requires
-	// access to the user's SSH agent or keys.
+	// ProxyJump chains are dialed natively, authenticating to each jump host with
+	// the SSH agent or identity files. ProxyCommand, and chains we can't handle
+	// natively, fall back to the external SSH command.
+	if host.ProxyJump != "" && host.ProxyCommand == "" {
+		if result, ok := pm.pingThroughJumps(ctx, host, start); ok {
+			return result
+		}
+	}
 	if host.ProxyJump != "" || host.ProxyCommand != "" {
 		return pm.pingWithExternalCommand(ctx, host, start)
 	}
@@ -130,29 +195,12 @@ func (pm *PingManager) PingHost(ctx context.Context, host config.SSHHost) *HostP
 	dialer := &net.Dialer{}
 	conn, err := dialer.DialContext(pingCtx, "tcp", net.JoinHostPort(hostname, port))
 	if err != nil {
-		duration := time.Since(start)
-		pm.updateStatus(host.Name, StatusOffline, err, duration)
-		return &HostPingResult{
-			HostName: host.Name,
-			Status:   StatusOffline,
-			Error:    err,
-			Duration: duration,
-		}
+		return pm.finishPing(&HostPingResult{HostName: host.Name, Status: StatusOffline, Error: err, Duration: time.Since(start)})
 	}
 	defer conn.Close()
 
 	// If TCP connection succeeds, try SSH handshake
-	sshConfig := &ssh.ClientConfig{
-		User:            host.User,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // For ping purposes only
-		Timeout:         time.Second * 2,             // Short timeout for handshake
-	}
-
-	// We don't need to authenticate, just check if SSH is responding
-	sshConn, _, _, err := ssh.NewClientConn(conn, net.JoinHostPort(hostname, port), sshConfig)
-	if sshConn != nil {
-		sshConn.Close()
-	}
+	auth, authKey, err := pm.handshake(pingCtx, conn, net.JoinHostPort(hostname, port), host)
 
 	duration := time.Since(start)
 
@@ -163,20 +211,72 @@ func (pm *PingManager) PingHost(ctx context.Context, host config.SSHHost) *HostP
 		status = StatusOffline
 	}
 
-	pm.updateStatus(host.Name, status, err, duration)
-	return &HostPingResult{
+	return pm.finishPing(&HostPingResult{
 		HostName: host.Name,
 		Status:   status,
 		Error:    err,
 		Duration: duration,
+		Auth:     auth,
+		AuthKey:  authKey,
+	})
+}
+
+// handshake performs the SSH handshake with the target of a check. Without the auth probe
+// no credentials are offered, as we only check that SSH is responding: the auth_required
+// error that follows is the expected outcome and not reported.
+func (pm *PingManager) handshake(ctx context.Context, conn net.Conn, address string, host config.SSHHost) (AuthStatus, string, error) {
+	// Abort the handshake when the check times out or is cancelled
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	sshConfig := &ssh.ClientConfig{
+		User:            host.User,
+		HostKeyCallback: pm.hostKeyCallback(host.Name), // Report changed host keys
+		Timeout:         time.Second * 2,               // Short timeout for handshake
+	}
+
+	probe := pm.authProbe.Load()
+	recorder := &authKeyRecorder{}
+	if probe {
+		auth, closeAuth := recordedAuthMethods(host, recorder)
+		defer closeAuth()
+		sshConfig.Auth = auth
+		if sshConfig.User == "" {
+			sshConfig.User = currentUsername()
+		}
+	}
+
+	sshConn, _, _, err := ssh.NewClientConn(conn, address, sshConfig)
+	if sshConn != nil {
+		sshConn.Close()
+	}
+	if err != nil && ctx.Err() != nil {
+		// The connection was closed by the deadline, not by the server
+		return AuthUnchecked, "", fmt.Errorf("ssh handshake: %w", ctx.Err())
+	}
+	if err != nil {
+		err = &handshakeError{err: err}
+	}
+
+	switch {
+	case !probe && ClassifyError(err) == ReasonAuthRequired:
+		return AuthUnchecked, "", nil
+	case !probe:
+		return AuthUnchecked, "", err
+	case err == nil:
+		return AuthOK, recorder.Last(), nil
+	case ClassifyError(err) == ReasonAuthRequired:
+		return AuthDenied, "", err
 	}
+	return AuthUnchecked, "", err
 }
 
 // pingWithExternalCommand pings a host using the external SSH command
 func (pm *PingManager) pingWithExternalCommand(ctx context.Context, host config.SSHHost, start time.Time) *HostPingResult {
 	// Construct the SSH command
-	// ssh -q -o BatchMode=yes -o StrictHostKeyChecking=no -o ConnectTimeout=5 host exit
-	args := []string{"-q", "-o", "BatchMode=yes", "-o", "StrictHostKeyChecking=no"}
+	// ssh -o LogLevel=ERROR -o BatchMode=yes -o StrictHostKeyChecking=no -o ConnectTimeout=5 host exit
+	// Errors are still printed so the failure reason can be derived from them
+	args := []string{"-o", "LogLevel=ERROR", "-o", "BatchMode=yes", "-o", "StrictHostKeyChecking=no"}
 
 	// Set timeout matching the manager's timeout
 	// Convert duration to seconds (rounding up to ensure we don't timeout too early in the command)
@@ -197,76 +297,175 @@ func (pm *PingManager) pingWithExternalCommand(ctx context.Context, host config.
 	// Create command with context for timeout cancellation
 	// Note: We used pm.timeout for the ssh command option, but we also respect the context deadline
 	cmd := exec.CommandContext(ctx, "ssh", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
 
 	// Run the command
 	err := cmd.Run()
 	duration := time.Since(start)
 
-	var status PingStatus
+	status := StatusOnline
 	if err != nil {
-		// SSH returns non-zero exit code on connection failure
-		status = StatusOffline
-	} else {
-		status = StatusOnline
+		err = &sshCommandError{err: err, stderr: lastLine(stderr.String())}
+		// SSH returns non-zero exit code on connection failure. Hosts that answered
+		// but rejected authentication are reachable, as for direct checks.
+		if reason := ClassifyError(err); reason == ReasonNone || isConnectionError(err) {
+			status = StatusOffline
+		}
 	}
 
-	pm.updateStatus(host.Name, status, err, duration)
-	return &HostPingResult{
-		HostName: host.Name,
-		Status:   status,
-		Error:    err,
-		Duration: duration,
+	// The ssh command authenticates non-interactively (BatchMode)
+	auth := AuthUnchecked
+	if pm.authProbe.Load() {
+		switch {
+		case err == nil:
+			auth = AuthOK
+		case ClassifyError(err) == ReasonAuthRequired:
+			auth = AuthDenied
+		}
 	}
+
+	return pm.finishPing(&HostPingResult{HostName: host.Name, Status: status, Error: err, Duration: duration, Auth: auth})
+}
+
+// lastLine returns the last non-empty line of a command output
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
 }
 
-// PingAllHosts pings all hosts concurrently and returns a channel of results
+// PingAllHosts pings hosts using a bounded pool of workers and returns a channel of results.
+// At most settings.Concurrency hosts are checked at once, and at most
+// settings.PerJumpHostConcurrency of them through the same jump host.
+// Results are delivered in the same order as hosts.
 func (pm *PingManager) PingAllHosts(ctx context.Context, hosts []config.SSHHost) <-chan *HostPingResult {
+	return pm.pingAll(ctx, hosts, true)
+}
+
+// PingAllHostsAsCompleted is like PingAllHosts, but delivers results as they complete
+// so a slow host does not hold back the others; callers match them to hosts by HostName.
+func (pm *PingManager) PingAllHostsAsCompleted(ctx context.Context, hosts []config.SSHHost) <-chan *HostPingResult {
+	return pm.pingAll(ctx, hosts, false)
+}
+
+// pingAll runs the worker pool behind PingAllHosts and PingAllHostsAsCompleted
+func (pm *PingManager) pingAll(ctx context.Context, hosts []config.SSHHost, ordered bool) <-chan *HostPingResult {
 	resultChan := make(chan *HostPingResult, len(hosts))
 
-	var wg sync.WaitGroup
+	workers := pm.settings.Concurrency
+	if workers <= 0 || workers > len(hosts) {
+		workers = len(hosts)
+	}
 
-	for _, host := range hosts {
-		wg.Add(1)
-		go func(h config.SSHHost) {
-			defer wg.Done()
-			result := pm.PingHost(ctx, h)
+	// One semaphore per jump host, shared by every host behind it
+	jumpLimits := make(map[string]chan struct{})
+	if pm.settings.PerJumpHostConcurrency > 0 {
+		for _, host := range hosts {
+			key := jumpHostKey(host)
+			if _, exists := jumpLimits[key]; key != "" && !exists {
+				jumpLimits[key] = make(chan struct{}, pm.settings.PerJumpHostConcurrency)
+			}
+		}
+	}
+
+	// Each host gets its own slot so results can be reordered
+	var slots []chan *HostPingResult
+	if ordered {
+		slots = make([]chan *HostPingResult, len(hosts))
+		for i := range slots {
+			slots[i] = make(chan *HostPingResult, 1)
+		}
+	}
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+		for i := range hosts {
 			select {
-			case resultChan <- result:
+			case jobs <- i:
 			case <-ctx.Done():
 				return
 			}
-		}(host)
+		}
+	}()
+
+	// Slots and resultChan hold a result per host, so workers never block on sending
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result := pm.pingWithRetry(ctx, hosts[i], jumpLimits[jumpHostKey(hosts[i])])
+				pm.recordSample(result)
+				if ordered {
+					slots[i] <- result
+				} else {
+					resultChan <- result
+				}
+			}
+		}()
 	}
 
-	// Close the channel when all goroutines are done
+	if !ordered {
+		go func() {
+			wg.Wait()
+			pm.SaveCache()
+			close(resultChan)
+		}()
+		return resultChan
+	}
+
+	// Stream results in host order, each one as soon as it and its predecessors are done
 	go func() {
-		wg.Wait()
-		close(resultChan)
+		defer close(resultChan)
+		defer pm.SaveCache()
+		for i := range hosts {
+			select {
+			case result := <-slots[i]:
+				resultChan <- result
+			case <-ctx.Done():
+				return
+			}
+		}
 	}()
 
 	return resultChan
 }
 
-// isConnectionError determines if an error is a connection-related error
-func isConnectionError(err error) bool {
-	if err == nil {
-		return false
+// pingWithRetry pings a host, retrying with exponential backoff while it is offline.
+// When limit is set, a slot is held for the whole check.
+func (pm *PingManager) pingWithRetry(ctx context.Context, host config.SSHHost, limit chan struct{}) *HostPingResult {
+	if limit != nil {
+		select {
+		case limit <- struct{}{}:
+			defer func() { <-limit }()
+		case <-ctx.Done():
+			return &HostPingResult{HostName: host.Name, Status: StatusUnknown, Error: ctx.Err()}
+		}
 	}
 
-	errStr := err.Error()
-	connectionErrors := []string{
-		"connection refused",
-		"no route to host",
-		"network is unreachable",
-		"timeout",
-		"connection timed out",
-	}
+	backoff := time.Duration(pm.settings.RetryBackoffMs) * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		result := pm.PingHost(ctx, host)
+		if result.Status != StatusOffline || attempt >= pm.settings.Retries {
+			return result
+		}
 
-	for _, connErr := range connectionErrors {
-		if strings.Contains(strings.ToLower(errStr), connErr) {
-			return true
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return result
 		}
 	}
+}
 
-	return false
+// jumpHostKey returns the first jump host a host is reached through, or "" for direct hosts
+func jumpHostKey(host config.SSHHost) string {
+	if host.ProxyJump == "" || strings.EqualFold(host.ProxyJump, "none") {
+		return ""
+	}
+	_, jumpHost, _ := parseJumpSpec(strings.Split(host.ProxyJump, ",")[0])
+	return strings.ToLower(jumpHost)
 }
@@ -2,6 +2,8 @@ package connectivity
 
 import (
 	"context"
+	"net"
+	"slices"
 	"testing"
 	"time"
 
@@ -142,3 +144,112 @@ func TestPingHost_Basic(t *testing.T) {
 		t.Error("Expected status to be set after ping attempt")
 	}
 }
+
+func TestPingAllHosts_AllResults(t *testing.T) {
+	pm := NewPingManager(1*time.Second, "")
+	pm.ApplySettings(config.PingSettings{Concurrency: 2, PerJumpHostConcurrency: 1, Retries: 0, RetryBackoffMs: 10})
+
+	// Port 1 on localhost is refused immediately, which keeps the test fast
+	hosts := []config.SSHHost{
+		{Name: "first", Hostname: "127.0.0.1", Port: "1"},
+		{Name: "second", Hostname: "127.0.0.1", Port: "1"},
+		{Name: "third", Hostname: "127.0.0.1", Port: "1"},
+		{Name: "fourth", Hostname: "127.0.0.1", Port: "1"},
+	}
+
+	seen := make(map[string]bool)
+	for result := range pm.PingAllHosts(context.Background(), hosts) {
+		seen[result.HostName] = true
+	}
+
+	if len(seen) != len(hosts) {
+		t.Fatalf("Expected %d results, got %d", len(hosts), len(seen))
+	}
+	for _, host := range hosts {
+		if !seen[host.Name] {
+			t.Errorf("Expected a result for %s", host.Name)
+		}
+	}
+}
+
+func TestPingAllHosts_SlowHostDoesNotBlock(t *testing.T) {
+	// A server that accepts connections and never answers keeps its host
+	// checking until the timeout
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+
+	pm := NewPingManager(time.Second, "")
+	pm.ApplySettings(config.PingSettings{Concurrency: 2, Retries: 0, RetryBackoffMs: 10})
+	hosts := []config.SSHHost{
+		{Name: "slow", Hostname: "127.0.0.1", Port: port},
+		{Name: "fast", Hostname: "127.0.0.1", Port: "1"},
+	}
+
+	var names []string
+	for result := range pm.PingAllHostsAsCompleted(context.Background(), hosts) {
+		names = append(names, result.HostName)
+	}
+	if len(names) != 2 || names[0] != "fast" {
+		t.Errorf("Expected the fast host first, got %v", names)
+	}
+}
+
+func TestPingAllHosts_InOrder(t *testing.T) {
+	// The first host is the slowest, yet results still follow the host order
+	hostname, port, _ := net.SplitHostPort(startHungListener(t))
+
+	pm := NewPingManager(500*time.Millisecond, "")
+	pm.ApplySettings(config.PingSettings{Concurrency: 3, Retries: 0, RetryBackoffMs: 10})
+	hosts := []config.SSHHost{
+		{Name: "slow", Hostname: hostname, Port: port},
+		{Name: "fast", Hostname: "127.0.0.1", Port: "1"},
+		{Name: "faster", Hostname: "127.0.0.1", Port: "1"},
+	}
+
+	var names []string
+	for result := range pm.PingAllHosts(context.Background(), hosts) {
+		names = append(names, result.HostName)
+	}
+	if !slices.Equal(names, []string{"slow", "fast", "faster"}) {
+		t.Errorf("Expected results in host order, got %v", names)
+	}
+}
+
+func TestPingAllHosts_Empty(t *testing.T) {
+	pm := NewPingManager(1*time.Second, "")
+	for range pm.PingAllHosts(context.Background(), nil) {
+		t.Error("Expected no results for an empty host list")
+	}
+}
+
+func TestJumpHostKey(t *testing.T) {
+	tests := []struct {
+		proxyJump string
+		expected  string
+	}{
+		{"", ""},
+		{"none", ""},
+		{"Bastion", "bastion"},
+		{"admin@bastion:2222,inner", "bastion"},
+	}
+
+	for _, tt := range tests {
+		host := config.SSHHost{Name: "target", ProxyJump: tt.proxyJump}
+		if got := jumpHostKey(host); got != tt.expected {
+			t.Errorf("jumpHostKey(%q) = %q, want %q", tt.proxyJump, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package connectivity
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// FailureReason classifies why a connectivity check did not complete a full SSH session
+type FailureReason string
+
+const (
+	ReasonNone            FailureReason = ""
+	ReasonDNSFailure      FailureReason = "dns_failure"
+	ReasonRefused         FailureReason = "refused"
+	ReasonTimeout         FailureReason = "timeout"
+	ReasonUnreachable     FailureReason = "unreachable"
+	ReasonHandshakeFailed FailureReason = "handshake_failed"
+	ReasonAuthRequired    FailureReason = "auth_required"
+	ReasonHostKeyMismatch FailureReason = "host_key_mismatch"
+)
+
+// Description returns a human readable explanation of the reason
+func (r FailureReason) Description() string {
+	switch r {
+	case ReasonDNSFailure:
+		return "hostname could not be resolved"
+	case ReasonRefused:
+		return "connection refused"
+	case ReasonTimeout:
+		return "connection timed out"
+	case ReasonUnreachable:
+		return "host or network unreachable"
+	case ReasonHandshakeFailed:
+		return "SSH handshake failed"
+	case ReasonAuthRequired:
+		return "SSH is up, authentication required"
+	case ReasonHostKeyMismatch:
+		return "host key does not match known_hosts"
+	}
+	return ""
+}
+
+// handshakeError marks an error returned by the SSH handshake, once the TCP connection succeeded
+type handshakeError struct {
+	err error
+}
+
+func (e *handshakeError) Error() string { return e.err.Error() }
+func (e *handshakeError) Unwrap() error { return e.err }
+
+// sshCommandError carries the diagnostics printed by the ssh binary when it failed
+type sshCommandError struct {
+	err    error
+	stderr string
+}
+
+func (e *sshCommandError) Error() string {
+	if e.stderr == "" {
+		return e.err.Error()
+	}
+	return e.err.Error() + ": " + e.stderr
+}
+
+func (e *sshCommandError) Unwrap() error { return e.err }
+
+// ClassifyError derives the failure reason of a connectivity check from its error
+func ClassifyError(err error) FailureReason {
+	if err == nil {
+		return ReasonNone
+	}
+
+	var keyErr *knownhosts.KeyError
+	var revokedErr *knownhosts.RevokedError
+	if (errors.As(err, &keyErr) && len(keyErr.Want) > 0) || errors.As(err, &revokedErr) {
+		return ReasonHostKeyMismatch
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		if dnsErr.IsTimeout {
+			return ReasonTimeout
+		}
+		return ReasonDNSFailure
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, syscall.ETIMEDOUT) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return ReasonTimeout
+	}
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ReasonRefused
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return ReasonUnreachable
+	}
+
+	// Jump hosts report failures to reach the next hop in the channel open error
+	var channelErr *ssh.OpenChannelError
+	if errors.As(err, &channelErr) {
+		if reason := classifySSHOutput(channelErr.Message); reason != ReasonNone {
+			return reason
+		}
+	}
+
+	var cmdErr *sshCommandError
+	if errors.As(err, &cmdErr) {
+		if reason := classifySSHOutput(cmdErr.stderr); reason != ReasonNone {
+			return reason
+		}
+	}
+
+	var hsErr *handshakeError
+	if errors.As(err, &hsErr) {
+		// The SSH client reports rejected authentication as a plain error
+		if strings.Contains(err.Error(), "unable to authenticate") {
+			return ReasonAuthRequired
+		}
+		return ReasonHandshakeFailed
+	}
+
+	return ReasonNone
+}
+
+// classifySSHOutput derives the failure reason from the diagnostics of the ssh binary
+func classifySSHOutput(stderr string) FailureReason {
+	output := strings.ToLower(stderr)
+	switch {
+	case strings.Contains(output, "could not resolve hostname"):
+		return ReasonDNSFailure
+	case strings.Contains(output, "connection refused"):
+		return ReasonRefused
+	case strings.Contains(output, "timed out"):
+		return ReasonTimeout
+	case strings.Contains(output, "no route to host"), strings.Contains(output, "network is unreachable"):
+		return ReasonUnreachable
+	case strings.Contains(output, "host key verification failed"), strings.Contains(output, "remote host identification has changed"):
+		return ReasonHostKeyMismatch
+	case strings.Contains(output, "permission denied"):
+		return ReasonAuthRequired
+	case strings.Contains(output, "kex_exchange_identification"), strings.Contains(output, "unable to negotiate"),
+		strings.Contains(output, "connection closed by"), strings.Contains(output, "connection reset"):
+		return ReasonHandshakeFailed
+	}
+	return ReasonNone
+}
+
+// isConnectionError reports whether an error means the host could not be reached at all
+func isConnectionError(err error) bool {
+	switch ClassifyError(err) {
+	case ReasonDNSFailure, ReasonRefused, ReasonTimeout, ReasonUnreachable:
+		return true
+	}
+	return false
+}
+
+// hostKeyCallback checks the host keys of a host against the known_hosts files ssh uses
+// for it, so that changed keys are reported. Unknown hosts are accepted, as with
+// StrictHostKeyChecking=accept-new. Files are read once per manager, not on every check.
+func (pm *PingManager) hostKeyCallback(name string) ssh.HostKeyCallback {
+	pm.knownHostsMutex.Lock()
+	defer pm.knownHostsMutex.Unlock()
+
+	if callback, ok := pm.hostKeys[name]; ok {
+		return callback
+	}
+	files := pm.knownHostsFiles(name)
+	key := strings.Join(files, "\n")
+	callback, ok := pm.knownHosts[key]
+	if !ok {
+		callback = knownHostsCallback(files...)
+		pm.knownHosts[key] = callback
+	}
+	pm.hostKeys[name] = callback
+	return callback
+}
+
+// knownHostsFiles returns the existing known_hosts files of a host, as reported by
+// ssh -G, falling back to the user's and the system's default files
+func (pm *PingManager) knownHostsFiles(name string) []string {
+	args := []string{"-G"}
+	if pm.configFile != "" {
+		args = append(args, "-F", pm.configFile)
+	}
+	output, err := exec.Command("ssh", append(args, "--", name)...).Output()
+
+	var files []string
+	if err == nil {
+		files = parseKnownHostsFiles(string(output))
+	} else {
+		if home, err := os.UserHomeDir(); err == nil {
+			files = append(files, filepath.Join(home, ".ssh", "known_hosts"))
+		}
+		files = append(files, "/etc/ssh/ssh_known_hosts")
+	}
+
+	var existing []string
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil && !info.IsDir() {
+			existing = append(existing, file)
+		}
+	}
+	return existing
+}
+
+// parseKnownHostsFiles returns the user and global known_hosts files listed in ssh -G output
+func parseKnownHostsFiles(output string) []string {
+	var files []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch strings.ToLower(fields[0]) {
+		case "userknownhostsfile", "globalknownhostsfile":
+			for _, file := range fields[1:] {
+				if strings.HasPrefix(file, "~/") {
+					if home, err := os.UserHomeDir(); err == nil {
+						file = filepath.Join(home, file[2:])
+					}
+				}
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+// knownHostsCallback returns a host key callback backed by the given known_hosts files
+func knownHostsCallback(paths ...string) ssh.HostKeyCallback {
+	if len(paths) == 0 {
+		return ssh.InsecureIgnoreHostKey()
+	}
+	callback, err := knownhosts.New(paths...)
+	if err != nil {
+		return ssh.InsecureIgnoreHostKey()
+	}
+
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := callback(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if !errors.As(err, &keyErr) {
+			return err
+		}
+		// Like ssh, only a different key of the same type is a mismatch; the server
+		// may simply have offered a key type that isn't recorded yet
+		for _, known := range keyErr.Want {
+			if known.Key.Type() == key.Type() {
+				return err
+			}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,235 @@
+package connectivity
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected FailureReason
+	}{
+		{"no error", nil, ReasonNone},
+		{"dns failure", &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "invalid", IsNotFound: true}}, ReasonDNSFailure},
+		{"dns timeout", &net.DNSError{Err: "i/o timeout", Name: "slow", IsTimeout: true}, ReasonTimeout},
+		{"refused", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}, ReasonRefused},
+		{"host unreachable", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.EHOSTUNREACH)}, ReasonUnreachable},
+		{"network unreachable", &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ENETUNREACH)}, ReasonUnreachable},
+		{"deadline", fmt.Errorf("dial: %w", context.DeadlineExceeded), ReasonTimeout},
+		{"auth", &handshakeError{err: errors.New("ssh: handshake failed: ssh: unable to authenticate, attempted methods [none]")}, ReasonAuthRequired},
+		{"handshake", &handshakeError{err: errors.New("ssh: handshake failed: EOF")}, ReasonHandshakeFailed},
+		{"host key mismatch", &handshakeError{err: fmt.Errorf("ssh: handshake failed: %w", &knownhosts.KeyError{Want: []knownhosts.KnownKey{{}}})}, ReasonHostKeyMismatch},
+		{"ssh command refused", &sshCommandError{err: errors.New("exit status 255"), stderr: "ssh: connect to host x port 22: Connection refused"}, ReasonRefused},
+		{"ssh command dns", &sshCommandError{err: errors.New("exit status 255"), stderr: "ssh: Could not resolve hostname x: Name or service not known"}, ReasonDNSFailure},
+		{"ssh command auth", &sshCommandError{err: errors.New("exit status 255"), stderr: "user@x: Permission denied (publickey)."}, ReasonAuthRequired},
+		{"ssh command unknown", &sshCommandError{err: errors.New("exit status 1")}, ReasonNone},
+		{"unknown", errors.New("something else"), ReasonNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.expected {
+				t.Errorf("ClassifyError() = %q, expected %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func newTestSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	return signer
+}
+
+func TestKnownHostsCallback(t *testing.T) {
+	known := newTestSigner(t)
+	other := newTestSigner(t)
+
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{"example.com"}, known.PublicKey()) + "\n"
+	if err := os.WriteFile(path, []byte(line), 0600); err != nil {
+		t.Fatalf("Failed to write known_hosts: %v", err)
+	}
+
+	callback := knownHostsCallback(path)
+	remote := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 22}
+
+	if err := callback("example.com:22", remote, known.PublicKey()); err != nil {
+		t.Errorf("Known key should be accepted, got %v", err)
+	}
+	if err := callback("unknown.example.com:22", remote, other.PublicKey()); err != nil {
+		t.Errorf("Unknown hosts should be accepted, got %v", err)
+	}
+	err := callback("example.com:22", remote, other.PublicKey())
+	if ClassifyError(err) != ReasonHostKeyMismatch {
+		t.Errorf("Changed key should be a host key mismatch, got %v", err)
+	}
+}
+
+func TestParseKnownHostsFiles(t *testing.T) {
+	output := "hostname example.com\n" +
+		"globalknownhostsfile /etc/ssh/ssh_known_hosts /etc/ssh/ssh_known_hosts2\n" +
+		"userknownhostsfile /home/me/.ssh/known_hosts /home/me/.ssh/work_hosts\n"
+	want := []string{
+		"/etc/ssh/ssh_known_hosts", "/etc/ssh/ssh_known_hosts2",
+		"/home/me/.ssh/known_hosts", "/home/me/.ssh/work_hosts",
+	}
+	if got := parseKnownHostsFiles(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// startTestSSHServer runs an SSH server on localhost that only accepts the authorized keys
+func startTestSSHServer(t *testing.T, hostKey ssh.Signer, authorized ...ssh.PublicKey) string {
+	t.Helper()
+
+	serverConfig := &ssh.ServerConfig{
+		PublicKeyCallback: func(_ ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			for _, authorizedKey := range authorized {
+				if bytes.Equal(key.Marshal(), authorizedKey.Marshal()) {
+					return nil, nil
+				}
+			}
+			return nil, errors.New("denied")
+		},
+	}
+	serverConfig.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				_, _, _, _ = ssh.NewServerConn(conn, serverConfig)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+// startHungListener accepts TCP connections on localhost without ever speaking SSH
+func startHungListener(t *testing.T) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	return listener.Addr().String()
+}
+
+func TestPingHost_Reasons(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// ssh -G reports the known_hosts file set in the config, ~/.ssh/known_hosts is
+	// the fallback when ssh is missing
+	knownHosts := filepath.Join(home, ".ssh", "known_hosts")
+	configFile := filepath.Join(home, "config")
+	sshConfig := fmt.Sprintf("Host *\n  UserKnownHostsFile %s\n  GlobalKnownHostsFile /dev/null\n", knownHosts)
+	if err := os.WriteFile(configFile, []byte(sshConfig), 0600); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+
+	hostKey := newTestSigner(t)
+	address := startTestSSHServer(t, hostKey)
+	hostname, port, _ := net.SplitHostPort(address)
+	host := config.SSHHost{Name: "local", Hostname: hostname, Port: port}
+
+	pm := NewPingManager(2*time.Second, configFile)
+	result := pm.PingHost(context.Background(), host)
+	// Without the auth probe, the rejected authentication is expected
+	if result.Status != StatusOnline || result.Reason != ReasonNone || result.Error != nil {
+		t.Errorf("Expected online with no reason, got %s with %q (%v)", result.Status, result.Reason, result.Error)
+	}
+
+	// Record a different key for the server
+	if err := os.MkdirAll(filepath.Dir(knownHosts), 0700); err != nil {
+		t.Fatalf("Failed to create .ssh: %v", err)
+	}
+	line := knownhosts.Line([]string{address}, newTestSigner(t).PublicKey()) + "\n"
+	if err := os.WriteFile(knownHosts, []byte(line), 0600); err != nil {
+		t.Fatalf("Failed to write known_hosts: %v", err)
+	}
+
+	// known_hosts files are read once per manager
+	result = pm.PingHost(context.Background(), host)
+	if result.Reason != ReasonNone {
+		t.Errorf("Expected known_hosts to be read once, got %q (%v)", result.Reason, result.Error)
+	}
+
+	pm = NewPingManager(2*time.Second, configFile)
+	result = pm.PingHost(context.Background(), host)
+	if result.Reason != ReasonHostKeyMismatch {
+		t.Errorf("Expected %q, got %q (%v)", ReasonHostKeyMismatch, result.Reason, result.Error)
+	}
+
+	closed := config.SSHHost{Name: "closed", Hostname: "127.0.0.1", Port: "1"}
+	result = pm.PingHost(context.Background(), closed)
+	if result.Status != StatusOffline || result.Reason != ReasonRefused {
+		t.Errorf("Expected offline with %q, got %s with %q", ReasonRefused, result.Status, result.Reason)
+	}
+}
+
+func TestPingHost_HandshakeTimeout(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	hostname, port, _ := net.SplitHostPort(startHungListener(t))
+	host := config.SSHHost{Name: "hung", Hostname: hostname, Port: port}
+
+	pm := NewPingManager(500*time.Millisecond, filepath.Join(t.TempDir(), "config"))
+	result := pm.PingHost(context.Background(), host)
+	if result.Status != StatusOffline || result.Reason != ReasonTimeout {
+		t.Errorf("Expected offline with %q, got %s with %q (%v)", ReasonTimeout, result.Status, result.Reason, result.Error)
+	}
+	if !errors.Is(result.Error, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline error, got %v", result.Error)
+	}
+}
@@ -0,0 +1,326 @@
+package connectivity
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// scanClientVersion identifies sshm to servers it scans
+const scanClientVersion = "SSH-2.0-sshm_scan"
+
+// msgKexInit is the SSH message number of the key exchange init packet
+const msgKexInit = 20
+
+// maxKexInitSize bounds the size of the KEXINIT packet we accept
+const maxKexInitSize = 64 * 1024
+
+// ServerScan holds what an SSH server offers during the key exchange
+type ServerScan struct {
+	Host      string          `json:"host"`
+	Address   string          `json:"address"`
+	Banner    string          `json:"banner"`
+	HostKeys  []string        `json:"host_keys"`
+	KEX       []string        `json:"kex"`
+	Ciphers   []string        `json:"ciphers"`
+	MACs      []string        `json:"macs"`
+	Weak      []WeakAlgorithm `json:"weak,omitempty"`
+	ScannedAt time.Time       `json:"scanned_at"`
+}
+
+// WeakAlgorithm is a deprecated algorithm offered by a server
+type WeakAlgorithm struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// weakAlgorithms lists deprecated algorithms by kind, with the reason they are flagged
+var weakAlgorithms = map[string]map[string]string{
+	"host_key": {
+		"ssh-rsa": "RSA signatures with SHA-1",
+		"ssh-dss": "DSA keys are limited to 1024 bits",
+	},
+	"kex": {
+		"diffie-hellman-group1-sha1":               "1024-bit group with SHA-1",
+		"diffie-hellman-group14-sha1":              "SHA-1 exchange hash",
+		"diffie-hellman-group-exchange-sha1":       "SHA-1 exchange hash",
+		"gss-group1-sha1-toWM5Slw5Ew8Mqkay+al2g==": "1024-bit group with SHA-1",
+	},
+	"mac": {
+		"hmac-md5":                     "MD5",
+		"hmac-md5-96":                  "MD5, truncated",
+		"hmac-sha1-96":                 "truncated SHA-1",
+		"hmac-md5-etm@openssh.com":     "MD5",
+		"hmac-md5-96-etm@openssh.com":  "MD5, truncated",
+		"hmac-sha1-96-etm@openssh.com": "truncated SHA-1",
+	},
+}
+
+// findWeakAlgorithms flags the deprecated algorithms offered in a scan
+func findWeakAlgorithms(scan *ServerScan) []WeakAlgorithm {
+	var weak []WeakAlgorithm
+	check := func(kind string, names []string) {
+		for _, name := range names {
+			if reason, exists := weakAlgorithms[kind][name]; exists {
+				weak = append(weak, WeakAlgorithm{Kind: kind, Name: name, Reason: reason})
+			}
+		}
+	}
+
+	check("host_key", scan.HostKeys)
+	check("kex", scan.KEX)
+	for _, name := range scan.Ciphers {
+		switch {
+		case strings.HasSuffix(name, "-cbc") || strings.HasSuffix(name, "-cbc@lysator.liu.se"):
+			weak = append(weak, WeakAlgorithm{Kind: "cipher", Name: name, Reason: "CBC mode"})
+		case strings.HasPrefix(name, "arcfour"):
+			weak = append(weak, WeakAlgorithm{Kind: "cipher", Name: name, Reason: "RC4 stream cipher"})
+		}
+	}
+	check("mac", scan.MACs)
+	return weak
+}
+
+// ScanHost connects to a host, directly or through its ProxyJump chain, and records
+// its version banner and the algorithms it offers. No authentication takes place.
+func (pm *PingManager) ScanHost(ctx context.Context, host config.SSHHost) (*ServerScan, error) {
+	if host.ProxyCommand != "" {
+		return nil, errors.New("hosts using ProxyCommand cannot be scanned")
+	}
+
+	hostname := host.Hostname
+	if hostname == "" {
+		hostname = host.Name
+	}
+	port := host.Port
+	if port == "" {
+		port = "22"
+	}
+	address := net.JoinHostPort(hostname, port)
+
+	scanCtx, cancel := context.WithTimeout(ctx, pm.timeout)
+	defer cancel()
+
+	var via *ssh.Client
+	if host.ProxyJump != "" && !strings.EqualFold(host.ProxyJump, "none") {
+		jumps, err := pm.resolveJumpChain(host.ProxyJump, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, jump := range jumps {
+			auth, closeAuth := authMethods(jump.host)
+			client, err := dialSSH(scanCtx, via, jump.address, &ssh.ClientConfig{
+				User:            jump.user,
+				Auth:            auth,
+				HostKeyCallback: pm.hostKeyCallback(jump.name),
+				Timeout:         pm.timeout,
+			})
+			closeAuth()
+			if err != nil {
+				return nil, fmt.Errorf("jump host %s: %w", jump.name, err)
+			}
+			defer client.Close()
+			via = client
+		}
+	}
+
+	conn, err := dialTCP(scanCtx, via, address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(scanCtx, func() { conn.Close() })
+	defer stop()
+
+	scan, err := readServerHello(conn)
+	if err != nil {
+		return nil, &handshakeError{err: err}
+	}
+	scan.Host = host.Name
+	scan.Address = address
+	scan.ScannedAt = time.Now()
+	scan.Weak = findWeakAlgorithms(scan)
+	return scan, nil
+}
+
+// readServerHello exchanges version strings with the server and parses its KEXINIT packet,
+// which is sent in clear text before any key is negotiated
+func readServerHello(conn net.Conn) (*ServerScan, error) {
+	reader := bufio.NewReader(conn)
+
+	if _, err := io.WriteString(conn, scanClientVersion+"\r\n"); err != nil {
+		return nil, err
+	}
+	banner, err := readBanner(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// Binary packet: uint32 length, byte padding length, payload, padding
+	var header [5]byte
+	if _, err := io.ReadFull(reader, header[:]); err != nil {
+		return nil, fmt.Errorf("reading KEXINIT: %w", err)
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	padding := uint32(header[4])
+	if length < padding+1 || length > maxKexInitSize {
+		return nil, fmt.Errorf("invalid KEXINIT packet length %d", length)
+	}
+	payload := make([]byte, length-1)
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, fmt.Errorf("reading KEXINIT: %w", err)
+	}
+	payload = payload[:len(payload)-int(padding)]
+
+	lists, err := parseKexInit(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ServerScan{
+		Banner:   banner,
+		KEX:      lists[0],
+		HostKeys: lists[1],
+		Ciphers:  mergeNameLists(lists[2], lists[3]),
+		MACs:     mergeNameLists(lists[4], lists[5]),
+	}, nil
+}
+
+// readBanner reads lines until the SSH identification string, which servers
+// may precede with other lines
+func readBanner(reader *bufio.Reader) (string, error) {
+	for i := 0; i < 20; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("reading SSH banner: %w", err)
+		}
+		if strings.HasPrefix(line, "SSH-") {
+			return strings.TrimSpace(line), nil
+		}
+	}
+	return "", errors.New("no SSH identification string received")
+}
+
+// parseKexInit extracts the algorithm name-lists of a KEXINIT payload: kex, host keys,
+// ciphers and MACs in each direction
+func parseKexInit(payload []byte) ([][]string, error) {
+	// Message number and 16-byte cookie
+	if len(payload) < 17 || payload[0] != msgKexInit {
+		return nil, errors.New("server did not send KEXINIT")
+	}
+	rest := payload[17:]
+
+	lists := make([][]string, 6)
+	for i := range lists {
+		if len(rest) < 4 {
+			return nil, errors.New("truncated KEXINIT")
+		}
+		size := binary.BigEndian.Uint32(rest[:4])
+		rest = rest[4:]
+		if uint32(len(rest)) < size {
+			return nil, errors.New("truncated KEXINIT")
+		}
+		if size > 0 {
+			lists[i] = strings.Split(string(rest[:size]), ",")
+		}
+		rest = rest[size:]
+	}
+	return lists, nil
+}
+
+// mergeNameLists returns the names of a followed by those only present in b
+func mergeNameLists(a, b []string) []string {
+	merged := append([]string(nil), a...)
+	for _, name := range b {
+		found := false
+		for _, existing := range a {
+			if existing == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, name)
+		}
+	}
+	return merged
+}
+
+// scanStorePath returns the file where scan results are kept
+func scanStorePath() (string, error) {
+	configDir, err := config.GetSSHMConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "scan_results.json"), nil
+}
+
+// LoadScans returns the stored scan results by host name
+func LoadScans() (map[string]ServerScan, error) {
+	path, err := scanStorePath()
+	if err != nil {
+		return nil, err
+	}
+	return loadScansFrom(path)
+}
+
+// loadScansFrom reads scan results from path, a missing file holds no results
+func loadScansFrom(path string) (map[string]ServerScan, error) {
+	scans := make(map[string]ServerScan)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return scans, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &scans); err != nil {
+		return nil, err
+	}
+	return scans, nil
+}
+
+// SaveScans stores scan results, replacing earlier results for the same hosts
+func SaveScans(results []*ServerScan) error {
+	path, err := scanStorePath()
+	if err != nil {
+		return err
+	}
+	return saveScansTo(path, results)
+}
+
+// saveScansTo merges scan results into the file at path
+func saveScansTo(path string, results []*ServerScan) error {
+	scans, err := loadScansFrom(path)
+	if err != nil {
+		// Start over rather than failing on a corrupted file
+		scans = make(map[string]ServerScan)
+	}
+	for _, scan := range results {
+		scans[scan.Host] = *scan
+	}
+
+	data, err := json.MarshalIndent(scans, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,95 @@
+package connectivity
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+func TestScanHost(t *testing.T) {
+	address := startTestSSHServer(t, newTestSigner(t))
+	hostname, port, _ := net.SplitHostPort(address)
+
+	pm := NewPingManager(5*time.Second, "")
+	scan, err := pm.ScanHost(context.Background(), config.SSHHost{Name: "local", Hostname: hostname, Port: port})
+	if err != nil {
+		t.Fatalf("ScanHost() error = %v", err)
+	}
+
+	if !strings.HasPrefix(scan.Banner, "SSH-2.0-") {
+		t.Errorf("Expected an SSH-2.0 banner, got %q", scan.Banner)
+	}
+	if len(scan.HostKeys) != 1 || scan.HostKeys[0] != "ssh-ed25519" {
+		t.Errorf("Expected the ed25519 host key to be offered, got %v", scan.HostKeys)
+	}
+	if len(scan.KEX) == 0 || len(scan.Ciphers) == 0 || len(scan.MACs) == 0 {
+		t.Errorf("Expected algorithm lists to be recorded, got %+v", scan)
+	}
+	if scan.Host != "local" || scan.Address != address {
+		t.Errorf("Unexpected host or address: %s %s", scan.Host, scan.Address)
+	}
+}
+
+func TestScanHost_Refused(t *testing.T) {
+	pm := NewPingManager(time.Second, "")
+	_, err := pm.ScanHost(context.Background(), config.SSHHost{Name: "closed", Hostname: "127.0.0.1", Port: "1"})
+	if ClassifyError(err) != ReasonRefused {
+		t.Errorf("Expected a refused error, got %v", err)
+	}
+}
+
+func TestFindWeakAlgorithms(t *testing.T) {
+	scan := &ServerScan{
+		HostKeys: []string{"ssh-ed25519", "ssh-rsa"},
+		KEX:      []string{"curve25519-sha256", "diffie-hellman-group1-sha1"},
+		Ciphers:  []string{"chacha20-poly1305@openssh.com", "aes128-cbc", "arcfour256"},
+		MACs:     []string{"hmac-sha2-256-etm@openssh.com", "hmac-md5"},
+	}
+
+	weak := findWeakAlgorithms(scan)
+	var names []string
+	for _, w := range weak {
+		names = append(names, w.Name)
+	}
+	expected := "ssh-rsa,diffie-hellman-group1-sha1,aes128-cbc,arcfour256,hmac-md5"
+	if strings.Join(names, ",") != expected {
+		t.Errorf("Expected weak algorithms %s, got %s", expected, strings.Join(names, ","))
+	}
+}
+
+func TestParseKexInit_Truncated(t *testing.T) {
+	payload := append([]byte{msgKexInit}, make([]byte, 16)...)
+	payload = append(payload, 0, 0, 0, 10, 'a')
+	if _, err := parseKexInit(payload); err == nil {
+		t.Error("Expected an error for a truncated KEXINIT")
+	}
+}
+
+func TestScanStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan_results.json")
+
+	first := &ServerScan{Host: "a", Banner: "SSH-2.0-A", ScannedAt: time.Now()}
+	second := &ServerScan{Host: "b", Banner: "SSH-2.0-B", ScannedAt: time.Now()}
+	if err := saveScansTo(path, []*ServerScan{first, second}); err != nil {
+		t.Fatalf("saveScansTo() error = %v", err)
+	}
+
+	// A later scan replaces the stored result of the same host only
+	updated := &ServerScan{Host: "a", Banner: "SSH-2.0-A2", ScannedAt: time.Now()}
+	if err := saveScansTo(path, []*ServerScan{updated}); err != nil {
+		t.Fatalf("saveScansTo() error = %v", err)
+	}
+
+	scans, err := loadScansFrom(path)
+	if err != nil {
+		t.Fatalf("loadScansFrom() error = %v", err)
+	}
+	if scans["a"].Banner != "SSH-2.0-A2" || scans["b"].Banner != "SSH-2.0-B" {
+		t.Errorf("Unexpected stored scans: %+v", scans)
+	}
+}
@@ -24,12 +24,23 @@ type PortForwardConfig struct {
 	BindAddress string `json:"bind_address"`
 }
 
+// ConnectionAttempt stores how one run of ssh to a host ended
+type ConnectionAttempt struct {
+	Time     time.Time `json:"time"`
+	Attempt  int       `json:"attempt"` // 1 for the first connection, then one more per reconnection
+	ExitCode int       `json:"exit_code"`
+}
+
+// maxAttempts is the number of attempts kept per host
+const maxAttempts = 20
+
 // ConnectionInfo stores information about a specific connection
 type ConnectionInfo struct {
-	HostName       string             `json:"host_name"`
-	LastConnect    time.Time          `json:"last_connect"`
-	ConnectCount   int                `json:"connect_count"`
-	PortForwarding *PortForwardConfig `json:"port_forwarding,omitempty"`
+	HostName       string              `json:"host_name"`
+	LastConnect    time.Time           `json:"last_connect"`
+	ConnectCount   int                 `json:"connect_count"`
+	PortForwarding *PortForwardConfig  `json:"port_forwarding,omitempty"`
+	Attempts       []ConnectionAttempt `json:"attempts,omitempty"`
 }
 
 // HistoryManager manages the connection history
@@ -162,6 +173,32 @@ func (hm *HistoryManager) RecordConnection(hostName string) error {
 	return hm.saveHistory()
 }
 
+// RecordAttempt records the exit code of an attempt to connect to a host,
+// keeping the most recent attempts only. The history is read again first, as
+// other sessions may have changed it since it was loaded.
+func (hm *HistoryManager) RecordAttempt(hostName string, attempt, exitCode int) error {
+	if err := hm.loadHistory(); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	conn, exists := hm.history.Connections[hostName]
+	if !exists {
+		conn = ConnectionInfo{HostName: hostName}
+	}
+	conn.Attempts = append(conn.Attempts, ConnectionAttempt{Time: time.Now(), Attempt: attempt, ExitCode: exitCode})
+	if len(conn.Attempts) > maxAttempts {
+		conn.Attempts = conn.Attempts[len(conn.Attempts)-maxAttempts:]
+	}
+	hm.history.Connections[hostName] = conn
+
+	return hm.saveHistory()
+}
+
+// GetAttempts returns the recorded attempts to connect to a host, oldest first
+func (hm *HistoryManager) GetAttempts(hostName string) []ConnectionAttempt {
+	return hm.history.Connections[hostName].Attempts
+}
+
 // GetLastConnectionTime returns the last connection time for a host
 func (hm *HistoryManager) GetLastConnectionTime(hostName string) (time.Time, bool) {
 	if conn, exists := hm.history.Connections[hostName]; exists {
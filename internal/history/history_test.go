@@ -162,3 +162,33 @@ func TestMigrateOldHistoryFile_NewFileExists(t *testing.T) {
 		t.Error("New file was modified when it shouldn't have been")
 	}
 }
+
+func TestHistoryManager_RecordAttempt(t *testing.T) {
+	hm := createTestHistoryManager(t)
+	if err := hm.RecordConnection("flaky"); err != nil {
+		t.Fatalf("RecordConnection() error = %v", err)
+	}
+
+	// Another session records an attempt in the same file
+	other := &HistoryManager{historyPath: hm.historyPath, history: &ConnectionHistory{Connections: make(map[string]ConnectionInfo)}}
+	if err := other.RecordAttempt("other", 1, 0); err != nil {
+		t.Fatalf("RecordAttempt() error = %v", err)
+	}
+
+	for attempt := 1; attempt <= maxAttempts+2; attempt++ {
+		if err := hm.RecordAttempt("flaky", attempt, 255); err != nil {
+			t.Fatalf("RecordAttempt() error = %v", err)
+		}
+	}
+
+	attempts := hm.GetAttempts("flaky")
+	if len(attempts) != maxAttempts || attempts[0].Attempt != 3 || attempts[len(attempts)-1].ExitCode != 255 {
+		t.Errorf("Expected the %d most recent attempts, got %d starting at %d", maxAttempts, len(attempts), attempts[0].Attempt)
+	}
+	if hm.GetConnectionCount("flaky") != 1 {
+		t.Errorf("Expected attempts not to count as connections, got %d", hm.GetConnectionCount("flaky"))
+	}
+	if len(hm.GetAttempts("other")) != 1 {
+		t.Error("Expected the attempts of other sessions to be kept")
+	}
+}
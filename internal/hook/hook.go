@@ -0,0 +1,173 @@
+package hook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+// Stages of a connection at which hooks run, as given to hooks in SSHM_HOOK
+const (
+	PreConnect     = "pre_connect"
+	PostDisconnect = "post_disconnect"
+)
+
+// newShellCommand runs a hook command through the shell
+var newShellCommand = func(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// Hooks are the commands run around the connections to a host
+type Hooks struct {
+	PreConnect     []string
+	PostDisconnect []string
+	Timeout        time.Duration
+}
+
+// Stdio is where hook commands read and write
+type Stdio struct {
+	In  io.Reader
+	Out io.Writer
+	Err io.Writer
+}
+
+// Terminal returns the standard streams of the process
+func Terminal() Stdio {
+	return Stdio{In: os.Stdin, Out: os.Stdout, Err: os.Stderr}
+}
+
+// Error is the failure of a hook command
+type Error struct {
+	Stage    string
+	Command  string
+	TimedOut bool
+	Timeout  time.Duration
+	Err      error
+}
+
+func (e *Error) Error() string {
+	stage := strings.ReplaceAll(e.Stage, "_", "-")
+	if e.TimedOut {
+		return fmt.Sprintf("%s hook '%s' timed out after %s", stage, e.Command, e.Timeout)
+	}
+	return fmt.Sprintf("%s hook '%s' failed: %v", stage, e.Command, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ForHost returns the hooks of host: the global hooks, then those of its tags
+// in the order the host lists them
+func ForHost(settings config.HookSettings, host config.SSHHost) Hooks {
+	hooks := Hooks{
+		PreConnect:     append([]string(nil), settings.Global.PreConnect...),
+		PostDisconnect: append([]string(nil), settings.Global.PostDisconnect...),
+		Timeout:        time.Duration(settings.TimeoutSeconds) * time.Second,
+	}
+	for _, tag := range host.Tags {
+		for name, commands := range settings.Tags {
+			if strings.EqualFold(name, tag) {
+				hooks.PreConnect = append(hooks.PreConnect, commands.PreConnect...)
+				hooks.PostDisconnect = append(hooks.PostDisconnect, commands.PostDisconnect...)
+			}
+		}
+	}
+	return hooks
+}
+
+// Empty reports whether there are no hooks to run
+func (h Hooks) Empty() bool {
+	return len(h.PreConnect) == 0 && len(h.PostDisconnect) == 0
+}
+
+// RunPreConnect runs the pre-connect hooks of host, stopping at the first
+// that fails, in which case the connection should not be made
+func (h Hooks) RunPreConnect(host config.SSHHost, stdio Stdio) error {
+	for _, command := range h.PreConnect {
+		if err := h.run(command, Env(host, PreConnect, 0), stdio); err != nil {
+			return h.error(PreConnect, command, err)
+		}
+	}
+	return nil
+}
+
+// RunPostDisconnect runs the post-disconnect hooks of host, given the exit
+// code of ssh, and returns the errors of those that failed
+func (h Hooks) RunPostDisconnect(host config.SSHHost, exitCode int, stdio Stdio) error {
+	var errs []error
+	for _, command := range h.PostDisconnect {
+		if err := h.run(command, Env(host, PostDisconnect, exitCode), stdio); err != nil {
+			errs = append(errs, h.error(PostDisconnect, command, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// error describes the failure of a hook command
+func (h Hooks) error(stage, command string, err error) *Error {
+	return &Error{Stage: stage, Command: command, TimedOut: errors.Is(err, context.DeadlineExceeded), Timeout: h.Timeout, Err: err}
+}
+
+// run runs a hook command with env added to the environment
+func (h Hooks) run(command string, env []string, stdio Stdio) error {
+	ctx := context.Background()
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+
+	cmd := newShellCommand(ctx, command)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = stdio.In
+	cmd.Stdout = stdio.Out
+	cmd.Stderr = stdio.Err
+	// Do not wait on children of the shell still holding the output open
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// Env returns the environment variables describing host to a hook
+func Env(host config.SSHHost, stage string, exitCode int) []string {
+	hostname := host.Hostname
+	if hostname == "" {
+		hostname = host.Name
+	}
+	port := host.Port
+	if port == "" {
+		port = "22"
+	}
+
+	env := []string{
+		"SSHM_HOOK=" + stage,
+		"SSHM_HOST=" + host.Name,
+		"SSHM_HOSTNAME=" + hostname,
+		"SSHM_USER=" + host.User,
+		"SSHM_PORT=" + port,
+		"SSHM_IDENTITY_FILE=" + host.Identity,
+		"SSHM_PROXY_JUMP=" + host.ProxyJump,
+		"SSHM_TAGS=" + strings.Join(host.Tags, ","),
+	}
+	if stage == PostDisconnect {
+		env = append(env, "SSHM_EXIT_CODE="+strconv.Itoa(exitCode))
+	}
+	return env
+}
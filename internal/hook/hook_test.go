@@ -0,0 +1,91 @@
+package hook
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+func TestForHost(t *testing.T) {
+	settings := config.HookSettings{
+		Global: config.HookCommands{PreConnect: []string{"vpn-up"}},
+		Tags: map[string]config.HookCommands{
+			"prod":    {PreConnect: []string{"fetch-cert"}, PostDisconnect: []string{"log-session"}},
+			"staging": {PreConnect: []string{"staging-only"}},
+		},
+		TimeoutSeconds: 10,
+	}
+
+	hooks := ForHost(settings, config.SSHHost{Name: "db", Tags: []string{"Prod"}})
+	if strings.Join(hooks.PreConnect, ",") != "vpn-up,fetch-cert" || strings.Join(hooks.PostDisconnect, ",") != "log-session" {
+		t.Errorf("ForHost() = %+v, want the global then the prod hooks", hooks)
+	}
+	if hooks.Timeout != 10*time.Second {
+		t.Errorf("Timeout = %s, want 10s", hooks.Timeout)
+	}
+
+	if !ForHost(config.HookSettings{}, config.SSHHost{Name: "db"}).Empty() {
+		t.Error("Expected no hooks without settings")
+	}
+	// The global hooks are not changed by those of the tags
+	if len(settings.Global.PreConnect) != 1 {
+		t.Errorf("Global hooks changed to %q", settings.Global.PreConnect)
+	}
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hooks are shell commands")
+	}
+	host := config.SSHHost{Name: "db", Hostname: "db.example.com", User: "deploy", Tags: []string{"prod", "eu"}}
+
+	var out bytes.Buffer
+	stdio := Stdio{In: strings.NewReader(""), Out: &out, Err: &out}
+	hooks := Hooks{
+		PreConnect:     []string{`echo "$SSHM_HOOK $SSHM_HOST $SSHM_USER@$SSHM_HOSTNAME:$SSHM_PORT $SSHM_TAGS"`},
+		PostDisconnect: []string{`echo "exit $SSHM_EXIT_CODE"`, "exit 4", "echo still run"},
+		Timeout:        5 * time.Second,
+	}
+	if err := hooks.RunPreConnect(host, stdio); err != nil {
+		t.Fatalf("RunPreConnect() error = %v", err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "pre_connect db deploy@db.example.com:22 prod,eu" {
+		t.Errorf("Unexpected hook output %q", got)
+	}
+
+	out.Reset()
+	err := hooks.RunPostDisconnect(host, 130, stdio)
+	var hookErr *Error
+	if !errors.As(err, &hookErr) || hookErr.Command != "exit 4" {
+		t.Errorf("RunPostDisconnect() error = %v, want the failing hook", err)
+	}
+	if got := out.String(); got != "exit 130\nstill run\n" {
+		t.Errorf("Expected every post-disconnect hook to run, got %q", got)
+	}
+
+	// A failing pre-connect hook stops the others
+	out.Reset()
+	hooks.PreConnect = []string{"echo no vpn >&2; exit 1", "echo unreachable"}
+	err = hooks.RunPreConnect(host, stdio)
+	if err == nil || !strings.Contains(err.Error(), "pre-connect hook 'echo no vpn >&2; exit 1' failed") {
+		t.Errorf("RunPreConnect() error = %v", err)
+	}
+	if out.String() != "no vpn\n" {
+		t.Errorf("Unexpected hook output %q", out.String())
+	}
+
+	hooks = Hooks{PreConnect: []string{"sleep 5"}, Timeout: 100 * time.Millisecond}
+	start := time.Now()
+	err = hooks.RunPreConnect(host, stdio)
+	if !errors.As(err, &hookErr) || !hookErr.TimedOut {
+		t.Errorf("RunPreConnect() error = %v, want a timeout", err)
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("Expected the hook to be stopped after the timeout, took %s", time.Since(start))
+	}
+}
@@ -0,0 +1,327 @@
+package mux
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+// Defaults written by Enable
+const (
+	DefaultControlPath    = "~/.ssh/sshm-%C"
+	DefaultControlPersist = "10m"
+)
+
+// checkTimeout bounds how long ssh -O check may take for a host
+const checkTimeout = 5 * time.Second
+
+// maxChecks is how many hosts List checks at once, each running ssh
+const maxChecks = 8
+
+// newSSHCommand creates the ssh command controlling a master, replaced in tests
+var newSSHCommand = func(ctx context.Context, args []string) *exec.Cmd {
+	return exec.CommandContext(ctx, "ssh", args...)
+}
+
+// Master is the control socket of a host and whether a master listens on it
+type Master struct {
+	Host   string
+	Socket string
+	Active bool
+}
+
+// option returns the value of an option of a host, options being kept one per
+// line in config format
+func option(options, name string) (string, bool) {
+	for _, line := range strings.Split(options, "\n") {
+		fields := strings.Fields(strings.Replace(line, "=", " ", 1))
+		if len(fields) >= 2 && strings.EqualFold(fields[0], name) {
+			return strings.Trim(strings.Join(fields[1:], " "), `"`), true
+		}
+	}
+	return "", false
+}
+
+// ControlPath returns the ControlPath of a host as written in its block, if
+// it sets one other than "none"
+func ControlPath(host config.SSHHost) (string, bool) {
+	path, ok := option(host.Options, "ControlPath")
+	if !ok || path == "" || strings.EqualFold(path, "none") {
+		return "", false
+	}
+	return path, true
+}
+
+// Socket returns the control socket path of a host as ssh resolves it, with
+// the options of every block matching the host, such as Host *. When ssh
+// cannot tell, the ControlPath of the host's own block is expanded instead.
+func Socket(ctx context.Context, host config.SSHHost, configFile string) (string, bool, error) {
+	if path, ok, err := resolveControlPath(ctx, host.Name, configFile); err == nil {
+		if !ok {
+			return "", false, nil
+		}
+		// Older versions of ssh print the ControlPath as written
+		if strings.Contains(path, "%") || strings.HasPrefix(path, "~") {
+			path, err = Expand(path, host)
+			if err != nil {
+				return "", false, err
+			}
+		}
+		return path, true, nil
+	}
+
+	path, ok := ControlPath(host)
+	if !ok {
+		return "", false, nil
+	}
+	socket, err := Expand(path, host)
+	if err != nil {
+		return "", false, err
+	}
+	return socket, true, nil
+}
+
+// resolveControlPath returns the ControlPath ssh -G reports for a host, if it
+// sets one other than "none"
+func resolveControlPath(ctx context.Context, hostName, configFile string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	args := []string{"-G"}
+	if configFile != "" {
+		args = append(args, "-F", configFile)
+	}
+	output, err := newSSHCommand(ctx, append(args, hostName)).Output()
+	if err != nil {
+		return "", false, err
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if strings.EqualFold(key, "controlpath") {
+			value = strings.TrimSpace(value)
+			return value, value != "" && !strings.EqualFold(value, "none"), nil
+		}
+	}
+	return "", false, nil
+}
+
+// Expand replaces the tokens of a ControlPath as ssh does: %h the hostname,
+// %p the port, %r the remote user, %C a hash of the connection, and the
+// local %d, %i, %L, %l, %n, %u and %j tokens. A leading ~ is the home directory.
+func Expand(path string, host config.SSHHost) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	localHost, _ := os.Hostname()
+	localUser := ""
+	if u, err := user.Current(); err == nil {
+		localUser = u.Username
+		// Windows usernames carry the domain
+		if i := strings.LastIndex(localUser, `\`); i >= 0 {
+			localUser = localUser[i+1:]
+		}
+	}
+
+	hostname := host.Hostname
+	if hostname == "" {
+		hostname = host.Name
+	}
+	hostname = strings.ReplaceAll(hostname, "%h", host.Name)
+	port := host.Port
+	if port == "" {
+		port = "22"
+	}
+	remoteUser := host.User
+	if remoteUser == "" {
+		remoteUser = localUser
+	}
+	shortHost, _, _ := strings.Cut(localHost, ".")
+
+	hash := sha1.Sum([]byte(localHost + hostname + port + remoteUser + host.ProxyJump))
+	tokens := map[byte]string{
+		'%': "%",
+		'C': hex.EncodeToString(hash[:]),
+		'd': home,
+		'h': hostname,
+		'i': strconv.Itoa(os.Getuid()),
+		'j': host.ProxyJump,
+		'L': shortHost,
+		'l': localHost,
+		'n': host.Name,
+		'p': port,
+		'r': remoteUser,
+		'u': localUser,
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		if path[i] != '%' {
+			b.WriteByte(path[i])
+			continue
+		}
+		if i+1 >= len(path) {
+			return "", fmt.Errorf("ControlPath '%s' ends with a lone %%", path)
+		}
+		value, ok := tokens[path[i+1]]
+		if !ok {
+			return "", fmt.Errorf("unknown token %%%c in ControlPath '%s'", path[i+1], path)
+		}
+		b.WriteString(value)
+		i++
+	}
+
+	expanded := b.String()
+	if expanded == "~" || strings.HasPrefix(expanded, "~/") {
+		expanded = filepath.Join(home, expanded[1:])
+	}
+	return expanded, nil
+}
+
+// controlArgs returns the ssh arguments sending a control command to the master of a host
+func controlArgs(hostName, configFile, command string) []string {
+	var args []string
+	if configFile != "" {
+		args = append(args, "-F", configFile)
+	}
+	return append(args, "-O", command, hostName)
+}
+
+// Check reports whether a master is running for a host. ssh is only asked
+// when the control socket exists.
+func Check(ctx context.Context, host config.SSHHost, configFile string) (Master, error) {
+	socket, ok, err := Socket(ctx, host, configFile)
+	if err != nil || !ok {
+		return Master{Host: host.Name}, err
+	}
+	master := Master{Host: host.Name, Socket: socket}
+	if _, err := os.Stat(socket); err != nil {
+		return master, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	master.Active = newSSHCommand(ctx, controlArgs(host.Name, configFile, "check")).Run() == nil
+	return master, nil
+}
+
+// List checks the masters of the hosts that have a ControlPath, sorted by host name
+func List(ctx context.Context, hosts []config.SSHHost, configFile string) []Master {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		masters []Master
+	)
+	limit := make(chan struct{}, maxChecks)
+	for _, host := range hosts {
+		wg.Add(1)
+		go func(host config.SSHHost) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			master, err := Check(ctx, host, configFile)
+			if err != nil || master.Socket == "" {
+				return
+			}
+			mu.Lock()
+			masters = append(masters, master)
+			mu.Unlock()
+		}(host)
+	}
+	wg.Wait()
+
+	sort.Slice(masters, func(i, j int) bool {
+		return masters[i].Host < masters[j].Host
+	})
+	return masters
+}
+
+// Stop asks the master of a host to exit, closing the connections multiplexed over it
+func Stop(hostName, configFile string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	output, err := newSSHCommand(ctx, controlArgs(hostName, configFile, "exit")).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("could not stop the master of %s: %s", hostName, msg)
+		}
+		return fmt.Errorf("could not stop the master of %s: %w", hostName, err)
+	}
+	return nil
+}
+
+// Enabled reports whether options turn multiplexing on
+func Enabled(options string) bool {
+	master, ok := option(options, "ControlMaster")
+	if !ok || strings.EqualFold(master, "no") {
+		return false
+	}
+	path, ok := option(options, "ControlPath")
+	return ok && !strings.EqualFold(path, "none")
+}
+
+// Enable adds the options turning multiplexing on, keeping those already set
+// unless they turn it off
+func Enable(options string) string {
+	var lines []string
+	for _, line := range nonEmptyLines(options) {
+		fields := strings.Fields(strings.Replace(line, "=", " ", 1))
+		if len(fields) >= 2 {
+			key, value := strings.ToLower(fields[0]), strings.ToLower(fields[1])
+			if (key == "controlmaster" && value == "no") || (key == "controlpath" && value == "none") {
+				continue
+			}
+		}
+		lines = append(lines, line)
+	}
+	options = strings.Join(lines, "\n")
+
+	if _, ok := option(options, "ControlMaster"); !ok {
+		lines = append(lines, "ControlMaster auto")
+	}
+	if _, ok := option(options, "ControlPath"); !ok {
+		lines = append(lines, "ControlPath "+DefaultControlPath)
+	}
+	if _, ok := option(options, "ControlPersist"); !ok {
+		lines = append(lines, "ControlPersist "+DefaultControlPersist)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Disable removes the multiplexing options
+func Disable(options string) string {
+	var kept []string
+	for _, line := range nonEmptyLines(options) {
+		fields := strings.Fields(strings.Replace(line, "=", " ", 1))
+		switch strings.ToLower(fields[0]) {
+		case "controlmaster", "controlpath", "controlpersist":
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.Join(kept, "\n")
+}
+
+// nonEmptyLines splits options into their non-empty lines
+func nonEmptyLines(options string) []string {
+	var lines []string
+	for _, line := range strings.Split(options, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,180 @@
+package mux
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+func TestExpand(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	localHost, _ := os.Hostname()
+	host := config.SSHHost{Name: "db", Hostname: "db.example.com", User: "deploy", Port: "2222"}
+
+	hash := sha1.Sum([]byte(localHost + "db.example.com" + "2222" + "deploy"))
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/tmp/cm-%r@%h:%p", "/tmp/cm-deploy@db.example.com:2222"},
+		{"~/.ssh/sshm-%C", filepath.Join(home, ".ssh", "sshm-"+hex.EncodeToString(hash[:]))},
+		{"%d/%n-100%%", home + "/db-100%"},
+	}
+	for _, tt := range tests {
+		got, err := Expand(tt.path, host)
+		if err != nil {
+			t.Errorf("Expand(%q) error = %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Expand(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	// The port defaults to 22 and the hostname to the host name
+	if got, _ := Expand("%h:%p", config.SSHHost{Name: "web"}); got != "web:22" {
+		t.Errorf("Expand() = %q, want the defaults", got)
+	}
+	for _, invalid := range []string{"/tmp/%z", "/tmp/%"} {
+		if _, err := Expand(invalid, host); err == nil {
+			t.Errorf("Expand(%q) expected an error", invalid)
+		}
+	}
+}
+
+func TestControlPath(t *testing.T) {
+	host := config.SSHHost{Name: "db", Options: "Compression yes\nControlPath ~/.ssh/cm-%C"}
+	if path, ok := ControlPath(host); !ok || path != "~/.ssh/cm-%C" {
+		t.Errorf("ControlPath() = %q, %v", path, ok)
+	}
+	host.Options = "ControlPath none"
+	if _, ok := ControlPath(host); ok {
+		t.Error("Expected ControlPath none to disable the socket")
+	}
+}
+
+func TestEnableDisable(t *testing.T) {
+	options := "Compression yes"
+	if Enabled(options) {
+		t.Error("Expected multiplexing to be off")
+	}
+
+	enabled := Enable(options)
+	if !Enabled(enabled) {
+		t.Errorf("Expected multiplexing to be on, got %q", enabled)
+	}
+	want := "Compression yes\nControlMaster auto\nControlPath " + DefaultControlPath + "\nControlPersist " + DefaultControlPersist
+	if enabled != want {
+		t.Errorf("Enable() = %q, want %q", enabled, want)
+	}
+
+	// Options already set are kept, those turning it off are replaced
+	if got := Enable("ControlMaster no\nControlPath /tmp/%C"); got != "ControlPath /tmp/%C\nControlMaster auto\nControlPersist "+DefaultControlPersist {
+		t.Errorf("Enable() = %q", got)
+	}
+
+	if got := Disable(enabled); got != "Compression yes" {
+		t.Errorf("Disable() = %q, want the other options only", got)
+	}
+}
+
+func TestCheckAndStop(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ssh is a shell script")
+	}
+	defer func() {
+		newSSHCommand = func(ctx context.Context, args []string) *exec.Cmd {
+			return exec.CommandContext(ctx, "ssh", args...)
+		}
+	}()
+
+	// Short path, sockets are limited to about 100 bytes
+	dir, err := os.MkdirTemp("", "mux")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// ssh -G resolves a ControlPath set for every host but plain
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	newSSHCommand = func(ctx context.Context, args []string) *exec.Cmd {
+		host := args[len(args)-1]
+		if args[0] == "-G" {
+			if host == "plain" {
+				return exec.CommandContext(ctx, "echo", "controlpath none")
+			}
+			return exec.CommandContext(ctx, "echo", "hostname "+host+"\ncontrolpath "+dir+"/"+host)
+		}
+		mu.Lock()
+		calls = append(calls, strings.Join(args, " "))
+		mu.Unlock()
+		if args[len(args)-2] == "exit" {
+			return exec.CommandContext(ctx, "sh", "-c", "echo 'Control socket connect: No such file' >&2; exit 255")
+		}
+		return exec.CommandContext(ctx, "true")
+	}
+
+	// The ControlPath comes from Host *, not from the blocks of the hosts
+	live := config.SSHHost{Name: "live"}
+	idle := config.SSHHost{Name: "idle"}
+	plain := config.SSHHost{Name: "plain"}
+
+	listener, err := net.Listen("unix", filepath.Join(dir, "live"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	masters := List(context.Background(), []config.SSHHost{plain, live, idle}, "/tmp/config")
+	if len(masters) != 2 || masters[0].Host != "idle" || masters[1].Host != "live" {
+		t.Fatalf("List() = %+v, want idle and live", masters)
+	}
+	if masters[0].Active || !masters[1].Active {
+		t.Errorf("Expected only live to be active, got %+v", masters)
+	}
+	// ssh is only asked about existing sockets
+	if len(calls) != 1 || calls[0] != "-F /tmp/config -O check live" {
+		t.Errorf("Unexpected ssh calls %q", calls)
+	}
+
+	err = Stop("idle", "")
+	if err == nil || !strings.Contains(err.Error(), "No such file") {
+		t.Errorf("Stop() error = %v, want the ssh error", err)
+	}
+}
+
+func TestSocketFallback(t *testing.T) {
+	defer func() {
+		newSSHCommand = func(ctx context.Context, args []string) *exec.Cmd {
+			return exec.CommandContext(ctx, "ssh", args...)
+		}
+	}()
+
+	// Without ssh, the ControlPath of the host's own block is expanded
+	newSSHCommand = func(ctx context.Context, args []string) *exec.Cmd {
+		return exec.CommandContext(ctx, filepath.Join(t.TempDir(), "missing-ssh"), args...)
+	}
+	host := config.SSHHost{Name: "db", Options: "ControlPath /tmp/cm-%n"}
+	if socket, ok, err := Socket(context.Background(), host, ""); err != nil || !ok || socket != "/tmp/cm-db" {
+		t.Errorf("Socket() = %q, %v, %v, want the expanded ControlPath", socket, ok, err)
+	}
+	if _, ok, _ := Socket(context.Background(), config.SSHHost{Name: "web"}, ""); ok {
+		t.Error("Expected no socket without a ControlPath")
+	}
+}
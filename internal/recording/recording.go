@@ -0,0 +1,386 @@
+package recording
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+// Tag is the host tag that turns on recording for every connection to the host
+const Tag = "record"
+
+// fileExt is the extension of asciicast files
+const fileExt = ".cast"
+
+// Header is the first line of an asciicast v2 file
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Recording describes a recorded session
+type Recording struct {
+	ID       string
+	Host     string
+	Path     string
+	Started  time.Time
+	Duration time.Duration
+	Size     int64
+}
+
+// Enabled reports whether the tags of a host turn on recording
+func Enabled(tags []string) bool {
+	for _, tag := range tags {
+		if strings.EqualFold(tag, Tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dir returns the directory holding recordings, creating it if needed.
+// Recordings may contain secrets typed or shown in sessions, so the directory is private.
+func Dir(settings config.RecordingSettings) (string, error) {
+	dir := settings.Directory
+	if dir == "" {
+		configDir, err := config.GetSSHMConfigDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(configDir, "recordings")
+	} else if strings.HasPrefix(dir, "~") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, dir[1:])
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// Writer writes a session to an asciicast v2 file as it happens
+type Writer struct {
+	mu      sync.Mutex
+	file    *os.File
+	path    string
+	start   time.Time
+	pending []byte // incomplete UTF-8 sequence at the end of the last output
+}
+
+// Create starts a recording of a session to host in dir
+func Create(dir, host string, width, height int) (*Writer, error) {
+	start := time.Now()
+	name := fmt.Sprintf("%s-%s%s", start.Format("20060102-150405"), sanitize(host), fileExt)
+	path := filepath.Join(dir, name)
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	header := Header{
+		Version:   2,
+		Width:     width,
+		Height:    height,
+		Timestamp: start.Unix(),
+		Title:     host,
+		Env: map[string]string{
+			"TERM":  os.Getenv("TERM"),
+			"SHELL": os.Getenv("SHELL"),
+		},
+	}
+	data, err := json.Marshal(header)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &Writer{file: file, path: path, start: start}, nil
+}
+
+// Path returns the path of the recording file
+func (w *Writer) Path() string {
+	return w.path
+}
+
+// Write records output of the session, so that a Writer can be used with io.Copy
+func (w *Writer) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Output is split at arbitrary points; keep partial characters for the next event
+	buf := append(w.pending, data...)
+	complete, rest := splitUTF8(buf)
+	w.pending = append([]byte(nil), rest...)
+	if len(complete) == 0 {
+		return len(data), nil
+	}
+	if err := w.event("o", string(complete)); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// Resize records a change of the terminal size
+func (w *Writer) Resize(width, height int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.event("r", fmt.Sprintf("%dx%d", width, height))
+}
+
+// event writes an event line; the caller holds the lock
+func (w *Writer) event(kind, data string) error {
+	elapsed := time.Since(w.start).Seconds()
+	line, err := json.Marshal([]interface{}{elapsed, kind, data})
+	if err != nil {
+		return err
+	}
+	_, err = w.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close flushes the remaining output and closes the file
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.pending) > 0 {
+		_ = w.event("o", string(w.pending))
+		w.pending = nil
+	}
+	return w.file.Close()
+}
+
+// splitUTF8 splits data before a trailing incomplete UTF-8 sequence
+func splitUTF8(data []byte) ([]byte, []byte) {
+	// A UTF-8 sequence is at most 4 bytes long
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(data[i]) {
+			continue
+		}
+		if !utf8.FullRune(data[i:]) {
+			return data[:i], data[i:]
+		}
+		break
+	}
+	return data, nil
+}
+
+// sanitize makes a host name safe to use in a file name
+func sanitize(host string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, host)
+}
+
+// List returns the recordings in dir, most recent first
+func List(dir string) ([]Recording, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var recordings []Recording
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fileExt) {
+			continue
+		}
+		rec, err := load(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			// Not an asciicast file, or a recording still being written
+			continue
+		}
+		recordings = append(recordings, rec)
+	}
+
+	sort.SliceStable(recordings, func(i, j int) bool {
+		return recordings[i].Started.After(recordings[j].Started)
+	})
+	return recordings, nil
+}
+
+// load reads the header and the duration of a recording
+func load(path string) (Recording, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Recording{}, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return Recording{}, err
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		return Recording{}, errors.New("empty recording")
+	}
+	var header Header
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil {
+		return Recording{}, err
+	}
+	if header.Version != 2 {
+		return Recording{}, fmt.Errorf("unsupported asciicast version %d", header.Version)
+	}
+
+	// The duration is the time of the last event
+	var last float64
+	for scanner.Scan() {
+		var event []interface{}
+		if json.Unmarshal(scanner.Bytes(), &event) == nil && len(event) > 0 {
+			if t, ok := event[0].(float64); ok {
+				last = t
+			}
+		}
+	}
+
+	return Recording{
+		ID:       strings.TrimSuffix(filepath.Base(path), fileExt),
+		Host:     header.Title,
+		Path:     path,
+		Started:  time.Unix(header.Timestamp, 0),
+		Duration: time.Duration(last * float64(time.Second)),
+		Size:     info.Size(),
+	}, nil
+}
+
+// Find returns the recording with the given ID, or the only one whose ID starts with it
+func Find(dir, id string) (Recording, error) {
+	recordings, err := List(dir)
+	if err != nil {
+		return Recording{}, err
+	}
+
+	var matches []Recording
+	for _, rec := range recordings {
+		if rec.ID == id {
+			return rec, nil
+		}
+		if strings.HasPrefix(rec.ID, id) {
+			matches = append(matches, rec)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return Recording{}, fmt.Errorf("recording '%s' not found", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return Recording{}, fmt.Errorf("'%s' matches %d recordings, use a longer ID", id, len(matches))
+	}
+}
+
+// Play writes the output of a recording to out with its original timing,
+// sped up by speed and with pauses shortened to idleLimit when it is positive
+func Play(ctx context.Context, out io.Writer, path string, speed float64, idleLimit time.Duration) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if speed <= 0 {
+		speed = 1
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	if !scanner.Scan() {
+		return errors.New("empty recording")
+	}
+
+	var previous float64
+	for scanner.Scan() {
+		var event []interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || len(event) != 3 {
+			continue
+		}
+		t, _ := event[0].(float64)
+		kind, _ := event[1].(string)
+		data, _ := event[2].(string)
+		if kind != "o" {
+			continue
+		}
+
+		delay := time.Duration((t - previous) / speed * float64(time.Second))
+		if idleLimit > 0 && delay > idleLimit {
+			delay = idleLimit
+		}
+		previous = t
+
+		if delay > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+		if _, err := io.WriteString(out, data); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// Prune deletes the recordings in dir that are past the retention settings
+// and returns how many were deleted
+func Prune(dir string, settings config.RecordingSettings) (int, error) {
+	if settings.RetentionDays <= 0 && settings.MaxRecordings <= 0 {
+		return 0, nil
+	}
+
+	recordings, err := List(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -settings.RetentionDays)
+	removed := 0
+	for i, rec := range recordings {
+		expired := settings.RetentionDays > 0 && rec.Started.Before(cutoff)
+		// Recordings are listed most recent first
+		extra := settings.MaxRecordings > 0 && i >= settings.MaxRecordings
+		if !expired && !extra {
+			continue
+		}
+		if err := os.Remove(rec.Path); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,149 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+func TestEnabled(t *testing.T) {
+	if !Enabled([]string{"prod", "Record"}) {
+		t.Error("Expected the 'record' tag to enable recording, case-insensitively")
+	}
+	if Enabled([]string{"prod"}) {
+		t.Error("Expected recording to be off without the 'record' tag")
+	}
+}
+
+func TestSplitUTF8(t *testing.T) {
+	euro := []byte("€") // 3 bytes
+	data := append([]byte("ab"), euro[:2]...)
+
+	complete, rest := splitUTF8(data)
+	if string(complete) != "ab" || !bytes.Equal(rest, euro[:2]) {
+		t.Errorf("Expected the partial character to be held back, got %q and %q", complete, rest)
+	}
+
+	complete, rest = splitUTF8([]byte("a€"))
+	if string(complete) != "a€" || len(rest) != 0 {
+		t.Errorf("Expected complete text to pass through, got %q and %q", complete, rest)
+	}
+}
+
+func TestWriteListAndPlay(t *testing.T) {
+	dir := t.TempDir()
+
+	writer, err := Create(dir, "prod/db", 100, 30)
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	euro := []byte("€")
+	writer.Write([]byte("hello "))
+	writer.Write(append([]byte("price: 5"), euro[:1]...))
+	writer.Write(euro[1:])
+	writer.Resize(120, 40)
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if !strings.HasSuffix(writer.Path(), "-prod_db.cast") {
+		t.Errorf("Expected the host name to be made safe for a file name, got %s", writer.Path())
+	}
+	info, err := os.Stat(writer.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected recordings to be private, got mode %v", info.Mode().Perm())
+	}
+
+	recordings, err := List(dir)
+	if err != nil || len(recordings) != 1 {
+		t.Fatalf("Expected 1 recording, got %d (%v)", len(recordings), err)
+	}
+	rec := recordings[0]
+	if rec.Host != "prod/db" {
+		t.Errorf("Expected host 'prod/db', got %q", rec.Host)
+	}
+
+	found, err := Find(dir, rec.ID[:8])
+	if err != nil || found.Path != rec.Path {
+		t.Errorf("Expected to find the recording by a prefix of its ID, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := Play(context.Background(), &out, rec.Path, 1, time.Millisecond); err != nil {
+		t.Fatalf("Play() error = %v", err)
+	}
+	if out.String() != "hello price: 5€" {
+		t.Errorf("Expected the recorded output, got %q", out.String())
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	// Three recordings, started 1, 10 and 100 days ago
+	for _, days := range []int{1, 10, 100} {
+		started := now.AddDate(0, 0, -days)
+		path := filepath.Join(dir, started.Format("20060102-150405")+"-host.cast")
+		header := fmt.Sprintf(`{"version":2,"width":80,"height":24,"timestamp":%d}`, started.Unix())
+		if err := os.WriteFile(path, []byte(header+"\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := Prune(dir, config.RecordingSettings{RetentionDays: 30})
+	if err != nil || removed != 1 {
+		t.Errorf("Expected 1 recording past retention to be deleted, got %d (%v)", removed, err)
+	}
+
+	removed, err = Prune(dir, config.RecordingSettings{MaxRecordings: 1})
+	if err != nil || removed != 1 {
+		t.Errorf("Expected 1 extra recording to be deleted, got %d (%v)", removed, err)
+	}
+
+	recordings, _ := List(dir)
+	if len(recordings) != 1 || now.Sub(recordings[0].Started) > 2*24*time.Hour {
+		t.Errorf("Expected only the most recent recording to be kept, got %v", recordings)
+	}
+}
+
+func TestCommandRecordsSession(t *testing.T) {
+	if !Supported {
+		t.Skip("session recording is not supported on this platform")
+	}
+	dir := t.TempDir()
+
+	session := NewCommand(exec.Command("sh", "-c", "echo recorded; exit 3"), dir, "web-1")
+	var out bytes.Buffer
+	session.SetStdin(strings.NewReader(""))
+	session.SetStdout(&out)
+
+	err := session.Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("Expected the exit code of the command, got %v", err)
+	}
+	if !strings.Contains(out.String(), "recorded") {
+		t.Errorf("Expected the output to reach the terminal, got %q", out.String())
+	}
+
+	var played bytes.Buffer
+	if err := Play(context.Background(), &played, session.Path(), 1, time.Millisecond); err != nil {
+		t.Fatalf("Play() error = %v", err)
+	}
+	if !strings.Contains(played.String(), "recorded") {
+		t.Errorf("Expected the output to be recorded, got %q", played.String())
+	}
+}
@@ -0,0 +1,119 @@
+//go:build !windows
+
+package recording
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/creack/pty"
+	"github.com/muesli/cancelreader"
+)
+
+// Supported reports whether sessions can be recorded on this platform
+const Supported = true
+
+// Command runs a command under a PTY attached to the terminal and records its
+// output. It can be run directly or handed to Bubble Tea with tea.Exec.
+type Command struct {
+	cmd    *exec.Cmd
+	dir    string
+	host   string
+	stdin  io.Reader
+	stdout io.Writer
+	path   string
+}
+
+// NewCommand prepares the recording of cmd, a session to host, into dir
+func NewCommand(cmd *exec.Cmd, dir, host string) *Command {
+	return &Command{cmd: cmd, dir: dir, host: host, stdin: os.Stdin, stdout: os.Stdout}
+}
+
+func (c *Command) SetStdin(r io.Reader)  { c.stdin = r }
+func (c *Command) SetStdout(w io.Writer) { c.stdout = w }
+func (c *Command) SetStderr(io.Writer)   {} // The PTY carries both output streams
+
+// Path returns the path of the recording, once Run has started it
+func (c *Command) Path() string {
+	return c.path
+}
+
+// Run runs the session and returns the error of the command, as exec.Cmd.Run does
+func (c *Command) Run() error {
+	width, height := 80, 24
+	stdinFile, isFile := c.stdin.(*os.File)
+	isTerminal := isFile && term.IsTerminal(stdinFile.Fd())
+	if isTerminal {
+		if w, h, err := term.GetSize(stdinFile.Fd()); err == nil && w > 0 && h > 0 {
+			width, height = w, h
+		}
+	}
+
+	writer, err := Create(c.dir, c.host, width, height)
+	if err != nil {
+		return err
+	}
+	defer writer.Close()
+	c.path = writer.Path()
+
+	ptmx, err := pty.StartWithSize(c.cmd, &pty.Winsize{Cols: uint16(width), Rows: uint16(height)})
+	if err != nil {
+		return err
+	}
+	defer ptmx.Close()
+
+	if isTerminal {
+		// Keys go to the remote side as typed, including ctrl+c
+		state, err := term.MakeRaw(stdinFile.Fd())
+		if err == nil {
+			defer term.Restore(stdinFile.Fd(), state)
+		}
+
+		// Follow the size of the terminal
+		resize := make(chan os.Signal, 1)
+		signal.Notify(resize, syscall.SIGWINCH)
+		defer func() {
+			signal.Stop(resize)
+			close(resize)
+		}()
+		go func() {
+			for range resize {
+				if w, h, err := term.GetSize(stdinFile.Fd()); err == nil {
+					_ = pty.Setsize(ptmx, &pty.Winsize{Cols: uint16(w), Rows: uint16(h)})
+					_ = writer.Resize(w, h)
+				}
+			}
+		}()
+	}
+
+	// The input reader is cancelled when the session ends, so that it does not
+	// swallow the next key press meant for sshm. Input that cannot be polled,
+	// like a regular file, needs no cancelling.
+	var input io.Reader = c.stdin
+	if reader, err := cancelreader.NewReader(c.stdin); err == nil {
+		defer reader.Close()
+		defer reader.Cancel()
+		input = reader
+	}
+	go func() {
+		_, _ = io.Copy(ptmx, input)
+	}()
+
+	// Reading fails once the command has exited and the PTY is closed
+	_, copyErr := io.Copy(io.MultiWriter(c.stdout, writer), ptmx)
+
+	waitErr := c.cmd.Wait()
+	if waitErr != nil {
+		return waitErr
+	}
+	var pathErr *os.PathError
+	if copyErr != nil && !errors.As(copyErr, &pathErr) {
+		return copyErr
+	}
+	return nil
+}
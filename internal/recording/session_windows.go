@@ -0,0 +1,35 @@
+//go:build windows
+
+package recording
+
+import (
+	"errors"
+	"io"
+	"os/exec"
+)
+
+// Supported reports whether sessions can be recorded on this platform
+const Supported = false
+
+// Command runs a command under a PTY and records its output; PTYs are not
+// available on Windows
+type Command struct{}
+
+// NewCommand prepares the recording of cmd, a session to host, into dir
+func NewCommand(cmd *exec.Cmd, dir, host string) *Command {
+	return &Command{}
+}
+
+func (c *Command) SetStdin(io.Reader)  {}
+func (c *Command) SetStdout(io.Writer) {}
+func (c *Command) SetStderr(io.Writer) {}
+
+// Path returns the path of the recording, once Run has started it
+func (c *Command) Path() string {
+	return ""
+}
+
+// Run returns an error since sessions cannot be recorded on Windows
+func (c *Command) Run() error {
+	return errors.New("session recording is not supported on Windows")
+}
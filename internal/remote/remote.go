@@ -0,0 +1,69 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// Command creates the ssh command running on a host, replaced in tests
+var Command = func(ctx context.Context, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "ssh", args...)
+}
+
+// Result is the outcome of a command run on a host
+type Result struct {
+	ExitCode int // Exit code of the command, -1 when it did not exit by itself
+	TimedOut bool
+	Err      error // Why the command did not exit by itself, if it didn't
+	Duration time.Duration
+}
+
+// Args returns the ssh arguments running command on a host non-interactively
+// (BatchMode), so that hosts asking for a password fail instead of waiting
+func Args(configFile, hostName string, command ...string) []string {
+	args := []string{"-o", "BatchMode=yes"}
+	if configFile != "" {
+		args = append(args, "-F", configFile)
+	}
+	args = append(args, hostName, "--")
+	return append(args, command...)
+}
+
+// Run runs command on a host through ssh, writing its output to stdout and
+// stderr. The command is stopped after timeout, unless it is 0. Given the same
+// writer for both, output is combined as it arrives.
+func Run(ctx context.Context, configFile, hostName string, command []string, timeout time.Duration, stdout, stderr io.Writer) Result {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	cmd := Command(ctx, Args(configFile, hostName, command...)...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	start := time.Now()
+	err := cmd.Run()
+	result := Result{Duration: time.Since(start)}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		result.ExitCode = 0
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.ExitCode = -1
+		result.TimedOut = true
+		result.Err = fmt.Errorf("timed out after %s", timeout)
+	case errors.As(err, &exitErr) && exitErr.ExitCode() >= 0:
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		result.ExitCode = -1
+		result.Err = err
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSSH replaces ssh with a shell script that receives the same arguments
+func fakeSSH(t *testing.T, script string) {
+	t.Helper()
+	original := Command
+	Command = func(ctx context.Context, args ...string) *exec.Cmd {
+		return exec.CommandContext(ctx, "sh", append([]string{"-c", script, "ssh"}, args...)...)
+	}
+	t.Cleanup(func() { Command = original })
+}
+
+func TestArgs(t *testing.T) {
+	want := []string{"-o", "BatchMode=yes", "-F", "/tmp/config", "web", "--", "df", "-h"}
+	if got := Args("/tmp/config", "web", "df", "-h"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	want = []string{"-o", "BatchMode=yes", "web", "--", "uptime"}
+	if got := Args("", "web", "uptime"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	// $3 is the host name after "-o BatchMode=yes"
+	fakeSSH(t, `echo "out from $3"; echo "err from $3" >&2; exit 3`)
+
+	var output bytes.Buffer
+	result := Run(context.Background(), "", "web", []string{"uptime"}, 0, &output, &output)
+	if result.ExitCode != 3 || result.TimedOut || result.Err != nil {
+		t.Errorf("Expected exit code 3, got %+v", result)
+	}
+	for _, expected := range []string{"out from web", "err from web"} {
+		if !strings.Contains(output.String(), expected) {
+			t.Errorf("Expected %q in combined output:\n%s", expected, output.String())
+		}
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	fakeSSH(t, `exec sleep 5`)
+
+	result := Run(context.Background(), "", "slow", []string{"uptime"}, 100*time.Millisecond, nil, nil)
+	if !result.TimedOut || result.ExitCode != -1 || result.Err == nil {
+		t.Errorf("Expected a timeout, got %+v", result)
+	}
+}
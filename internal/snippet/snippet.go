@@ -0,0 +1,215 @@
+package snippet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+// Snippet is a command run on hosts over ssh. A snippet without hosts and tags
+// applies to every host, otherwise to the hosts whose name matches one of
+// Hosts (names or glob patterns) or that carry one of Tags.
+type Snippet struct {
+	Name        string   `json:"name"`
+	Command     string   `json:"command"`
+	Description string   `json:"description,omitempty"`
+	Hosts       []string `json:"hosts,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	TTY         bool     `json:"tty,omitempty"` // allocate a terminal, for interactive commands
+}
+
+// Data is what the command of a snippet can refer to, as {{.Hostname}}
+type Data struct {
+	Name     string
+	Hostname string
+	User     string
+	Port     string
+	Tags     []string
+}
+
+// Global reports whether the snippet applies to every host
+func (s Snippet) Global() bool {
+	return len(s.Hosts) == 0 && len(s.Tags) == 0
+}
+
+// AppliesTo reports whether the snippet can run on host
+func (s Snippet) AppliesTo(host config.SSHHost) bool {
+	if s.Global() {
+		return true
+	}
+	for _, pattern := range s.Hosts {
+		if matched, err := path.Match(pattern, host.Name); err == nil && matched {
+			return true
+		}
+	}
+	for _, tag := range s.Tags {
+		for _, hostTag := range host.Tags {
+			if strings.EqualFold(tag, hostTag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Validate checks the name, the command template and the host patterns of a snippet
+func (s Snippet) Validate() error {
+	if strings.TrimSpace(s.Name) == "" {
+		return fmt.Errorf("snippet name is required")
+	}
+	if strings.ContainsAny(s.Name, " \t") {
+		return fmt.Errorf("snippet name '%s' cannot contain spaces", s.Name)
+	}
+	if strings.TrimSpace(s.Command) == "" {
+		return fmt.Errorf("snippet '%s' has no command", s.Name)
+	}
+	if _, err := template.New(s.Name).Option("missingkey=error").Parse(s.Command); err != nil {
+		return fmt.Errorf("snippet '%s': %w", s.Name, err)
+	}
+	for _, pattern := range s.Hosts {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("snippet '%s': invalid host pattern '%s'", s.Name, pattern)
+		}
+	}
+	return nil
+}
+
+// Render returns the command of the snippet for host, with the template
+// fields filled in
+func (s Snippet) Render(host config.SSHHost) (string, error) {
+	tmpl, err := template.New(s.Name).Option("missingkey=error").Parse(s.Command)
+	if err != nil {
+		return "", fmt.Errorf("snippet '%s': %w", s.Name, err)
+	}
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, NewData(host)); err != nil {
+		return "", fmt.Errorf("snippet '%s': %w", s.Name, err)
+	}
+	return b.String(), nil
+}
+
+// NewData returns the template data of host, with the defaults ssh uses for
+// the fields the host block leaves out
+func NewData(host config.SSHHost) Data {
+	data := Data{
+		Name:     host.Name,
+		Hostname: host.Hostname,
+		User:     host.User,
+		Port:     host.Port,
+		Tags:     host.Tags,
+	}
+	if data.Hostname == "" {
+		data.Hostname = host.Name
+	}
+	if data.Port == "" {
+		data.Port = "22"
+	}
+	if data.User == "" {
+		if u, err := user.Current(); err == nil {
+			data.User = u.Username
+			// Windows usernames carry the domain
+			if i := strings.LastIndex(data.User, `\`); i >= 0 {
+				data.User = data.User[i+1:]
+			}
+		}
+	}
+	return data
+}
+
+// ForHost returns the snippets that apply to host. A snippet scoped to hosts
+// or tags replaces a global snippet of the same name.
+func ForHost(snippets []Snippet, host config.SSHHost) []Snippet {
+	scoped := make(map[string]bool)
+	for _, s := range snippets {
+		if !s.Global() && s.AppliesTo(host) {
+			scoped[s.Name] = true
+		}
+	}
+
+	var applicable []Snippet
+	for _, s := range snippets {
+		if !s.AppliesTo(host) || (s.Global() && scoped[s.Name]) {
+			continue
+		}
+		applicable = append(applicable, s)
+	}
+	return applicable
+}
+
+// Find returns the snippet called name
+func Find(snippets []Snippet, name string) (Snippet, bool) {
+	for _, s := range snippets {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Snippet{}, false
+}
+
+// Path returns the file where snippets are kept
+func Path() (string, error) {
+	configDir, err := config.GetSSHMConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "snippets.json"), nil
+}
+
+// Load returns the snippets, sorted by name
+func Load() ([]Snippet, error) {
+	path, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	return loadFrom(path)
+}
+
+// loadFrom reads the snippets from path, a missing file holds none
+func loadFrom(path string) ([]Snippet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var snippets []Snippet
+	if err := json.Unmarshal(data, &snippets); err != nil {
+		return nil, fmt.Errorf("invalid snippets file %s: %w", path, err)
+	}
+	for _, s := range snippets {
+		if err := s.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid snippets file %s: %w", path, err)
+		}
+	}
+	sort.SliceStable(snippets, func(i, j int) bool {
+		return snippets[i].Name < snippets[j].Name
+	})
+	return snippets, nil
+}
+
+// SSHArgs returns the ssh arguments running the snippet on host
+func (s Snippet) SSHArgs(host config.SSHHost, configFile string) ([]string, error) {
+	command, err := s.Render(host)
+	if err != nil {
+		return nil, err
+	}
+	var args []string
+	if configFile != "" {
+		args = append(args, "-F", configFile)
+	}
+	if s.TTY {
+		args = append(args, "-t")
+	}
+	return append(args, host.Name, "--", command), nil
+}
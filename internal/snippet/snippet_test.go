@@ -0,0 +1,119 @@
+package snippet
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+func TestForHost(t *testing.T) {
+	snippets := []Snippet{
+		{Name: "disk", Command: "df -h"},
+		{Name: "logs", Command: "journalctl -n 100"},
+		{Name: "logs", Command: "tail -f /var/log/nginx/access.log", Tags: []string{"web"}},
+		{Name: "restart", Command: "sudo systemctl restart postgresql", Hosts: []string{"db-*"}},
+	}
+
+	tests := []struct {
+		host config.SSHHost
+		want []string
+	}{
+		{config.SSHHost{Name: "db-1"}, []string{"df -h", "journalctl -n 100", "sudo systemctl restart postgresql"}},
+		{config.SSHHost{Name: "front", Tags: []string{"Web"}}, []string{"df -h", "tail -f /var/log/nginx/access.log"}},
+		{config.SSHHost{Name: "other"}, []string{"df -h", "journalctl -n 100"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, s := range ForHost(snippets, tt.host) {
+			got = append(got, s.Command)
+		}
+		if strings.Join(got, ", ") != strings.Join(tt.want, ", ") {
+			t.Errorf("ForHost(%s) = %q, want %q", tt.host.Name, got, tt.want)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	s := Snippet{Name: "check", Command: "curl -s http://{{.Hostname}}:{{.Port}}/health -u {{.User}}"}
+
+	got, err := s.Render(config.SSHHost{Name: "web", Hostname: "web.example.com", User: "deploy"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "curl -s http://web.example.com:22/health -u deploy"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	// The hostname defaults to the host name
+	if data := NewData(config.SSHHost{Name: "web"}); data.Hostname != "web" || data.Port != "22" {
+		t.Errorf("NewData() = %+v, want the defaults", data)
+	}
+
+	if _, err := (Snippet{Name: "bad", Command: "echo {{.Missing}}"}).Render(config.SSHHost{Name: "web"}); err == nil {
+		t.Error("Expected an error for an unknown field")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "snippets.json")
+
+	snippets, err := loadFrom(path)
+	if err != nil || len(snippets) != 0 {
+		t.Fatalf("loadFrom() = %v, %v, want no snippets for a missing file", snippets, err)
+	}
+
+	data := `[
+  {"name": "uptime", "command": "uptime"},
+  {"name": "disk", "command": "df -h", "description": "Disk usage", "tags": ["prod"]},
+  {"name": "top", "command": "htop", "tty": true}
+]`
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	snippets, err = loadFrom(path)
+	if err != nil {
+		t.Fatalf("loadFrom() error = %v", err)
+	}
+	if len(snippets) != 3 || snippets[0].Name != "disk" || snippets[2].Name != "uptime" {
+		t.Fatalf("loadFrom() = %+v, want 3 snippets sorted by name", snippets)
+	}
+	if s, ok := Find(snippets, "top"); !ok || !s.TTY {
+		t.Errorf("Find(top) = %+v, %v", s, ok)
+	}
+
+	for _, invalid := range []string{
+		`[{"name": "x"}]`,
+		`[{"name": "a b", "command": "ls"}]`,
+		`[{"name": "x", "command": "echo {{.Hostname"}]`,
+		`[{"name": "x", "command": "ls", "hosts": ["[db"]}]`,
+		`{"name": "x"}`,
+	} {
+		if err := os.WriteFile(path, []byte(invalid), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadFrom(path); err == nil {
+			t.Errorf("loadFrom(%s) expected an error", invalid)
+		}
+	}
+}
+
+func TestSSHArgs(t *testing.T) {
+	host := config.SSHHost{Name: "web", Hostname: "web.example.com"}
+
+	args, err := Snippet{Name: "top", Command: "htop", TTY: true}.SSHArgs(host, "/tmp/config")
+	if err != nil {
+		t.Fatalf("SSHArgs() error = %v", err)
+	}
+	if got := strings.Join(args, " "); got != "-F /tmp/config -t web -- htop" {
+		t.Errorf("SSHArgs() = %q", got)
+	}
+
+	args, _ = Snippet{Name: "ping", Command: "ping -c 1 {{.Hostname}}"}.SSHArgs(host, "")
+	if got := strings.Join(args, " "); got != "web -- ping -c 1 web.example.com" {
+		t.Errorf("SSHArgs() = %q", got)
+	}
+}
@@ -0,0 +1,127 @@
+package tunnel
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// freePortSearch is how many ports after a taken one are tried for a free one
+const freePortSearch = 100
+
+// PortError reports that the local port of a forward cannot be listened on
+type PortError struct {
+	Forward   Forward
+	Err       error
+	Suggested string // a free port to listen on instead, if one was found
+}
+
+func (e *PortError) Error() string {
+	msg := fmt.Sprintf("port %s on %s is not available: %v", e.Forward.LocalPort, listenHost(e.Forward), e.Err)
+	if e.Suggested != "" {
+		msg += fmt.Sprintf(" (port %s is free)", e.Suggested)
+	}
+	return msg
+}
+
+func (e *PortError) Unwrap() error {
+	return e.Err
+}
+
+// ListensLocally reports whether the forward listens on this machine rather than on the host
+func (f Forward) ListensLocally() bool {
+	return f.Type == Local || f.Type == Dynamic
+}
+
+// CheckPort checks that the local port of a forward is free by listening on
+// it briefly. Forwards listening on the host cannot be checked from here.
+func CheckPort(f Forward) error {
+	if !f.ListensLocally() {
+		return nil
+	}
+	err := tryListen(listenHost(f), f.LocalPort)
+	if err == nil {
+		return nil
+	}
+
+	portErr := &PortError{Forward: f, Err: err}
+	// A port that needs privileges is not taken, another port would not help
+	if !errors.Is(err, os.ErrPermission) {
+		if port, err := strconv.Atoi(f.LocalPort); err == nil {
+			if next, err := NextFreePort(f.BindAddress, port+1); err == nil {
+				portErr.Suggested = next
+			}
+		}
+	}
+	return portErr
+}
+
+// NextFreePort returns the first port from from on that can be listened on at bindAddress
+func NextFreePort(bindAddress string, from int) (string, error) {
+	host := listenHost(Forward{BindAddress: bindAddress})
+	for port := from; port < from+freePortSearch && port <= 65535; port++ {
+		if tryListen(host, strconv.Itoa(port)) == nil {
+			return strconv.Itoa(port), nil
+		}
+	}
+	return "", fmt.Errorf("no free port found on %s from %d", host, from)
+}
+
+// tryListen listens on host and port, and closes the listener at once
+func tryListen(host, port string) error {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) {
+			// Keep the cause without the repeated address
+			return opErr.Err
+		}
+		return err
+	}
+	return listener.Close()
+}
+
+// listenHost returns the address ssh listens on for a forward: loopback without
+// a bind address or with "localhost", every interface for "*"
+func listenHost(f Forward) string {
+	switch f.BindAddress {
+	case "", "localhost":
+		return "127.0.0.1"
+	case "*":
+		return "0.0.0.0"
+	}
+	return f.BindAddress
+}
+
+// Warnings describes the risks of a forward: listening on a privileged port,
+// or on an address reachable from other machines
+func Warnings(f Forward) []string {
+	var warnings []string
+	where := "this machine"
+	if !f.ListensLocally() {
+		where = "the host"
+	}
+
+	if port, err := strconv.Atoi(f.LocalPort); err == nil && port < 1024 {
+		warnings = append(warnings, fmt.Sprintf("port %d is privileged, listening on it on %s usually requires root", port, where))
+	}
+	if !isLoopback(f.BindAddress) {
+		msg := fmt.Sprintf("bind address %s exposes port %s to other machines on the network of %s", f.BindAddress, f.LocalPort, where)
+		if !f.ListensLocally() {
+			msg += " (if the server allows it with GatewayPorts)"
+		}
+		warnings = append(warnings, msg)
+	}
+	return warnings
+}
+
+// isLoopback reports whether a bind address only accepts connections from the same machine
+func isLoopback(bindAddress string) bool {
+	if bindAddress == "" || bindAddress == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(bindAddress)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,213 @@
+package tunnel
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Forward types, as stored in the port forwarding history
+const (
+	Local   = "local"
+	Remote  = "remote"
+	Dynamic = "dynamic"
+)
+
+// Forward is a port forward of an ssh connection. LocalPort is the port that
+// listens: on this machine for local and dynamic forwards, on the host for
+// remote forwards. RemoteHost and RemotePort are where connections go.
+type Forward struct {
+	Type        string `json:"type"`
+	LocalPort   string `json:"local_port"`
+	RemoteHost  string `json:"remote_host,omitempty"`
+	RemotePort  string `json:"remote_port,omitempty"`
+	BindAddress string `json:"bind_address,omitempty"`
+}
+
+// ParseForward parses a forward written as for ssh: [bind_address:]port:host:hostport
+// for -L and -R, [bind_address:]port for -D
+func ParseForward(forwardType, spec string) (Forward, error) {
+	parts := splitSpec(spec)
+	f := Forward{Type: forwardType}
+
+	switch forwardType {
+	case Local, Remote:
+		switch len(parts) {
+		case 3:
+			f.LocalPort, f.RemoteHost, f.RemotePort = parts[0], parts[1], parts[2]
+		case 4:
+			f.BindAddress, f.LocalPort, f.RemoteHost, f.RemotePort = parts[0], parts[1], parts[2], parts[3]
+		default:
+			return Forward{}, fmt.Errorf("invalid forward '%s', expected [bind_address:]port:host:hostport", spec)
+		}
+	case Dynamic:
+		switch len(parts) {
+		case 1:
+			f.LocalPort = parts[0]
+		case 2:
+			f.BindAddress, f.LocalPort = parts[0], parts[1]
+		default:
+			return Forward{}, fmt.Errorf("invalid forward '%s', expected [bind_address:]port", spec)
+		}
+	default:
+		return Forward{}, fmt.Errorf("unknown forward type '%s'", forwardType)
+	}
+
+	return f, f.Validate()
+}
+
+// ParseDirective parses a forward written as an ssh_config directive, such as
+// "LocalForward 8080 localhost:80" or "DynamicForward 1080"
+func ParseDirective(directive string) (Forward, error) {
+	fields := strings.Fields(directive)
+	if len(fields) == 0 {
+		return Forward{}, fmt.Errorf("forward directive is empty")
+	}
+
+	var forwardType string
+	switch strings.ToLower(fields[0]) {
+	case "localforward":
+		forwardType = Local
+	case "remoteforward":
+		forwardType = Remote
+	case "dynamicforward":
+		forwardType = Dynamic
+	default:
+		return Forward{}, fmt.Errorf("unknown forward directive '%s', expected LocalForward, RemoteForward or DynamicForward", fields[0])
+	}
+
+	args := fields[1:]
+	switch {
+	case forwardType == Dynamic && len(args) == 1:
+		return ParseForward(forwardType, args[0])
+	case forwardType != Dynamic && len(args) == 2:
+		return ParseForward(forwardType, args[0]+":"+args[1])
+	}
+	return Forward{}, fmt.Errorf("invalid forward directive '%s'", directive)
+}
+
+// splitSpec splits a forward spec on colons, keeping bracketed IPv6 addresses whole
+func splitSpec(spec string) []string {
+	var parts []string
+	var current strings.Builder
+	inBrackets := false
+	for _, r := range spec {
+		switch {
+		case r == '[':
+			inBrackets = true
+		case r == ']':
+			inBrackets = false
+		case r == ':' && !inBrackets:
+			parts = append(parts, current.String())
+			current.Reset()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	return append(parts, current.String())
+}
+
+// Validate checks that the ports of a forward are valid
+func (f Forward) Validate() error {
+	if err := validatePort(f.LocalPort); err != nil {
+		return err
+	}
+	if f.Type == Dynamic {
+		return nil
+	}
+	if f.RemoteHost == "" {
+		return fmt.Errorf("destination host is required for %s forwarding", f.Type)
+	}
+	return validatePort(f.RemotePort)
+}
+
+// validatePort checks a port number
+func validatePort(port string) error {
+	if port == "" {
+		return fmt.Errorf("port is required")
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return fmt.Errorf("invalid port number '%s'", port)
+	}
+	if n < 1 || n > 65535 {
+		return fmt.Errorf("port %d is out of range (1-65535)", n)
+	}
+	return nil
+}
+
+// Flag returns the ssh option of the forward type
+func (f Forward) Flag() string {
+	switch f.Type {
+	case Remote:
+		return "-R"
+	case Dynamic:
+		return "-D"
+	default:
+		return "-L"
+	}
+}
+
+// Spec returns the forward in ssh syntax
+func (f Forward) Spec() string {
+	var parts []string
+	if f.BindAddress != "" {
+		bind := f.BindAddress
+		if strings.Contains(bind, ":") {
+			bind = "[" + bind + "]"
+		}
+		parts = append(parts, bind)
+	}
+	parts = append(parts, f.LocalPort)
+	if f.Type != Dynamic {
+		parts = append(parts, f.RemoteHost, f.RemotePort)
+	}
+	return strings.Join(parts, ":")
+}
+
+// Directive returns the forward as an ssh_config directive
+func (f Forward) Directive() string {
+	switch f.Type {
+	case Dynamic:
+		return "DynamicForward " + f.Spec()
+	case Remote:
+		return "RemoteForward " + f.listenSpec() + " " + f.targetSpec()
+	default:
+		return "LocalForward " + f.listenSpec() + " " + f.targetSpec()
+	}
+}
+
+// listenSpec returns the [bind_address:]port part of the forward
+func (f Forward) listenSpec() string {
+	return Forward{Type: Dynamic, LocalPort: f.LocalPort, BindAddress: f.BindAddress}.Spec()
+}
+
+// targetSpec returns the host:hostport part of the forward
+func (f Forward) targetSpec() string {
+	host := f.RemoteHost
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return host + ":" + f.RemotePort
+}
+
+// Args returns the ssh arguments for the forward
+func (f Forward) Args() []string {
+	return []string{f.Flag(), f.Spec()}
+}
+
+// String describes the forward for display
+func (f Forward) String() string {
+	listen := f.LocalPort
+	if f.BindAddress != "" {
+		listen = f.BindAddress + ":" + f.LocalPort
+	}
+	switch f.Type {
+	case Remote:
+		return fmt.Sprintf("R %s → %s:%s", listen, f.RemoteHost, f.RemotePort)
+	case Dynamic:
+		return fmt.Sprintf("D %s (SOCKS)", listen)
+	default:
+		return fmt.Sprintf("L %s → %s:%s", listen, f.RemoteHost, f.RemotePort)
+	}
+}
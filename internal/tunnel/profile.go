@@ -0,0 +1,175 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+// Profile is a named set of forwards to a host, launched together
+type Profile struct {
+	Name     string    `json:"name"`
+	Forwards []Forward `json:"forwards"`
+}
+
+// Validate checks the name and the forwards of a profile
+func (p Profile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if strings.ContainsAny(p.Name, " \t/\\") {
+		return fmt.Errorf("profile name '%s' cannot contain spaces or slashes", p.Name)
+	}
+	if len(p.Forwards) == 0 {
+		return fmt.Errorf("profile '%s' has no forwards", p.Name)
+	}
+	for _, f := range p.Forwards {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ports describes the forwards of the profile for display
+func (p Profile) Ports() string {
+	return Tunnel{Forwards: p.Forwards}.Ports()
+}
+
+// FindProfile returns the profile called name
+func FindProfile(profiles []Profile, name string) (Profile, bool) {
+	for _, p := range profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return Profile{}, false
+}
+
+// profilesPath returns the file where forward profiles are kept
+func profilesPath() (string, error) {
+	configDir, err := config.GetSSHMConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "forward_profiles.json"), nil
+}
+
+// LoadProfiles returns the forward profiles of host, sorted by name
+func LoadProfiles(host string) ([]Profile, error) {
+	path, err := profilesPath()
+	if err != nil {
+		return nil, err
+	}
+	profiles, err := loadProfilesFrom(path)
+	if err != nil {
+		return nil, err
+	}
+	return profiles[host], nil
+}
+
+// loadProfilesFrom reads the profiles of all hosts from path, a missing file holds none
+func loadProfilesFrom(path string) (map[string][]Profile, error) {
+	profiles := make(map[string][]Profile)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return profiles, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// SaveProfile stores a profile of host, replacing the profile of the same name
+func SaveProfile(host string, profile Profile) error {
+	path, err := profilesPath()
+	if err != nil {
+		return err
+	}
+	return saveProfileTo(path, host, profile)
+}
+
+// saveProfileTo stores a profile of host in the file at path
+func saveProfileTo(path, host string, profile Profile) error {
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+	profiles, err := loadProfilesFrom(path)
+	if err != nil {
+		return err
+	}
+
+	hostProfiles := profiles[host]
+	replaced := false
+	for i, p := range hostProfiles {
+		if p.Name == profile.Name {
+			hostProfiles[i] = profile
+			replaced = true
+		}
+	}
+	if !replaced {
+		hostProfiles = append(hostProfiles, profile)
+	}
+	sort.Slice(hostProfiles, func(i, j int) bool {
+		return hostProfiles[i].Name < hostProfiles[j].Name
+	})
+	profiles[host] = hostProfiles
+
+	return writeProfiles(path, profiles)
+}
+
+// DeleteProfile removes the profile called name of host
+func DeleteProfile(host, name string) error {
+	path, err := profilesPath()
+	if err != nil {
+		return err
+	}
+	return deleteProfileFrom(path, host, name)
+}
+
+// deleteProfileFrom removes a profile of host from the file at path
+func deleteProfileFrom(path, host, name string) error {
+	profiles, err := loadProfilesFrom(path)
+	if err != nil {
+		return err
+	}
+
+	var kept []Profile
+	for _, p := range profiles[host] {
+		if p.Name != name {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == len(profiles[host]) {
+		return fmt.Errorf("profile '%s' not found for host '%s'", name, host)
+	}
+	if len(kept) == 0 {
+		delete(profiles, host)
+	} else {
+		profiles[host] = kept
+	}
+
+	return writeProfiles(path, profiles)
+}
+
+// writeProfiles writes the profiles of all hosts to path
+func writeProfiles(path string, profiles map[string][]Profile) error {
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,196 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// connectedAfter is how long ssh must stay up for the tunnel to count as connected
+	connectedAfter = 3 * time.Second
+	// restartDelay is the first delay before restarting ssh, doubled on each failure
+	restartDelay = time.Second
+	// maxRestartDelay bounds the delay before restarting ssh
+	maxRestartDelay = time.Minute
+	// stableAfter is how long a connection must last to reset the restart delay
+	stableAfter = time.Minute
+	// lockTimeout is how long the supervisor waits for its lock, which checks
+	// of the tunnel take for a moment
+	lockTimeout = 2 * time.Second
+)
+
+// newSSHCommand creates the ssh process of a tunnel; tests replace it
+var newSSHCommand = func(ctx context.Context, args []string) *exec.Cmd {
+	return exec.CommandContext(ctx, "ssh", args...)
+}
+
+// Supervise keeps the ssh process of tunnel id running until ctx is cancelled,
+// restarting it with a growing delay whenever it exits. Progress and the
+// output of ssh go to logOut. The supervisor holds the lock of the tunnel
+// while it runs, telling others it is alive.
+func Supervise(ctx context.Context, dir, id string, logOut io.Writer) error {
+	lock, err := Lock(dir, id)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	t, err := Load(dir, id)
+	if err != nil {
+		return err
+	}
+	t.PID = os.Getpid()
+
+	logf := func(format string, args ...any) {
+		fmt.Fprintf(logOut, "%s %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+	}
+	logf("supervising %s: %s", t.Host, t.Ports())
+
+	delay := restartDelay
+	for {
+		t.State = StateConnecting
+		t.SSHPID = 0
+		if err := Save(dir, t); err != nil {
+			return err
+		}
+
+		stderr := &lastLineWriter{}
+		cmd := newSSHCommand(ctx, t.SSHArgs())
+		cmd.Stdout = logOut
+		cmd.Stderr = io.MultiWriter(logOut, stderr)
+		started := time.Now()
+
+		if err := cmd.Start(); err != nil {
+			t.LastError = err.Error()
+			logf("could not start ssh: %v", err)
+		} else {
+			t.SSHPID = cmd.Process.Pid
+			logf("ssh started (pid %d)", t.SSHPID)
+			_ = Save(dir, t)
+
+			done := make(chan error, 1)
+			go func() {
+				done <- cmd.Wait()
+			}()
+
+			var waitErr error
+			select {
+			case waitErr = <-done:
+			case <-time.After(connectedAfter):
+				t.State = StateConnected
+				t.ConnectedAt = time.Now()
+				_ = Save(dir, t)
+				logf("connected")
+				waitErr = <-done
+			}
+
+			if ctx.Err() != nil {
+				break
+			}
+			t.LastError = exitReason(waitErr, stderr.Last())
+			logf("ssh exited: %s", t.LastError)
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+
+		// A connection that lasted is a disconnect rather than a failure to connect
+		if time.Since(started) >= stableAfter {
+			delay = restartDelay
+		}
+		t.State = StateReconnecting
+		t.SSHPID = 0
+		t.ConnectedAt = time.Time{}
+		t.Restarts++
+		_ = Save(dir, t)
+		logf("restarting in %s", delay)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		delay = min(delay*2, maxRestartDelay)
+	}
+
+	logf("stopped")
+	t.State = StateStopped
+	t.SSHPID = 0
+	t.ConnectedAt = time.Time{}
+	return Save(dir, t)
+}
+
+// Lock takes the lock the supervisor of tunnel id holds while it runs, until
+// it is closed. Checks of the tunnel take the lock for a moment, so they are
+// waited for; it fails when another supervisor holds it.
+func Lock(dir, id string) (io.Closer, error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		lock, err := lockFile(lockPath(dir, id))
+		switch {
+		case err == nil:
+			return lock, nil
+		case !errors.Is(err, errLocked):
+			return nil, err
+		case time.Now().After(deadline):
+			return nil, fmt.Errorf("tunnel '%s' is already supervised", id)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// exitReason describes why ssh exited, preferring its last error message
+func exitReason(err error, lastLine string) string {
+	if lastLine != "" {
+		return lastLine
+	}
+	if err != nil {
+		return err.Error()
+	}
+	return "connection closed"
+}
+
+// lastLineWriter keeps the last non-empty line written to it
+type lastLineWriter struct {
+	mu      sync.Mutex
+	partial []byte
+	last    string
+}
+
+func (w *lastLineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(w.partial[:i])); line != "" {
+			w.last = line
+		}
+		w.partial = w.partial[i+1:]
+	}
+	return len(p), nil
+}
+
+// Last returns the last line written, including an unterminated one
+func (w *lastLineWriter) Last() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if line := strings.TrimSpace(string(w.partial)); line != "" {
+		return line
+	}
+	return w.last
+}
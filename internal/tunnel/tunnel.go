@@ -0,0 +1,391 @@
+package tunnel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+// State is the state of a tunnel
+type State string
+
+const (
+	// StateStarting is set until the supervisor process runs
+	StateStarting State = "starting"
+	// StateConnecting is set while ssh connects and sets up the forwards
+	StateConnecting State = "connecting"
+	// StateConnected is set once ssh has been up for a moment
+	StateConnected State = "connected"
+	// StateReconnecting is set while waiting to restart ssh after it exited
+	StateReconnecting State = "reconnecting"
+	// StateStopped is set when the supervisor stops
+	StateStopped State = "stopped"
+	// StateExited is reported for a tunnel whose supervisor is gone without stopping it
+	StateExited State = "exited"
+)
+
+// startTimeout is how long a tunnel may stay starting before it is considered exited
+const startTimeout = 30 * time.Second
+
+// Tunnel is a set of forwards to a host kept up by a background supervisor process
+type Tunnel struct {
+	ID          string    `json:"id"`
+	Host        string    `json:"host"`
+	ConfigFile  string    `json:"config_file,omitempty"`
+	Forwards    []Forward `json:"forwards"`
+	PID         int       `json:"pid,omitempty"`
+	SSHPID      int       `json:"ssh_pid,omitempty"`
+	State       State     `json:"state"`
+	StartedAt   time.Time `json:"started_at"`
+	ConnectedAt time.Time `json:"connected_at,omitempty"`
+	Restarts    int       `json:"restarts"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// Active reports whether the tunnel is meant to be up
+func (t Tunnel) Active() bool {
+	switch t.State {
+	case StateStarting, StateConnecting, StateConnected, StateReconnecting:
+		return true
+	}
+	return false
+}
+
+// Uptime returns how long the current connection has been up
+func (t Tunnel) Uptime(now time.Time) time.Duration {
+	if t.State != StateConnected || t.ConnectedAt.IsZero() {
+		return 0
+	}
+	return now.Sub(t.ConnectedAt)
+}
+
+// FormatUptime formats an uptime compactly, "-" when the tunnel is not up
+func FormatUptime(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "-"
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%02dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
+
+// Ports describes the forwards of the tunnel for display
+func (t Tunnel) Ports() string {
+	parts := make([]string, len(t.Forwards))
+	for i, f := range t.Forwards {
+		parts[i] = f.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SSHArgs returns the arguments of the ssh process holding the forwards
+func (t Tunnel) SSHArgs() []string {
+	args := []string{
+		"-N",
+		"-o", "ExitOnForwardFailure=yes",
+		"-o", "ServerAliveInterval=15",
+		"-o", "ServerAliveCountMax=3",
+		"-o", "BatchMode=yes",
+	}
+	if t.ConfigFile != "" {
+		args = append(args, "-F", t.ConfigFile)
+	}
+	for _, f := range t.Forwards {
+		args = append(args, f.Args()...)
+	}
+	return append(args, t.Host)
+}
+
+// Dir returns the directory where tunnel states and logs are kept, creating it if needed
+func Dir() (string, error) {
+	configDir, err := config.GetSSHMConfigDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(configDir, "tunnels")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// statePath returns the file holding the state of tunnel id
+func statePath(dir, id string) string {
+	return filepath.Join(dir, id+".json")
+}
+
+// lockPath returns the file the supervisor of tunnel id keeps locked while it runs
+func lockPath(dir, id string) string {
+	return filepath.Join(dir, id+".lock")
+}
+
+// errLocked is returned by lockFile when another process holds the lock
+var errLocked = errors.New("locked by another process")
+
+// supervised reports whether the supervisor of tunnel id runs. Unlike its PID,
+// which may be reused by another process once it exits, its lock is released
+// by the system as soon as it is gone.
+func supervised(dir, id string) bool {
+	lock, err := lockFile(lockPath(dir, id))
+	if err != nil {
+		return errors.Is(err, errLocked)
+	}
+	lock.Close()
+	return false
+}
+
+// LogPath returns the log file of tunnel id
+func LogPath(dir, id string) string {
+	return filepath.Join(dir, id+".log")
+}
+
+// NewID returns an identifier for a tunnel to host, made of the host name and
+// the first forwarded port
+func NewID(host string, forwards []Forward) string {
+	id := sanitize(host)
+	if len(forwards) > 0 {
+		id += "-" + forwards[0].LocalPort
+	}
+	return id
+}
+
+// sanitize makes a name safe for a file name
+func sanitize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// Load reads the state of tunnel id and checks that its supervisor still runs
+func Load(dir, id string) (*Tunnel, error) {
+	data, err := os.ReadFile(statePath(dir, id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("tunnel '%s' not found", id)
+		}
+		return nil, err
+	}
+	var t Tunnel
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("reading tunnel '%s': %w", id, err)
+	}
+	refresh(dir, &t, time.Now())
+	return &t, nil
+}
+
+// refresh marks a tunnel as exited when its supervisor is gone
+func refresh(dir string, t *Tunnel, now time.Time) {
+	if !t.Active() {
+		return
+	}
+	if t.PID == 0 {
+		if now.Sub(t.StartedAt) > startTimeout {
+			t.State = StateExited
+		}
+		return
+	}
+	if !supervised(dir, t.ID) {
+		t.State = StateExited
+	}
+}
+
+// List returns the tunnels in dir, sorted by host and ID
+func List(dir string) ([]Tunnel, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var tunnels []Tunnel
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		t, err := Load(dir, strings.TrimSuffix(name, ".json"))
+		if err != nil {
+			continue
+		}
+		tunnels = append(tunnels, *t)
+	}
+
+	sort.Slice(tunnels, func(i, j int) bool {
+		if tunnels[i].Host != tunnels[j].Host {
+			return tunnels[i].Host < tunnels[j].Host
+		}
+		return tunnels[i].ID < tunnels[j].ID
+	})
+	return tunnels, nil
+}
+
+// Save writes the state of a tunnel. The file is replaced at once so that
+// readers never see a partial state.
+func Save(dir string, t *Tunnel) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, t.ID+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), statePath(dir, t.ID)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// Start launches a supervisor process for t, running executable with
+// "tunnel run <id>". The ID defaults to NewID. The supervisor writes its
+// output to the log of the tunnel and updates its state from then on.
+func Start(dir string, t *Tunnel, executable string) error {
+	if t.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+	if len(t.Forwards) == 0 {
+		return fmt.Errorf("at least one forward is required")
+	}
+	for _, f := range t.Forwards {
+		if err := f.Validate(); err != nil {
+			return err
+		}
+	}
+	if t.ID == "" {
+		t.ID = NewID(t.Host, t.Forwards)
+	} else {
+		t.ID = sanitize(t.ID)
+	}
+
+	if existing, err := Load(dir, t.ID); err == nil && existing.Active() {
+		return fmt.Errorf("tunnel '%s' is already running", t.ID)
+	}
+
+	t.PID, t.SSHPID = 0, 0
+	t.State = StateStarting
+	t.StartedAt = time.Now()
+	t.ConnectedAt = time.Time{}
+	t.Restarts = 0
+	t.LastError = ""
+	if err := Save(dir, t); err != nil {
+		return err
+	}
+
+	logFile, err := os.OpenFile(LogPath(dir, t.ID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(executable, "tunnel", "run", t.ID)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		os.Remove(statePath(dir, t.ID))
+		return fmt.Errorf("starting the tunnel supervisor: %w", err)
+	}
+	// The supervisor records its own PID, as it owns the state from here on.
+	// Waiting reaps it if it exits while this process still runs.
+	t.PID = cmd.Process.Pid
+	go func() {
+		_ = cmd.Wait()
+	}()
+	return nil
+}
+
+// WaitStarted waits until tunnel id is past connecting or timeout elapses,
+// and returns its state
+func WaitStarted(dir, id string, timeout time.Duration) (*Tunnel, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		t, err := Load(dir, id)
+		if err != nil {
+			return nil, err
+		}
+		if (t.State != StateStarting && t.State != StateConnecting) || time.Now().After(deadline) {
+			return t, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// Stop stops the supervisor of tunnel id and its ssh process, and forgets the
+// tunnel. Its log is kept.
+func Stop(dir, id string) error {
+	t, err := Load(dir, id)
+	if err != nil {
+		return err
+	}
+
+	// The PID is only signalled while the lock shows it is still the supervisor's
+	killed := false
+	if t.PID != 0 && supervised(dir, id) {
+		if err := terminate(t.PID); err != nil {
+			return fmt.Errorf("stopping tunnel '%s': %w", id, err)
+		}
+		if !waitUnsupervised(dir, id, 5*time.Second) {
+			_ = kill(t.PID)
+			waitUnsupervised(dir, id, time.Second)
+			killed = true
+		}
+	}
+
+	// The supervisor ends ssh when it stops, unless it was killed. Read the
+	// state again since ssh may have been restarted in the meantime.
+	if latest, err := Load(dir, id); err == nil {
+		t = latest
+	}
+	if killed && t.SSHPID != 0 && processAlive(t.SSHPID) {
+		_ = kill(t.SSHPID)
+	}
+
+	for _, path := range []string{statePath(dir, id), lockPath(dir, id)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// waitUnsupervised waits for the supervisor of tunnel id to exit and reports whether it did
+func waitUnsupervised(dir, id string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for supervised(dir, id) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return true
+}
@@ -0,0 +1,405 @@
+package tunnel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseForward(t *testing.T) {
+	tests := []struct {
+		forwardType string
+		spec        string
+		want        Forward
+		wantErr     bool
+	}{
+		{Local, "8080:localhost:80", Forward{Type: Local, LocalPort: "8080", RemoteHost: "localhost", RemotePort: "80"}, false},
+		{Remote, "0.0.0.0:9000:127.0.0.1:3000", Forward{Type: Remote, LocalPort: "9000", RemoteHost: "127.0.0.1", RemotePort: "3000", BindAddress: "0.0.0.0"}, false},
+		{Local, "[::1]:5432:[fd00::5]:5432", Forward{Type: Local, LocalPort: "5432", RemoteHost: "fd00::5", RemotePort: "5432", BindAddress: "::1"}, false},
+		{Dynamic, "1080", Forward{Type: Dynamic, LocalPort: "1080"}, false},
+		{Dynamic, "127.0.0.1:1080", Forward{Type: Dynamic, LocalPort: "1080", BindAddress: "127.0.0.1"}, false},
+		{Local, "8080", Forward{}, true},
+		{Local, "70000:localhost:80", Forward{}, true},
+		{Dynamic, "socks", Forward{}, true},
+		{"sideways", "1080", Forward{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseForward(tt.forwardType, tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseForward(%q, %q) error = %v, wantErr %v", tt.forwardType, tt.spec, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseForward(%q, %q) = %+v, want %+v", tt.forwardType, tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestForwardArgs(t *testing.T) {
+	f := Forward{Type: Local, LocalPort: "5432", RemoteHost: "fd00::5", RemotePort: "5432", BindAddress: "::1"}
+	if got := strings.Join(f.Args(), " "); got != "-L [::1]:5432:fd00::5:5432" {
+		t.Errorf("Args() = %q", got)
+	}
+	d := Forward{Type: Dynamic, LocalPort: "1080"}
+	if got := strings.Join(d.Args(), " "); got != "-D 1080" {
+		t.Errorf("Args() = %q", got)
+	}
+}
+
+func TestDirective(t *testing.T) {
+	tests := []struct {
+		forward   Forward
+		directive string
+	}{
+		{Forward{Type: Local, LocalPort: "8080", RemoteHost: "localhost", RemotePort: "80"}, "LocalForward 8080 localhost:80"},
+		{Forward{Type: Remote, LocalPort: "9000", RemoteHost: "127.0.0.1", RemotePort: "3000", BindAddress: "0.0.0.0"}, "RemoteForward 0.0.0.0:9000 127.0.0.1:3000"},
+		{Forward{Type: Local, LocalPort: "5432", RemoteHost: "fd00::5", RemotePort: "5432", BindAddress: "::1"}, "LocalForward [::1]:5432 [fd00::5]:5432"},
+		{Forward{Type: Dynamic, LocalPort: "1080"}, "DynamicForward 1080"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.forward.Directive(); got != tt.directive {
+			t.Errorf("Directive() = %q, want %q", got, tt.directive)
+		}
+		got, err := ParseDirective(tt.directive)
+		if err != nil {
+			t.Errorf("ParseDirective(%q) error = %v", tt.directive, err)
+		} else if got != tt.forward {
+			t.Errorf("ParseDirective(%q) = %+v, want %+v", tt.directive, got, tt.forward)
+		}
+	}
+
+	for _, invalid := range []string{"", "LocalForward 8080", "RemoteForward 9000", "DynamicForward 1080 extra", "ForwardAgent yes"} {
+		if _, err := ParseDirective(invalid); err == nil {
+			t.Errorf("ParseDirective(%q) expected an error", invalid)
+		}
+	}
+}
+
+func TestSSHArgs(t *testing.T) {
+	tunnel := Tunnel{
+		Host:       "db",
+		ConfigFile: "/tmp/config",
+		Forwards: []Forward{
+			{Type: Local, LocalPort: "5432", RemoteHost: "localhost", RemotePort: "5432"},
+			{Type: Dynamic, LocalPort: "1080"},
+		},
+	}
+	got := strings.Join(tunnel.SSHArgs(), " ")
+	for _, want := range []string{"-N ", "ExitOnForwardFailure=yes", "-F /tmp/config", "-L 5432:localhost:5432", "-D 1080"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in the ssh arguments, got %q", want, got)
+		}
+	}
+	if !strings.HasSuffix(got, " db") {
+		t.Errorf("Expected the host to come last, got %q", got)
+	}
+	if id := NewID("prod/db", tunnel.Forwards); id != "prod_db-5432" {
+		t.Errorf("NewID() = %q", id)
+	}
+}
+
+func TestSaveLoadAndList(t *testing.T) {
+	dir := t.TempDir()
+
+	running := &Tunnel{ID: "web-8080", Host: "web", PID: os.Getpid(), State: StateConnected,
+		Forwards: []Forward{{Type: Local, LocalPort: "8080", RemoteHost: "localhost", RemotePort: "80"}}}
+	lock, err := Lock(dir, running.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Close()
+
+	// The PID of the supervisor that is gone now belongs to another process
+	gone := &Tunnel{ID: "db-5432", Host: "db", PID: os.Getppid(), State: StateConnected, StartedAt: time.Now()}
+	for _, tunnel := range []*Tunnel{running, gone} {
+		if err := Save(dir, tunnel); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	tunnels, err := List(dir)
+	if err != nil || len(tunnels) != 2 {
+		t.Fatalf("Expected 2 tunnels, got %d (%v)", len(tunnels), err)
+	}
+	if tunnels[0].ID != "db-5432" || tunnels[0].State != StateExited {
+		t.Errorf("Expected the tunnel without supervisor to be exited, got %+v", tunnels[0])
+	}
+	if tunnels[1].ID != "web-8080" || tunnels[1].State != StateConnected {
+		t.Errorf("Expected the supervised tunnel to stay connected, got %+v", tunnels[1])
+	}
+
+	if _, err := Load(dir, "missing"); err == nil {
+		t.Error("Expected an error for an unknown tunnel")
+	}
+}
+
+func TestSupervise(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake ssh needs a Unix shell")
+	}
+	dir := t.TempDir()
+
+	connectedAfter = 100 * time.Millisecond
+	restartDelay = 10 * time.Millisecond
+	defer func() {
+		connectedAfter, restartDelay = 3*time.Second, time.Second
+		newSSHCommand = func(ctx context.Context, args []string) *exec.Cmd {
+			return exec.CommandContext(ctx, "ssh", args...)
+		}
+	}()
+
+	// The first ssh fails to forward, the next one stays up
+	var runs atomic.Int32
+	newSSHCommand = func(ctx context.Context, args []string) *exec.Cmd {
+		if runs.Add(1) == 1 {
+			return exec.CommandContext(ctx, "sh", "-c", "echo 'Error: remote port forwarding failed' >&2; exit 255")
+		}
+		return exec.CommandContext(ctx, "sleep", "30")
+	}
+
+	tunnel := &Tunnel{ID: "web-8080", Host: "web", State: StateStarting, StartedAt: time.Now(),
+		Forwards: []Forward{{Type: Local, LocalPort: "8080", RemoteHost: "localhost", RemotePort: "80"}}}
+	if err := Save(dir, tunnel); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var logOut bytes.Buffer
+	done := make(chan error, 1)
+	go func() {
+		done <- Supervise(ctx, dir, tunnel.ID, &syncWriter{buf: &logOut})
+	}()
+
+	state, err := WaitStarted(dir, tunnel.ID, 5*time.Second)
+	for err == nil && state.State != StateConnected {
+		time.Sleep(20 * time.Millisecond)
+		state, err = Load(dir, tunnel.ID)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Restarts != 1 || state.LastError != "Error: remote port forwarding failed" {
+		t.Errorf("Expected one restart after the forwarding error, got %d restarts (%q)", state.Restarts, state.LastError)
+	}
+	if state.PID != os.Getpid() || state.SSHPID == 0 || state.Uptime(time.Now()) <= 0 {
+		t.Errorf("Expected the supervisor and ssh PIDs and an uptime, got %+v", state)
+	}
+
+	// A second supervisor does not take over the tunnel
+	lockTimeout = 50 * time.Millisecond
+	defer func() { lockTimeout = 2 * time.Second }()
+	if err := Supervise(ctx, dir, tunnel.ID, io.Discard); err == nil || !strings.Contains(err.Error(), "already supervised") {
+		t.Errorf("Expected a second supervisor to fail, got %v", err)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Supervise() error = %v", err)
+	}
+	state, _ = Load(dir, tunnel.ID)
+	if state.State != StateStopped {
+		t.Errorf("Expected the tunnel to be stopped, got %s", state.State)
+	}
+}
+
+// TestHelperSupervisor holds the lock of a tunnel as its supervisor would,
+// when run as a separate process by TestStop
+func TestHelperSupervisor(t *testing.T) {
+	dir, id := os.Getenv("SSHM_TEST_TUNNEL_DIR"), os.Getenv("SSHM_TEST_TUNNEL_ID")
+	if dir == "" {
+		t.Skip("only run as the fake supervisor of TestStop")
+	}
+	lock, err := Lock(dir, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Close()
+	time.Sleep(30 * time.Second)
+}
+
+func TestStop(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake supervisor must exit on SIGTERM")
+	}
+	dir := t.TempDir()
+	tunnel := &Tunnel{ID: "web-8080", Host: "web", State: StateConnected}
+
+	supervisor := exec.Command(os.Args[0], "-test.run=^TestHelperSupervisor$")
+	supervisor.Env = append(os.Environ(), "SSHM_TEST_TUNNEL_DIR="+dir, "SSHM_TEST_TUNNEL_ID="+tunnel.ID)
+	if err := supervisor.Start(); err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan struct{})
+	go func() {
+		_ = supervisor.Wait()
+		close(exited)
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !supervised(dir, tunnel.ID) {
+		if time.Now().After(deadline) {
+			t.Fatal("The fake supervisor did not take the lock")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	tunnel.PID = supervisor.Process.Pid
+	if err := Save(dir, tunnel); err != nil {
+		t.Fatal(err)
+	}
+	if err := Stop(dir, tunnel.ID); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Error("Expected the supervisor to be stopped")
+	}
+	if _, err := Load(dir, tunnel.ID); err == nil {
+		t.Error("Expected a stopped tunnel to be forgotten")
+	}
+}
+
+// syncWriter serializes writes from the supervisor and ssh output
+type syncWriter struct {
+	mu  sync.Mutex
+	buf *bytes.Buffer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func TestFormatUptime(t *testing.T) {
+	tests := map[time.Duration]string{
+		0:                             "-",
+		42 * time.Second:              "42s",
+		3*time.Minute + 5*time.Second: "3m05s",
+		2*time.Hour + 7*time.Minute:   "2h07m",
+		50*time.Hour + 30*time.Minute: "2d02h",
+	}
+	for d, want := range tests {
+		if got := FormatUptime(d); got != want {
+			t.Errorf("FormatUptime(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forward_profiles.json")
+
+	db := Profile{Name: "db", Forwards: []Forward{
+		{Type: Local, LocalPort: "5432", RemoteHost: "localhost", RemotePort: "5432"},
+		{Type: Dynamic, LocalPort: "1080"},
+	}}
+	grafana := Profile{Name: "grafana", Forwards: []Forward{{Type: Local, LocalPort: "3000", RemoteHost: "grafana.internal", RemotePort: "3000"}}}
+	for _, p := range []Profile{grafana, db} {
+		if err := saveProfileTo(path, "prod", p); err != nil {
+			t.Fatalf("saveProfileTo() error = %v", err)
+		}
+	}
+
+	profiles, err := loadProfilesFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := profiles["prod"]; len(got) != 2 || got[0].Name != "db" || len(got[0].Forwards) != 2 {
+		t.Fatalf("Expected the db and grafana profiles sorted by name, got %+v", got)
+	}
+
+	// Saving under the same name replaces the profile
+	db.Forwards = db.Forwards[:1]
+	if err := saveProfileTo(path, "prod", db); err != nil {
+		t.Fatal(err)
+	}
+	profiles, _ = loadProfilesFrom(path)
+	if p, ok := FindProfile(profiles["prod"], "db"); !ok || len(p.Forwards) != 1 {
+		t.Errorf("Expected the db profile to be replaced, got %+v", p)
+	}
+
+	if err := saveProfileTo(path, "prod", Profile{Name: "empty"}); err == nil {
+		t.Error("Expected an error for a profile without forwards")
+	}
+	if err := saveProfileTo(path, "prod", Profile{Name: "my db", Forwards: db.Forwards}); err == nil {
+		t.Error("Expected an error for a profile name with a space")
+	}
+
+	if err := deleteProfileFrom(path, "prod", "grafana"); err != nil {
+		t.Fatalf("deleteProfileFrom() error = %v", err)
+	}
+	if err := deleteProfileFrom(path, "prod", "grafana"); err == nil {
+		t.Error("Expected an error when deleting a missing profile")
+	}
+	profiles, _ = loadProfilesFrom(path)
+	if len(profiles["prod"]) != 1 {
+		t.Errorf("Expected one profile left, got %+v", profiles["prod"])
+	}
+}
+
+func TestCheckPort(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	taken := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	err = CheckPort(Forward{Type: Local, LocalPort: taken, RemoteHost: "localhost", RemotePort: "80"})
+	var portErr *PortError
+	if !errors.As(err, &portErr) {
+		t.Fatalf("Expected a port error for a taken port, got %v", err)
+	}
+	if portErr.Suggested == "" || portErr.Suggested == taken {
+		t.Errorf("Expected another free port to be suggested, got %q", portErr.Suggested)
+	}
+	if err := CheckPort(Forward{Type: Dynamic, LocalPort: portErr.Suggested}); err != nil {
+		t.Errorf("Expected the suggested port to be free, got %v", err)
+	}
+
+	// Ports of remote forwards listen on the host and are not checked
+	if err := CheckPort(Forward{Type: Remote, LocalPort: taken, RemoteHost: "localhost", RemotePort: "80"}); err != nil {
+		t.Errorf("Expected remote forwards not to be checked, got %v", err)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		forward Forward
+		want    []string
+	}{
+		{Forward{Type: Local, LocalPort: "8080"}, nil},
+		{Forward{Type: Local, LocalPort: "8080", BindAddress: "::1"}, nil},
+		{Forward{Type: Local, LocalPort: "80"}, []string{"privileged"}},
+		{Forward{Type: Dynamic, LocalPort: "1080", BindAddress: "0.0.0.0"}, []string{"exposes port 1080"}},
+		{Forward{Type: Remote, LocalPort: "443", BindAddress: "*"}, []string{"privileged", "GatewayPorts"}},
+	}
+
+	for _, tt := range tests {
+		got := Warnings(tt.forward)
+		if len(got) != len(tt.want) {
+			t.Errorf("Warnings(%+v) = %q, want %d warnings", tt.forward, got, len(tt.want))
+			continue
+		}
+		for i, want := range tt.want {
+			if !strings.Contains(got[i], want) {
+				t.Errorf("Warnings(%+v)[%d] = %q, want it to mention %q", tt.forward, i, got[i], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/Gu1llaum-3/sshm/internal/adhoc"
+	"github.com/Gu1llaum-3/sshm/internal/hook"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// adhocIndicator marks the row connecting to the target typed in the search box
+const adhocIndicator = "→"
+
+// adhocDoneMsg reports the end of a session on an ad-hoc target
+type adhocDoneMsg struct {
+	target adhoc.Target
+	err    error
+}
+
+// adhocTarget returns the target typed in the search box, if the search is one
+func (m Model) adhocTarget() (adhoc.Target, bool) {
+	query := strings.TrimSpace(m.searchInput.Value())
+	if query == "" {
+		return adhoc.Target{}, false
+	}
+	target, err := adhoc.Parse(query)
+	return target, err == nil
+}
+
+// adhocRow returns the row connecting to target, with one cell per column
+func adhocRow(target adhoc.Target, columns int) table.Row {
+	row := make(table.Row, max(columns, 2))
+	row[0] = adhocIndicator + " " + target.String()
+	row[1] = "connect to ad-hoc target"
+	return row
+}
+
+// adhocRowSelected reports whether the cursor is on the ad-hoc target row,
+// which always comes last
+func (m Model) adhocRowSelected() bool {
+	if _, ok := m.adhocTarget(); !ok {
+		return false
+	}
+	rows := m.table.Rows()
+	return len(rows) > 0 && m.table.Cursor() == len(rows)-1 && strings.HasPrefix(rows[len(rows)-1][0], adhocIndicator+" ")
+}
+
+// connectToTarget opens a session on an ad-hoc target, running the global hooks around it
+func (m Model) connectToTarget(target adhoc.Target) tea.Cmd {
+	name := target.String()
+	if m.historyManager != nil {
+		if err := m.historyManager.RecordConnection(name); err != nil {
+			// Log the error but don't prevent the connection
+			fmt.Printf("Warning: Could not record connection history: %v\n", err)
+		}
+	}
+
+	var args []string
+	if m.configFile != "" {
+		args = append(args, "-F", m.configFile)
+	}
+	sshCmd := exec.Command("ssh", append(args, target.Args()...)...)
+
+	done := func(err error) tea.Msg {
+		return adhocDoneMsg{target: target, err: err}
+	}
+	if m.appConfig != nil {
+		host := target.SSHHost(name)
+		if hooks := hook.ForHost(m.appConfig.Hooks, host); !hooks.Empty() {
+			return tea.Exec(newHookedCommand(execCommand{sshCmd}, hooks, host), done)
+		}
+	}
+	return tea.ExecProcess(sshCmd, done)
+}
+
+// adhocDone offers to save the target of a session once it ends, unless the
+// connection could not be made
+func (m Model) adhocDone(msg adhocDoneMsg) (Model, tea.Cmd) {
+	var hookErr *hook.Error
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(msg.err, &hookErr) && hookErr.Stage == hook.PreConnect:
+		return m, m.showErrorCmd(msg.err.Error())
+	case errors.As(msg.err, &exitErr) && exitErr.ExitCode() == 255:
+		return m, m.showErrorCmd(fmt.Sprintf("Could not connect to %s", msg.target))
+	case msg.err != nil && exitErr == nil:
+		return m, m.showErrorCmd(fmt.Sprintf("Could not run ssh: %v", msg.err))
+	}
+
+	// The target is no longer searched for once saved or skipped
+	m.searchInput.SetValue("")
+	m.filteredHosts = m.hosts
+	m.updateTableRows()
+
+	m.addForm = NewAddForm(msg.target.SuggestAlias(m.allHosts), m.styles, m.width, m.height, m.configFile)
+	m.addForm.inputs[hostnameInput].SetValue(msg.target.Host)
+	m.addForm.inputs[userInput].SetValue(msg.target.User)
+	m.addForm.inputs[portInput].SetValue(msg.target.Port)
+	m.viewMode = ViewAdd
+	return m, nil
+}
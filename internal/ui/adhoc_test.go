@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/adhoc"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAdhocTarget(t *testing.T) {
+	m := createTestModel()
+
+	// Type a target no host matches
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	m = updated.(Model)
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("deploy@10.0.0.5:2222")})
+	m = updated.(Model)
+
+	rows := m.table.Rows()
+	if len(m.filteredHosts) != 0 || len(rows) != 1 {
+		t.Fatalf("Expected only the ad-hoc row, got %d hosts and %d rows", len(m.filteredHosts), len(rows))
+	}
+	if rows[0][0] != "→ deploy@10.0.0.5:2222" || rows[0][1] != "connect to ad-hoc target" {
+		t.Errorf("Unexpected ad-hoc row %q", rows[0])
+	}
+	if len(rows[0]) != len(m.table.Columns()) {
+		t.Errorf("Expected the ad-hoc row to have %d cells, got %d", len(m.table.Columns()), len(rows[0]))
+	}
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd == nil {
+		t.Error("Expected enter to connect to the target when no host matches")
+	}
+
+	// Host actions do not apply to the ad-hoc row
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updated.(Model)
+	if !m.adhocRowSelected() {
+		t.Fatal("Expected the ad-hoc row to be selected")
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	if updated.(Model).viewMode != ViewList {
+		t.Error("Expected e to do nothing on the ad-hoc row")
+	}
+
+	// Plain searches get no ad-hoc row
+	m.searchInput.SetValue("server")
+	m.filteredHosts = m.filterHosts("server")
+	m.updateTableRows()
+	if len(m.table.Rows()) != len(m.filteredHosts) || m.adhocRowSelected() {
+		t.Error("Expected no ad-hoc row for a plain search")
+	}
+
+	// Once the session ends, the target can be saved
+	m.searchInput.SetValue("deploy@web.example.com:2222")
+	m.allHosts = m.hosts
+	updated, _ = m.Update(adhocDoneMsg{target: adhoc.Target{User: "deploy", Host: "web.example.com", Port: "2222"}})
+	m = updated.(Model)
+	if m.viewMode != ViewAdd || m.addForm == nil {
+		t.Fatal("Expected the add form once the session ends")
+	}
+	for input, want := range map[int]string{nameInput: "web", hostnameInput: "web.example.com", userInput: "deploy", portInput: "2222"} {
+		if got := m.addForm.inputs[input].Value(); got != want {
+			t.Errorf("Expected input %d to be %q, got %q", input, want, got)
+		}
+	}
+	if m.searchInput.Value() != "" {
+		t.Error("Expected the search to be cleared")
+	}
+
+	// A failed connection is not offered for saving
+	if runtime.GOOS == "windows" {
+		return
+	}
+	err := exec.Command("sh", "-c", "exit 255").Run()
+	m = createTestModel()
+	updated, _ = m.Update(adhocDoneMsg{target: adhoc.Target{Host: "unreachable"}, err: err})
+	m = updated.(Model)
+	if m.viewMode == ViewAdd || !strings.Contains(m.errorMessage, "Could not connect to unreachable") {
+		t.Errorf("Expected an error for a failed connection, got %q", m.errorMessage)
+	}
+}
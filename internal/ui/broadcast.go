@@ -0,0 +1,327 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// broadcastPrefix is the key that precedes broadcast mode commands, like in tmux
+const broadcastPrefix = "ctrl+b"
+
+// broadcastModel shows several ssh sessions side by side and sends keystrokes
+// to all of them, or to the focused one
+type broadcastModel struct {
+	panes     []*broadcastPane
+	focus     int
+	all       bool // when true, keystrokes go to every running session
+	prefix    bool // the prefix key was pressed, the next key is a command
+	updates   chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+	warning   string // shown under the header, e.g. when history could not be recorded
+	styles    Styles
+	width     int
+	height    int
+}
+
+// Messages for communication with parent model
+type broadcastUpdateMsg struct{}
+
+type broadcastCloseMsg struct{}
+
+// NewBroadcast starts an interactive ssh session under a PTY for each host
+func NewBroadcast(hosts []config.SSHHost, configFile string, styles Styles, width, height int) (*broadcastModel, error) {
+	m := &broadcastModel{
+		all:     true,
+		updates: make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		styles:  styles,
+		width:   width,
+		height:  height,
+	}
+
+	cols, rows := m.paneSize(len(hosts))
+	for _, host := range hosts {
+		var args []string
+		if configFile != "" {
+			args = append(args, "-F", configFile)
+		}
+		args = append(args, host.Name)
+
+		pane, err := startBroadcastPane(host.Name, args, cols, rows, m.updates)
+		if err != nil {
+			m.Close()
+			return nil, fmt.Errorf("failed to start ssh for %s: %w", host.Name, err)
+		}
+		m.panes = append(m.panes, pane)
+	}
+
+	return m, nil
+}
+
+// listenCmd waits for new output from any session
+func (m *broadcastModel) listenCmd() tea.Cmd {
+	return func() tea.Msg {
+		select {
+		case <-m.updates:
+			return broadcastUpdateMsg{}
+		case <-m.done:
+			return nil
+		}
+	}
+}
+
+// Close ends all sessions
+func (m *broadcastModel) Close() {
+	m.closeOnce.Do(func() {
+		close(m.done)
+		for _, pane := range m.panes {
+			pane.close()
+		}
+	})
+}
+
+// paneSize returns the terminal size of each pane when showing count panes
+func (m *broadcastModel) paneSize(count int) (int, int) {
+	gridCols, gridRows := broadcastGrid(count)
+	// Each pane has a border (2) and a title line; the view has a header and a help line
+	cols := max(10, m.width/gridCols-2)
+	rows := max(3, (m.height-2)/gridRows-3)
+	return cols, rows
+}
+
+// broadcastGrid lays out count panes in a grid as square as possible
+func broadcastGrid(count int) (int, int) {
+	if count <= 0 {
+		return 1, 1
+	}
+	gridCols := int(math.Ceil(math.Sqrt(float64(count))))
+	gridRows := (count + gridCols - 1) / gridCols
+	return gridCols, gridRows
+}
+
+// resize resizes all sessions to fit the window
+func (m *broadcastModel) resize(width, height int) {
+	m.width = width
+	m.height = height
+	cols, rows := m.paneSize(len(m.panes))
+	for _, pane := range m.panes {
+		pane.resize(cols, rows)
+	}
+}
+
+func (m *broadcastModel) Update(msg tea.Msg) (*broadcastModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.styles = NewStyles(msg.Width)
+		m.resize(msg.Width, msg.Height)
+		return m, nil
+
+	case tea.KeyMsg:
+		key := msg.String()
+		if m.prefix {
+			m.prefix = false
+			switch key {
+			case "tab", "n", "right", "down":
+				m.focus = (m.focus + 1) % len(m.panes)
+			case "shift+tab", "p", "left", "up":
+				m.focus = (m.focus + len(m.panes) - 1) % len(m.panes)
+			case "a":
+				m.all = !m.all
+			case "q", "x":
+				return m, func() tea.Msg { return broadcastCloseMsg{} }
+			case broadcastPrefix:
+				// Pressing the prefix twice sends it to the sessions
+				m.send(keyBytes(msg))
+			}
+			return m, nil
+		}
+		if key == broadcastPrefix {
+			m.prefix = true
+			return m, nil
+		}
+		m.send(keyBytes(msg))
+	}
+	return m, nil
+}
+
+// send writes input to every running session, or to the focused one
+func (m *broadcastModel) send(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	for i, pane := range m.panes {
+		if (m.all || i == m.focus) && !pane.exited.Load() {
+			pane.write(data)
+		}
+	}
+}
+
+// keyBytes converts a key press to the bytes a terminal sends for it
+func keyBytes(msg tea.KeyMsg) []byte {
+	var data []byte
+	switch msg.Type {
+	case tea.KeyRunes:
+		data = []byte(string(msg.Runes))
+	case tea.KeySpace:
+		data = []byte(" ")
+	case tea.KeyUp:
+		data = []byte("\x1b[A")
+	case tea.KeyDown:
+		data = []byte("\x1b[B")
+	case tea.KeyRight:
+		data = []byte("\x1b[C")
+	case tea.KeyLeft:
+		data = []byte("\x1b[D")
+	case tea.KeyHome:
+		data = []byte("\x1b[H")
+	case tea.KeyEnd:
+		data = []byte("\x1b[F")
+	case tea.KeyPgUp:
+		data = []byte("\x1b[5~")
+	case tea.KeyPgDown:
+		data = []byte("\x1b[6~")
+	case tea.KeyInsert:
+		data = []byte("\x1b[2~")
+	case tea.KeyDelete:
+		data = []byte("\x1b[3~")
+	case tea.KeyShiftTab:
+		data = []byte("\x1b[Z")
+	default:
+		// Control characters, including Enter, Tab, Backspace and Esc
+		if (msg.Type >= 0 && msg.Type < 32) || msg.Type == 127 {
+			data = []byte{byte(msg.Type)}
+		}
+	}
+	if msg.Alt && len(data) > 0 {
+		data = append([]byte{0x1b}, data...)
+	}
+	return data
+}
+
+func (m *broadcastModel) View() string {
+	target := "ALL sessions"
+	if !m.all {
+		target = m.panes[m.focus].host
+	}
+	header := m.styles.FormTitle.Render(fmt.Sprintf("Broadcast: typing to %s", target))
+
+	gridCols, _ := broadcastGrid(len(m.panes))
+	var rows []string
+	for start := 0; start < len(m.panes); start += gridCols {
+		end := min(start+gridCols, len(m.panes))
+		var cells []string
+		for i := start; i < end; i++ {
+			cells = append(cells, m.renderPane(i))
+		}
+		rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, cells...))
+	}
+
+	help := m.styles.HelpText.Render(" ctrl+b then: tab/n: next • p: previous • a: all/one session • q: close all • ctrl+b: send ctrl+b")
+	if m.prefix {
+		help = m.styles.FocusedLabel.Render(" ctrl+b: waiting for a command key...")
+	}
+
+	components := []string{header}
+	if m.warning != "" {
+		components = append(components, m.styles.Error.Render(m.warning))
+	}
+	components = append(components, lipgloss.JoinVertical(lipgloss.Left, rows...), help)
+	return lipgloss.JoinVertical(lipgloss.Left, components...)
+}
+
+// renderPane draws a session with its title, showing the cursor of the focused one
+func (m *broadcastModel) renderPane(i int) string {
+	pane := m.panes[i]
+	focused := i == m.focus
+	targeted := m.all || focused
+
+	title := pane.host
+	if pane.exited.Load() {
+		if pane.err != nil {
+			title += fmt.Sprintf(" [exited: %v]", pane.err)
+		} else {
+			title += " [exited]"
+		}
+	}
+
+	borderColor := lipgloss.Color("241")
+	if targeted {
+		borderColor = lipgloss.Color(PrimaryColor)
+	}
+	titleStyle := lipgloss.NewStyle().Bold(focused).Foreground(borderColor)
+	cursorStyle := lipgloss.NewStyle().Reverse(true)
+
+	screen, cols, cursorX, cursorY, cursorVisible := pane.screen()
+	lines := make([]string, 0, len(screen)+1)
+	lines = append(lines, titleStyle.Render(truncate(title, cols)))
+	for y, line := range screen {
+		if focused && cursorVisible && !pane.exited.Load() && y == cursorY && cursorX < len(line) {
+			lines = append(lines, string(line[:cursorX])+cursorStyle.Render(string(line[cursorX]))+string(line[cursorX+1:]))
+			continue
+		}
+		lines = append(lines, string(line))
+	}
+
+	return lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Render(strings.Join(lines, "\n"))
+}
+
+// truncate shortens text to width runes
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:width])
+}
+
+// Standalone broadcast session for CLI usage
+type standaloneBroadcast struct {
+	broadcast *broadcastModel
+}
+
+func (m standaloneBroadcast) Init() tea.Cmd {
+	return m.broadcast.listenCmd()
+}
+
+func (m standaloneBroadcast) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg.(type) {
+	case broadcastUpdateMsg:
+		return m, m.broadcast.listenCmd()
+	case broadcastCloseMsg:
+		m.broadcast.Close()
+		return m, tea.Quit
+	}
+
+	newBroadcast, cmd := m.broadcast.Update(msg)
+	m.broadcast = newBroadcast
+	return m, cmd
+}
+
+func (m standaloneBroadcast) View() string {
+	return m.broadcast.View()
+}
+
+// RunBroadcast opens a broadcast session to hosts outside of the interactive mode
+func RunBroadcast(hosts []config.SSHHost, configFile string) error {
+	styles := NewStyles(80)
+	broadcast, err := NewBroadcast(hosts, configFile, styles, 80, 24)
+	if err != nil {
+		return err
+	}
+	defer broadcast.Close()
+
+	p := tea.NewProgram(standaloneBroadcast{broadcast}, tea.WithAltScreen())
+	_, err = p.Run()
+	return err
+}
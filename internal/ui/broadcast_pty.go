@@ -0,0 +1,103 @@
+//go:build !windows
+
+package ui
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"sync/atomic"
+
+	"github.com/creack/pty"
+	"github.com/hinshun/vt10x"
+)
+
+// broadcastPane is an interactive ssh session running under a PTY
+type broadcastPane struct {
+	host   string
+	cmd    *exec.Cmd
+	pty    *os.File
+	term   vt10x.Terminal
+	err    error // exit error of ssh, set before exited
+	exited atomic.Bool
+}
+
+// startBroadcastPane starts ssh with args under a PTY of the given size,
+// signaling updates whenever its screen changes
+func startBroadcastPane(hostName string, args []string, cols, rows int, updates chan<- struct{}) (*broadcastPane, error) {
+	cmd := exec.Command("ssh", args...)
+	ptyFile, err := pty.StartWithSize(cmd, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+	if err != nil {
+		return nil, err
+	}
+
+	pane := &broadcastPane{
+		host: hostName,
+		cmd:  cmd,
+		pty:  ptyFile,
+		// Terminal replies (cursor position, device attributes) go back to ssh
+		term: vt10x.New(vt10x.WithWriter(ptyFile), vt10x.WithSize(cols, rows)),
+	}
+	go pane.run(updates)
+	return pane, nil
+}
+
+// run feeds the session output to the terminal emulator until ssh exits
+func (p *broadcastPane) run(updates chan<- struct{}) {
+	reader := bufio.NewReader(p.pty)
+	for {
+		if err := p.term.Parse(reader); err != nil {
+			break
+		}
+		notifyBroadcast(updates)
+	}
+	p.err = p.cmd.Wait()
+	p.exited.Store(true)
+	notifyBroadcast(updates)
+}
+
+// notifyBroadcast signals new output without blocking, coalescing pending signals
+func notifyBroadcast(updates chan<- struct{}) {
+	select {
+	case updates <- struct{}{}:
+	default:
+	}
+}
+
+func (p *broadcastPane) write(data []byte) {
+	_, _ = p.pty.Write(data)
+}
+
+func (p *broadcastPane) resize(cols, rows int) {
+	p.term.Resize(cols, rows)
+	_ = pty.Setsize(p.pty, &pty.Winsize{Cols: uint16(cols), Rows: uint16(rows)})
+}
+
+// close ends the session
+func (p *broadcastPane) close() {
+	if !p.exited.Load() && p.cmd.Process != nil {
+		_ = p.cmd.Process.Kill()
+	}
+	p.pty.Close()
+}
+
+// screen returns the lines of the terminal, its width and the cursor position
+func (p *broadcastPane) screen() ([][]rune, int, int, int, bool) {
+	p.term.Lock()
+	defer p.term.Unlock()
+
+	cols, rows := p.term.Size()
+	lines := make([][]rune, rows)
+	for y := range lines {
+		line := make([]rune, cols)
+		for x := range line {
+			line[x] = p.term.Cell(x, y).Char
+			if line[x] == 0 {
+				line[x] = ' '
+			}
+		}
+		lines[y] = line
+	}
+	cursor := p.term.Cursor()
+	return lines, cols, cursor.X, cursor.Y, p.term.CursorVisible()
+}
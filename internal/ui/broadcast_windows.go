@@ -0,0 +1,29 @@
+//go:build windows
+
+package ui
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// broadcastPane is an interactive ssh session; PTYs are not available on Windows
+type broadcastPane struct {
+	host   string
+	err    error
+	exited atomic.Bool
+}
+
+func startBroadcastPane(hostName string, args []string, cols, rows int, updates chan<- struct{}) (*broadcastPane, error) {
+	return nil, errors.New("broadcast sessions are not supported on Windows")
+}
+
+func (p *broadcastPane) write(data []byte) {}
+
+func (p *broadcastPane) resize(cols, rows int) {}
+
+func (p *broadcastPane) close() {}
+
+func (p *broadcastPane) screen() ([][]rune, int, int, int, bool) {
+	return nil, 0, 0, 0, false
+}
@@ -0,0 +1,525 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/remote"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// bulkRunTimeout is the timeout for a command run on each marked host
+const bulkRunTimeout = 2 * time.Minute
+
+// bulkAction is an action applied to all marked hosts
+type bulkAction int
+
+const (
+	bulkAddTag bulkAction = iota
+	bulkRemoveTag
+	bulkMove
+	bulkPing
+	bulkExport
+	bulkRun
+	bulkBroadcast
+	bulkDelete
+)
+
+// bulkActions lists the actions in the order they are shown
+var bulkActions = []bulkAction{bulkAddTag, bulkRemoveTag, bulkMove, bulkPing, bulkExport, bulkRun, bulkBroadcast, bulkDelete}
+
+func (a bulkAction) String() string {
+	switch a {
+	case bulkAddTag:
+		return "Add tag"
+	case bulkRemoveTag:
+		return "Remove tag"
+	case bulkMove:
+		return "Move to another config file"
+	case bulkPing:
+		return "Ping"
+	case bulkExport:
+		return "Export to a file"
+	case bulkRun:
+		return "Run a command"
+	case bulkBroadcast:
+		return "Open a broadcast session"
+	case bulkDelete:
+		return "Delete"
+	default:
+		return "Unknown"
+	}
+}
+
+type bulkFormState int
+
+const (
+	bulkFormChoosingAction bulkFormState = iota
+	bulkFormInput
+	bulkFormSelectingFile
+	bulkFormRunning
+	bulkFormResults
+)
+
+type bulkFormModel struct {
+	hosts        []config.SSHHost // marked hosts the action applies to
+	cursor       int
+	action       bulkAction
+	input        textinput.Model
+	fileSelector *fileSelectorModel
+	results      []bulkRunResult
+	scroll       int
+	parallel     int
+	configFile   string
+	err          string
+	state        bulkFormState
+	styles       Styles
+	width        int
+	height       int
+}
+
+// bulkRunResult is the output of a command run on one marked host
+type bulkRunResult struct {
+	host     string
+	exitCode int
+	output   string
+	err      error
+}
+
+// Messages for communication with parent model
+type bulkFormSubmitMsg struct {
+	action bulkAction
+	errs   []error
+}
+
+// bulkFormActionMsg asks the parent model to perform an action it owns (ping, broadcast, delete)
+type bulkFormActionMsg struct {
+	action bulkAction
+}
+
+type bulkFormCancelMsg struct{}
+
+type bulkRunDoneMsg struct {
+	results []bulkRunResult
+}
+
+// NewBulkForm creates a form to choose an action for the marked hosts
+func NewBulkForm(hosts []config.SSHHost, styles Styles, width, height int, configFile string, parallel int) *bulkFormModel {
+	ti := textinput.New()
+	ti.CharLimit = 200
+	ti.Width = 50
+
+	return &bulkFormModel{
+		hosts:      hosts,
+		input:      ti,
+		parallel:   parallel,
+		configFile: configFile,
+		state:      bulkFormChoosingAction,
+		styles:     styles,
+		width:      width,
+		height:     height,
+	}
+}
+
+func (m *bulkFormModel) Init() tea.Cmd {
+	return nil
+}
+
+// startAction moves the form to the step needed by an action
+func (m *bulkFormModel) startAction(action bulkAction) tea.Cmd {
+	m.action = action
+	m.err = ""
+
+	switch action {
+	case bulkPing, bulkBroadcast, bulkDelete:
+		return func() tea.Msg { return bulkFormActionMsg{action: action} }
+
+	case bulkMove:
+		var files []string
+		var err error
+		if m.configFile != "" {
+			files, err = config.GetAllConfigFilesFromBase(m.configFile)
+		} else {
+			files, err = config.GetAllConfigFiles()
+		}
+		if err == nil && len(files) <= 1 {
+			err = fmt.Errorf("no includes found in SSH config file - move operation requires multiple config files")
+		}
+		if err != nil {
+			m.err = err.Error()
+			return nil
+		}
+		fileSelector, err := newFileSelectorFromFiles(
+			fmt.Sprintf("Select destination config file for %d hosts:", len(m.hosts)),
+			m.styles, m.width, m.height, files)
+		if err != nil {
+			m.err = err.Error()
+			return nil
+		}
+		m.fileSelector = fileSelector
+		m.state = bulkFormSelectingFile
+		return nil
+
+	default:
+		switch action {
+		case bulkAddTag, bulkRemoveTag:
+			m.input.Placeholder = "tag"
+		case bulkExport:
+			m.input.Placeholder = "~/sshm-export.conf"
+		case bulkRun:
+			m.input.Placeholder = "uptime"
+		}
+		m.input.SetValue("")
+		m.input.Focus()
+		m.state = bulkFormInput
+		return textinput.Blink
+	}
+}
+
+func (m *bulkFormModel) Update(msg tea.Msg) (*bulkFormModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.styles = NewStyles(m.width)
+		return m, nil
+
+	case bulkRunDoneMsg:
+		m.results = msg.results
+		m.scroll = 0
+		m.state = bulkFormResults
+		return m, nil
+
+	case tea.KeyMsg:
+		switch m.state {
+		case bulkFormChoosingAction:
+			switch msg.String() {
+			case "ctrl+c", "esc", "q":
+				return m, func() tea.Msg { return bulkFormCancelMsg{} }
+			case "up", "k":
+				if m.cursor > 0 {
+					m.cursor--
+				}
+			case "down", "j":
+				if m.cursor < len(bulkActions)-1 {
+					m.cursor++
+				}
+			case "enter":
+				return m, m.startAction(bulkActions[m.cursor])
+			}
+			return m, nil
+
+		case bulkFormInput:
+			switch msg.String() {
+			case "ctrl+c", "esc":
+				m.input.Blur()
+				m.err = ""
+				m.state = bulkFormChoosingAction
+				return m, nil
+			case "enter":
+				return m, m.submitInput()
+			}
+			var cmd tea.Cmd
+			m.input, cmd = m.input.Update(msg)
+			return m, cmd
+
+		case bulkFormSelectingFile:
+			switch msg.String() {
+			case "ctrl+c", "esc", "q":
+				m.fileSelector = nil
+				m.state = bulkFormChoosingAction
+				return m, nil
+			case "enter":
+				if m.fileSelector != nil && len(m.fileSelector.files) > 0 {
+					targetFile := m.fileSelector.files[m.fileSelector.selected]
+					m.state = bulkFormRunning
+					return m, m.applyToHosts(func(host config.SSHHost) error {
+						if host.SourceFile == targetFile {
+							return nil
+						}
+						return config.MoveHostToFile(host.Name, targetFile)
+					})
+				}
+			default:
+				if m.fileSelector != nil {
+					newFileSelector, cmd := m.fileSelector.Update(msg)
+					m.fileSelector = newFileSelector
+					return m, cmd
+				}
+			}
+			return m, nil
+
+		case bulkFormResults:
+			switch msg.String() {
+			case "ctrl+c", "esc", "q", "enter":
+				return m, func() tea.Msg { return bulkFormCancelMsg{} }
+			case "up", "k":
+				if m.scroll > 0 {
+					m.scroll--
+				}
+			case "down", "j":
+				m.scroll = min(m.scroll+1, m.maxScroll())
+			case "pgup":
+				m.scroll = max(0, m.scroll-m.resultsHeight())
+			case "pgdown":
+				m.scroll = min(m.scroll+m.resultsHeight(), m.maxScroll())
+			}
+			return m, nil
+		}
+	}
+
+	return m, nil
+}
+
+// submitInput validates the input of the current action and starts it
+func (m *bulkFormModel) submitInput() tea.Cmd {
+	value := strings.TrimSpace(m.input.Value())
+	if value == "" {
+		m.err = "A value is required"
+		return nil
+	}
+
+	switch m.action {
+	case bulkAddTag, bulkRemoveTag:
+		if strings.ContainsAny(value, ", \t") {
+			m.err = "A tag cannot contain spaces or commas"
+			return nil
+		}
+		add := m.action == bulkAddTag
+		m.state = bulkFormRunning
+		return m.applyToHosts(func(host config.SSHHost) error {
+			return updateHostTag(host, value, add)
+		})
+
+	case bulkExport:
+		path := expandHomePath(value)
+		m.state = bulkFormRunning
+		return m.applyToHosts(func(host config.SSHHost) error {
+			return config.AddSSHHostToFile(host, path)
+		})
+
+	case bulkRun:
+		m.state = bulkFormRunning
+		return runBulkCommandCmd(m.hosts, value, m.configFile, m.parallel)
+	}
+	return nil
+}
+
+// applyToHosts runs fn on each marked host in the background and reports the failures
+func (m *bulkFormModel) applyToHosts(fn func(config.SSHHost) error) tea.Cmd {
+	action := m.action
+	hosts := m.hosts
+	return func() tea.Msg {
+		var errs []error
+		for _, host := range hosts {
+			if err := fn(host); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", host.Name, err))
+			}
+		}
+		return bulkFormSubmitMsg{action: action, errs: errs}
+	}
+}
+
+// updateHostTag adds or removes a tag on a host, leaving hosts that already match untouched
+func updateHostTag(host config.SSHHost, tag string, add bool) error {
+	var tags []string
+	found := false
+	for _, existing := range host.Tags {
+		if existing == tag {
+			found = true
+			if !add {
+				continue
+			}
+		}
+		tags = append(tags, existing)
+	}
+	if found == add {
+		return nil
+	}
+	if add {
+		tags = append(tags, tag)
+	}
+
+	updated := host
+	updated.Tags = tags
+	return config.UpdateSSHHostInFile(host.Name, updated, host.SourceFile)
+}
+
+// expandHomePath expands a leading ~ to the user's home directory
+func expandHomePath(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+}
+
+// runBulkCommandCmd runs a command on hosts over ssh, at most parallel at once
+func runBulkCommandCmd(hosts []config.SSHHost, command, configFile string, parallel int) tea.Cmd {
+	if parallel <= 0 {
+		parallel = 1
+	}
+	return func() tea.Msg {
+		results := make([]bulkRunResult, len(hosts))
+		limit := make(chan struct{}, parallel)
+		var wg sync.WaitGroup
+		for i, host := range hosts {
+			wg.Add(1)
+			go func(i int, hostName string) {
+				defer wg.Done()
+				limit <- struct{}{}
+				defer func() { <-limit }()
+				results[i] = runOnHost(hostName, command, configFile)
+			}(i, host.Name)
+		}
+		wg.Wait()
+		return bulkRunDoneMsg{results: results}
+	}
+}
+
+// runOnHost runs a command on a host non-interactively and captures its output
+func runOnHost(hostName, command, configFile string) bulkRunResult {
+	var output bytes.Buffer
+	run := remote.Run(context.Background(), configFile, hostName, []string{command}, bulkRunTimeout, &output, &output)
+	return bulkRunResult{host: hostName, output: output.String(), exitCode: run.ExitCode, err: run.Err}
+}
+
+// resultsHeight is the number of result lines shown at once
+func (m *bulkFormModel) resultsHeight() int {
+	return max(5, m.height-10)
+}
+
+// maxScroll is the scroll offset showing the last result lines
+func (m *bulkFormModel) maxScroll() int {
+	return max(0, len(m.resultLines())-m.resultsHeight())
+}
+
+func (m *bulkFormModel) View() string {
+	var b strings.Builder
+
+	switch m.state {
+	case bulkFormSelectingFile:
+		if m.fileSelector != nil {
+			return m.fileSelector.View()
+		}
+
+	case bulkFormChoosingAction:
+		b.WriteString(m.styles.FormTitle.Render(fmt.Sprintf("Bulk actions on %d marked hosts", len(m.hosts))))
+		b.WriteString("\n\n")
+		b.WriteString(m.styles.HelpText.Render(m.hostList()))
+		b.WriteString("\n\n")
+		for i, action := range bulkActions {
+			if i == m.cursor {
+				b.WriteString(m.styles.Selected.Render(fmt.Sprintf("▶ %s", action)))
+			} else {
+				b.WriteString(fmt.Sprintf("  %s", action))
+			}
+			b.WriteString("\n")
+		}
+		if m.err != "" {
+			b.WriteString("\n")
+			b.WriteString(m.styles.ErrorText.Render(m.err))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+		b.WriteString(m.styles.FormHelp.Render("↑/↓: navigate • Enter: select • Esc: cancel"))
+
+	case bulkFormInput:
+		label := map[bulkAction]string{
+			bulkAddTag:    "Tag to add",
+			bulkRemoveTag: "Tag to remove",
+			bulkExport:    "Export to file",
+			bulkRun:       "Command to run",
+		}[m.action]
+		b.WriteString(m.styles.FormTitle.Render(fmt.Sprintf("%s (%d hosts)", m.action, len(m.hosts))))
+		b.WriteString("\n\n")
+		b.WriteString(m.styles.FocusedLabel.Render(label))
+		b.WriteString("\n")
+		b.WriteString(m.input.View())
+		b.WriteString("\n")
+		if m.err != "" {
+			b.WriteString("\n")
+			b.WriteString(m.styles.ErrorText.Render(m.err))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+		b.WriteString(m.styles.FormHelp.Render("Enter: apply • Esc: back"))
+
+	case bulkFormRunning:
+		b.WriteString(m.styles.FormTitle.Render(fmt.Sprintf("%s...", m.action)))
+		b.WriteString("\n\n")
+		b.WriteString(m.styles.HelpText.Render(fmt.Sprintf("Working on %d hosts...", len(m.hosts))))
+
+	case bulkFormResults:
+		b.WriteString(m.styles.FormTitle.Render(m.resultsTitle()))
+		b.WriteString("\n\n")
+		lines := m.resultLines()
+		end := min(len(lines), m.scroll+m.resultsHeight())
+		b.WriteString(strings.Join(lines[m.scroll:end], "\n"))
+		b.WriteString("\n\n")
+		b.WriteString(m.styles.FormHelp.Render("↑/↓/PgUp/PgDn: scroll • Enter/Esc: close"))
+	}
+
+	return b.String()
+}
+
+// hostList names the marked hosts, shortened when there are many
+func (m *bulkFormModel) hostList() string {
+	const shown = 8
+	var names []string
+	for i, host := range m.hosts {
+		if i == shown {
+			names = append(names, fmt.Sprintf("and %d more", len(m.hosts)-shown))
+			break
+		}
+		names = append(names, host.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// resultsTitle summarizes the exit codes of a bulk command
+func (m *bulkFormModel) resultsTitle() string {
+	succeeded := 0
+	for _, result := range m.results {
+		if result.exitCode == 0 {
+			succeeded++
+		}
+	}
+	return fmt.Sprintf("Command finished: %d/%d hosts succeeded", succeeded, len(m.results))
+}
+
+// resultLines renders the output of each host under a header with its exit status
+func (m *bulkFormModel) resultLines() []string {
+	okStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true)
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
+
+	var lines []string
+	for _, result := range m.results {
+		switch {
+		case result.err != nil:
+			lines = append(lines, failStyle.Render(fmt.Sprintf("── %s: %v", result.host, result.err)))
+		case result.exitCode != 0:
+			lines = append(lines, failStyle.Render(fmt.Sprintf("── %s (exit %d)", result.host, result.exitCode)))
+		default:
+			lines = append(lines, okStyle.Render(fmt.Sprintf("── %s (exit 0)", result.host)))
+		}
+		if output := strings.TrimRight(result.output, "\n"); output != "" {
+			lines = append(lines, strings.Split(output, "\n")...)
+		}
+		lines = append(lines, "")
+	}
+	return lines
+}
@@ -2,9 +2,12 @@ package ui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/mux"
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
 	"github.com/Gu1llaum-3/sshm/internal/validation"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -16,6 +19,10 @@ const (
 	focusAreaProperties
 )
 
+// editForwardsStart is the index of the first forward directive input, the
+// inputs after the host properties list the host's forward directives
+const editForwardsStart = 11
+
 type editFormSubmitMsg struct {
 	hostname string
 	err      error
@@ -91,7 +98,7 @@ func NewEditForm(hostName string, styles Styles, width, height int, configFile s
 		}
 	}
 
-	inputs := make([]textinput.Model, 10)
+	inputs := make([]textinput.Model, 11)
 
 	// Hostname input
 	inputs[0] = textinput.New()
@@ -167,6 +174,22 @@ func NewEditForm(hostName string, styles Styles, width, height int, configFile s
 	inputs[9].Width = 30
 	inputs[9].SetValue(host.RequestTTY)
 
+	// Multiplexing input
+	inputs[10] = textinput.New()
+	inputs[10].Placeholder = "yes, no"
+	inputs[10].CharLimit = 3
+	inputs[10].Width = 30
+	if mux.Enabled(host.Options) {
+		inputs[10].SetValue("yes")
+	} else {
+		inputs[10].SetValue("no")
+	}
+
+	// Forward directive inputs
+	for _, forward := range host.Forwards {
+		inputs = append(inputs, newForwardInput(forward))
+	}
+
 	return &editFormModel{
 		hostInputs:       hostInputs,
 		inputs:           inputs,
@@ -184,6 +207,16 @@ func NewEditForm(hostName string, styles Styles, width, height int, configFile s
 	}, nil
 }
 
+// newForwardInput creates the input of a LocalForward, RemoteForward or DynamicForward directive
+func newForwardInput(directive string) textinput.Model {
+	input := textinput.New()
+	input.Placeholder = "LocalForward 8080 localhost:80"
+	input.CharLimit = 200
+	input.Width = 50
+	input.SetValue(directive)
+	return input
+}
+
 func (m *editFormModel) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -232,6 +265,22 @@ func (m *editFormModel) deleteHostInput() tea.Cmd {
 	return nil
 }
 
+// deleteForwardInput removes the focused forward directive
+func (m *editFormModel) deleteForwardInput() tea.Cmd {
+	if m.focusArea != focusAreaProperties || m.focused < editForwardsStart || m.focused >= len(m.inputs) {
+		return nil
+	}
+
+	m.inputs = append(m.inputs[:m.focused], m.inputs[m.focused+1:]...)
+
+	// Focus the next forward, or the last field of the tab
+	if m.focused >= len(m.inputs) {
+		properties := m.getPropertiesForCurrentTab()
+		m.focused = properties[len(properties)-1]
+	}
+	return m.updateFocus()
+}
+
 // updateFocus updates the focus state based on current area and index
 func (m *editFormModel) updateFocus() tea.Cmd {
 	// Blur all inputs first
@@ -262,7 +311,11 @@ func (m *editFormModel) getPropertiesForCurrentTab() []int {
 	case 0: // General
 		return []int{0, 1, 2, 3, 4, 5, 7} // hostname, user, port, identity, proxyjump, proxycommand, tags
 	case 1: // Advanced
-		return []int{6, 8, 9} // options, remotecommand, requesttty
+		properties := []int{6, 8, 9, 10} // options, remotecommand, requesttty, multiplexing
+		for i := editForwardsStart; i < len(m.inputs); i++ {
+			properties = append(properties, i) // forward directives
+		}
+		return properties
 	default:
 		return []int{0, 1, 2, 3, 4, 5, 7}
 	}
@@ -374,7 +427,7 @@ func (m *editFormModel) getMinimumHeight() int {
 	if m.currentTab == 0 {
 		fieldsCount = 6 // 6 fields in general tab
 	} else {
-		fieldsCount = 3 // 3 fields in advanced tab
+		fieldsCount = 4 + len(m.inputs) - editForwardsStart // 4 fields and the forwards in advanced tab
 	}
 	// Each field: reduced from 4 to 3 lines per field
 	fieldsLines := fieldsCount * 3
@@ -455,6 +508,10 @@ func (m *editFormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if m.focusArea == focusAreaHosts && len(m.hostInputs) > 1 {
 				return m, m.deleteHostInput()
 			}
+			// Or the currently focused forward directive
+			if m.focusArea == focusAreaProperties && m.focused >= editForwardsStart {
+				return m, m.deleteForwardInput()
+			}
 		}
 
 	case editFormSubmitMsg:
@@ -621,6 +678,7 @@ func (m *editFormModel) renderEditAdvancedTab() string {
 		{6, "SSH Options"},
 		{8, "Remote Command"},
 		{9, "Request TTY"},
+		{10, "Multiplexing (ControlMaster)"},
 	}
 
 	for _, field := range fields {
@@ -634,6 +692,22 @@ func (m *editFormModel) renderEditAdvancedTab() string {
 		b.WriteString("\n\n")
 	}
 
+	// Forward directives of the host
+	if len(m.inputs) > editForwardsStart {
+		b.WriteString(m.styles.FormHelp.Render("Forwards: edit a directive, or clear it or press Ctrl+D to remove it"))
+		b.WriteString("\n\n")
+	}
+	for i := editForwardsStart; i < len(m.inputs); i++ {
+		fieldStyle := m.styles.FormField
+		if m.focusArea == focusAreaProperties && m.focused == i {
+			fieldStyle = m.styles.FocusedLabel
+		}
+		b.WriteString(fieldStyle.Render(fmt.Sprintf("Forward %d", i-editForwardsStart+1)))
+		b.WriteString("\n")
+		b.WriteString(m.inputs[i].View())
+		b.WriteString("\n\n")
+	}
+
 	return b.String()
 }
 
@@ -675,6 +749,12 @@ func RunEditForm(hostName string, configFile string) error {
 	return err
 }
 
+// normalizeDirective collapses the spacing of a forward directive, so that
+// directives differing only in whitespace compare equal
+func normalizeDirective(directive string) string {
+	return strings.Join(strings.Fields(directive), " ")
+}
+
 func (m *editFormModel) submitEditForm() tea.Cmd {
 	return func() tea.Msg {
 		// Collect host names
@@ -700,6 +780,7 @@ func (m *editFormModel) submitEditForm() tea.Cmd {
 		options := config.ParseSSHOptionsFromCommand(strings.TrimSpace(m.inputs[6].Value())) // optionsInput
 		remoteCommand := strings.TrimSpace(m.inputs[8].Value())                              // remoteCommandInput
 		requestTTY := strings.TrimSpace(m.inputs[9].Value())                                 // requestTTYInput
+		multiplexing := strings.ToLower(strings.TrimSpace(m.inputs[10].Value()))             // multiplexingInput
 
 		// Set defaults
 		if port == "" {
@@ -730,6 +811,40 @@ func (m *editFormModel) submitEditForm() tea.Cmd {
 			}
 		}
 
+		// Add or remove the multiplexing options when the field was changed
+		wasMultiplexed := mux.Enabled(m.host.Options)
+		switch multiplexing {
+		case "yes":
+			if !wasMultiplexed {
+				options = mux.Enable(options)
+			}
+		case "no", "":
+			if wasMultiplexed {
+				options = mux.Disable(options)
+			}
+		default:
+			return editFormSubmitMsg{err: fmt.Errorf("multiplexing must be yes or no")}
+		}
+
+		// Collect the forward directives, an emptied one is removed
+		var forwards []string
+		for _, input := range m.inputs[editForwardsStart:] {
+			directive := normalizeDirective(input.Value())
+			if directive == "" {
+				continue
+			}
+			// Directives kept as they were are not checked again
+			unchanged := slices.ContainsFunc(m.host.Forwards, func(forward string) bool {
+				return strings.EqualFold(normalizeDirective(forward), directive)
+			})
+			if !unchanged {
+				if _, err := tunnel.ParseDirective(directive); err != nil {
+					return editFormSubmitMsg{err: err}
+				}
+			}
+			forwards = append(forwards, directive)
+		}
+
 		// Create the common host configuration
 		commonHost := config.SSHHost{
 			Hostname:      hostname,
@@ -741,6 +856,7 @@ func (m *editFormModel) submitEditForm() tea.Cmd {
 			Options:       options,
 			RemoteCommand: remoteCommand,
 			RequestTTY:    requestTTY,
+			Forwards:      forwards,
 			Tags:          tags,
 		}
 
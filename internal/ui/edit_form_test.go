@@ -0,0 +1,148 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestForwardDirectivesInConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("APPDATA", tempDir)
+
+	configFile := filepath.Join(tempDir, "config")
+	if err := os.WriteFile(configFile, []byte("Host db\n    HostName db.example.com\n"), 0600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	// Save a local forward and a SOCKS proxy to the host block
+	form := NewPortForwardForm("db", NewStyles(120), 120, 40, configFile, nil)
+	form.inputs[pfLocalPortInput].SetValue("5432")
+	form.inputs[pfRemotePortInput].SetValue("5432")
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyRight})
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyRight})
+	form.inputs[pfLocalPortInput].SetValue("1080")
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	if form.err != "" {
+		t.Fatalf("Expected the forwards to be saved, got %q", form.err)
+	}
+
+	// The edit form lists them, and removing one drops it from the config
+	edit, err := NewEditForm("db", NewStyles(120), 120, 60, configFile)
+	if err != nil {
+		t.Fatalf("NewEditForm() error = %v", err)
+	}
+	forwards := edit.inputs[editForwardsStart:]
+	if len(forwards) != 2 || forwards[0].Value() != "LocalForward 5432 localhost:5432" || forwards[1].Value() != "DynamicForward 1080" {
+		t.Fatalf("Expected the saved forwards in the edit form, got %d inputs", len(forwards))
+	}
+
+	edit.focusArea = focusAreaProperties
+	edit.currentTab = 1
+	edit.focused = editForwardsStart
+	model, _ := edit.Update(tea.KeyMsg{Type: tea.KeyCtrlD})
+	edit = model.(*editFormModel)
+	edit.inputs[editForwardsStart].SetValue("DynamicForward 1081")
+	if msg := edit.submitEditForm()().(editFormSubmitMsg); msg.err != nil {
+		t.Fatalf("submitEditForm() error = %v", msg.err)
+	}
+
+	host, err := config.GetSSHHostFromFile("db", configFile)
+	if err != nil {
+		t.Fatalf("GetSSHHostFromFile() error = %v", err)
+	}
+	if strings.Join(host.Forwards, "|") != "DynamicForward 1081" {
+		t.Errorf("Expected only the edited forward to be kept, got %v", host.Forwards)
+	}
+
+	// An invalid directive is refused
+	edit, _ = NewEditForm("db", NewStyles(120), 120, 60, configFile)
+	edit.inputs[editForwardsStart].SetValue("DynamicForward socks")
+	if msg := edit.submitEditForm()().(editFormSubmitMsg); msg.err == nil {
+		t.Error("Expected an error for an invalid forward directive")
+	}
+}
+
+func TestUnchangedForwardWithIrregularSpacing(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("APPDATA", tempDir)
+
+	// A socket forward ssh accepts but the port form can't parse, spaced unevenly
+	configFile := filepath.Join(tempDir, "config")
+	content := "Host db\n    HostName db.example.com\n    LocalForward   /tmp/db.sock\t /var/run/db.sock\n"
+	if err := os.WriteFile(configFile, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+
+	edit, err := NewEditForm("db", NewStyles(120), 120, 60, configFile)
+	if err != nil {
+		t.Fatalf("NewEditForm() error = %v", err)
+	}
+	// Compare against the directive as it is written in the file
+	edit.host.Forwards = []string{"LocalForward   /tmp/db.sock\t /var/run/db.sock"}
+	edit.inputs[editForwardsStart].SetValue("LocalForward /tmp/db.sock  /var/run/db.sock ")
+	if msg := edit.submitEditForm()().(editFormSubmitMsg); msg.err != nil {
+		t.Fatalf("Expected the unchanged forward to be kept, got %v", msg.err)
+	}
+
+	host, err := config.GetSSHHostFromFile("db", configFile)
+	if err != nil {
+		t.Fatalf("GetSSHHostFromFile() error = %v", err)
+	}
+	if strings.Join(host.Forwards, "|") != "LocalForward /tmp/db.sock /var/run/db.sock" {
+		t.Errorf("Expected the forward to be kept once, got %v", host.Forwards)
+	}
+}
+
+func TestMultiplexing(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("APPDATA", tempDir)
+
+	// Multiplexed hosts keep a selectable name
+	m := createTestModel()
+	updated, _ := m.Update(muxCheckedMsg{"server2": true})
+	m = updated.(Model)
+	m.table.SetCursor(1)
+	nameCell := m.table.SelectedRow()[0]
+	if !strings.HasSuffix(nameCell, multiplexedIndicator) {
+		t.Errorf("Expected the multiplexed indicator in %q", nameCell)
+	}
+	if name := extractHostNameFromTableRow(nameCell); name != "server2" {
+		t.Errorf("extractHostNameFromTableRow(%q) = %q, want server2", nameCell, name)
+	}
+
+	// The edit form turns multiplexing on and off
+	configFile := filepath.Join(tempDir, "config")
+	if err := os.WriteFile(configFile, []byte("Host db\n    HostName db.example.com\n    Compression yes\n"), 0600); err != nil {
+		t.Fatalf("Failed to create config: %v", err)
+	}
+	for _, value := range []string{"yes", "no"} {
+		edit, err := NewEditForm("db", NewStyles(120), 120, 60, configFile)
+		if err != nil {
+			t.Fatalf("NewEditForm() error = %v", err)
+		}
+		edit.inputs[10].SetValue(value)
+		if msg := edit.submitEditForm()().(editFormSubmitMsg); msg.err != nil {
+			t.Fatalf("submitEditForm() error = %v", msg.err)
+		}
+
+		host, err := config.GetSSHHostFromFile("db", configFile)
+		if err != nil {
+			t.Fatalf("GetSSHHostFromFile() error = %v", err)
+		}
+		if enabled := strings.Contains(host.Options, "ControlMaster auto"); enabled != (value == "yes") {
+			t.Errorf("Multiplexing %s: unexpected options %q", value, host.Options)
+		}
+		if !strings.Contains(host.Options, "Compression yes") {
+			t.Errorf("Expected the other options to be kept, got %q", host.Options)
+		}
+	}
+}
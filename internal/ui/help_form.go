@@ -54,7 +54,7 @@ func (m *helpModel) View() string {
 			m.styles.HelpText.Render("show host information")),
 		lipgloss.JoinHorizontal(lipgloss.Left,
 			m.styles.FocusedLabel.Render("/  "),
-			m.styles.HelpText.Render("search hosts")),
+			m.styles.HelpText.Render("search hosts, or type user@host:port")),
 		lipgloss.JoinHorizontal(lipgloss.Left,
 			m.styles.FocusedLabel.Render("Tab "),
 			m.styles.HelpText.Render("switch focus")),
@@ -73,6 +73,24 @@ func (m *helpModel) View() string {
 		lipgloss.JoinHorizontal(lipgloss.Left,
 			m.styles.FocusedLabel.Render("d  "),
 			m.styles.HelpText.Render("delete selected host")),
+		"",
+		m.styles.FocusedLabel.Render("Multi-select"),
+		"",
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("␣  "),
+			m.styles.HelpText.Render("mark/unmark host")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("+  "),
+			m.styles.HelpText.Render("mark all matching hosts")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("*  "),
+			m.styles.HelpText.Render("invert marks of matching hosts")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("-  "),
+			m.styles.HelpText.Render("clear marks")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("B  "),
+			m.styles.HelpText.Render("bulk actions on marked hosts")),
 	)
 
 	rightColumn := lipgloss.JoinVertical(lipgloss.Left,
@@ -81,12 +99,27 @@ func (m *helpModel) View() string {
 		lipgloss.JoinHorizontal(lipgloss.Left,
 			m.styles.FocusedLabel.Render("p  "),
 			m.styles.HelpText.Render("ping all hosts")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("M  "),
+			m.styles.HelpText.Render("toggle continuous monitoring")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("A  "),
+			m.styles.HelpText.Render("toggle authentication check")),
 		lipgloss.JoinHorizontal(lipgloss.Left,
 			m.styles.FocusedLabel.Render("H  "),
 			m.styles.HelpText.Render("toggle hidden hosts visibility")),
 		lipgloss.JoinHorizontal(lipgloss.Left,
 			m.styles.FocusedLabel.Render("f  "),
 			m.styles.HelpText.Render("setup port forwarding")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("F  "),
+			m.styles.HelpText.Render("browse files over SFTP")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("T  "),
+			m.styles.HelpText.Render("show background tunnels")),
+		lipgloss.JoinHorizontal(lipgloss.Left,
+			m.styles.FocusedLabel.Render("x  "),
+			m.styles.HelpText.Render("run a snippet on selected host")),
 		lipgloss.JoinHorizontal(lipgloss.Left,
 			m.styles.FocusedLabel.Render("s  "),
 			m.styles.HelpText.Render("cycle sort modes")),
@@ -0,0 +1,127 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/hook"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// hookFailedMsg reports a pre-connect hook that stopped a connection
+type hookFailedMsg struct {
+	err error
+}
+
+// hooksFor returns the hooks configured for a host, along with the host
+func (m Model) hooksFor(hostName string) (hook.Hooks, config.SSHHost) {
+	host := config.SSHHost{Name: hostName}
+	for _, h := range m.allHosts {
+		if h.Name == hostName {
+			host = h
+			break
+		}
+	}
+	if m.appConfig == nil {
+		return hook.Hooks{}, host
+	}
+	return hook.ForHost(m.appConfig.Hooks, host), host
+}
+
+// execCommand adapts an exec.Cmd to tea.ExecCommand, keeping the streams
+// already set on it
+type execCommand struct {
+	*exec.Cmd
+}
+
+func (c execCommand) SetStdin(r io.Reader) {
+	if c.Stdin == nil {
+		c.Stdin = r
+	}
+}
+
+func (c execCommand) SetStdout(w io.Writer) {
+	if c.Stdout == nil {
+		c.Stdout = w
+	}
+}
+
+func (c execCommand) SetStderr(w io.Writer) {
+	if c.Stderr == nil {
+		c.Stderr = w
+	}
+}
+
+// hookedCommand runs the hooks of a host around a session. When a
+// pre-connect hook fails, the session is not started and the hook output
+// stays on screen until Enter is pressed.
+type hookedCommand struct {
+	session tea.ExecCommand
+	hooks   hook.Hooks
+	host    config.SSHHost
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+// newHookedCommand wraps session with the hooks of host
+func newHookedCommand(session tea.ExecCommand, hooks hook.Hooks, host config.SSHHost) *hookedCommand {
+	return &hookedCommand{session: session, hooks: hooks, host: host, stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+}
+
+func (c *hookedCommand) SetStdin(r io.Reader) {
+	c.stdin = r
+	c.session.SetStdin(r)
+}
+
+func (c *hookedCommand) SetStdout(w io.Writer) {
+	c.stdout = w
+	c.session.SetStdout(w)
+}
+
+func (c *hookedCommand) SetStderr(w io.Writer) {
+	c.stderr = w
+	c.session.SetStderr(w)
+}
+
+// Run runs the pre-connect hooks, the session and the post-disconnect hooks,
+// and returns the error of the session or of the hook that stopped it
+func (c *hookedCommand) Run() error {
+	stdio := hook.Stdio{In: c.stdin, Out: c.stdout, Err: c.stderr}
+	if err := c.hooks.RunPreConnect(c.host, stdio); err != nil {
+		fmt.Fprintf(c.stderr, "\nError: %v. Press Enter to return to sshm...", err)
+		_, _ = bufio.NewReader(c.stdin).ReadString('\n')
+		return err
+	}
+
+	err := c.session.Run()
+	exitCode := 0
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		exitCode = exitErr.ExitCode()
+	case err != nil:
+		exitCode = 1
+	}
+
+	if postErr := c.hooks.RunPostDisconnect(c.host, exitCode, stdio); postErr != nil {
+		fmt.Fprintf(c.stderr, "Warning: %v\n", postErr)
+	}
+	return err
+}
+
+// connectedMsg returns the message ending a connection made from the list:
+// the TUI quits unless a pre-connect hook stopped the connection
+func connectedMsg(err error) tea.Msg {
+	var hookErr *hook.Error
+	if errors.As(err, &hookErr) && hookErr.Stage == hook.PreConnect {
+		return hookFailedMsg{err: err}
+	}
+	return tea.Quit()
+}
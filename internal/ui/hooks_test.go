@@ -0,0 +1,65 @@
+package ui
+
+import (
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestHookedCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hooks are shell commands")
+	}
+	host := config.SSHHost{Name: "db", Tags: []string{"prod"}}
+	m := createTestModel()
+	m.allHosts = []config.SSHHost{host}
+	m.appConfig = &config.AppConfig{Hooks: config.HookSettings{
+		Tags: map[string]config.HookCommands{"prod": {
+			PreConnect:     []string{"echo pre $SSHM_HOST"},
+			PostDisconnect: []string{"echo post $SSHM_EXIT_CODE"},
+		}},
+		TimeoutSeconds: 5,
+	}}
+
+	hooks, hooked := m.hooksFor("db")
+	if hooks.Empty() || hooked.Name != "db" {
+		t.Fatalf("Expected the prod hooks for db, got %+v", hooks)
+	}
+
+	var out strings.Builder
+	cmd := newHookedCommand(execCommand{exec.Command("sh", "-c", "echo session; exit 3")}, hooks, hooked)
+	cmd.SetStdin(strings.NewReader(""))
+	cmd.SetStdout(&out)
+	cmd.SetStderr(&out)
+	err := cmd.Run()
+	if got := out.String(); got != "pre db\nsession\npost 3\n" {
+		t.Errorf("Unexpected output %q", got)
+	}
+	if _, ok := connectedMsg(err).(tea.QuitMsg); !ok {
+		t.Error("Expected to quit once the session is over")
+	}
+
+	// A failing pre-connect hook stops the connection and keeps the TUI open
+	hooks.PreConnect = []string{"echo vpn down; exit 1"}
+	out.Reset()
+	cmd = newHookedCommand(execCommand{exec.Command("sh", "-c", "echo session")}, hooks, hooked)
+	cmd.SetStdin(strings.NewReader("\n"))
+	cmd.SetStdout(&out)
+	cmd.SetStderr(&out)
+	err = cmd.Run()
+	if strings.Contains(out.String(), "session") || !strings.Contains(out.String(), "vpn down") {
+		t.Errorf("Expected only the hook to run, got %q", out.String())
+	}
+	msg, ok := connectedMsg(err).(hookFailedMsg)
+	if !ok {
+		t.Fatalf("Expected a hookFailedMsg, got %T", connectedMsg(err))
+	}
+	updated, _ := m.Update(msg)
+	if view := updated.(Model).errorMessage; !strings.Contains(view, "pre-connect hook 'echo vpn down; exit 1' failed") {
+		t.Errorf("Expected the hook error to be shown, got %q", view)
+	}
+}
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
 	"strings"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -16,6 +17,10 @@ type infoFormModel struct {
 	height     int
 	configFile string
 	hostName   string
+	// connectivity describes the latest connectivity check, if any
+	connectivity string
+	// scan is the latest server scan (sshm scan), if any
+	scan *connectivity.ServerScan
 }
 
 // Messages for communication with parent model
@@ -99,7 +104,11 @@ func (m *infoFormModel) View() string {
 		{"ProxyJump", formatOptionalValue(m.host.ProxyJump)},
 		{"ProxyCommand", formatOptionalValue(m.host.ProxyCommand)},
 		{"SSH Options", formatSSHOptions(m.host.Options)},
+		{"Forwards", formatSSHOptions(strings.Join(m.host.Forwards, "\n"))},
 		{"Tags", formatTags(m.host.Tags)},
+		{"Connectivity", formatOptionalValue(m.connectivity)},
+		{"SSH Server", formatServerScan(m.scan)},
+		{"Weak Crypto", formatWeakCrypto(m.scan)},
 	}
 
 	// Render each section
@@ -188,6 +197,27 @@ func formatSSHOptions(options string) string {
 	return options
 }
 
+func formatServerScan(scan *connectivity.ServerScan) string {
+	if scan == nil {
+		return "Not set"
+	}
+	return fmt.Sprintf("%s (scanned %s)", scan.Banner, formatTimeAgo(scan.ScannedAt))
+}
+
+func formatWeakCrypto(scan *connectivity.ServerScan) string {
+	if scan == nil {
+		return "Not set"
+	}
+	if len(scan.Weak) == 0 {
+		return "None"
+	}
+	var names []string
+	for _, weak := range scan.Weak {
+		names = append(names, weak.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
 func formatTags(tags []string) string {
 	if len(tags) == 0 {
 		return "Not set"
@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"context"
+
 	"github.com/Gu1llaum-3/sshm/internal/config"
 	"github.com/Gu1llaum-3/sshm/internal/connectivity"
 	"github.com/Gu1llaum-3/sshm/internal/history"
@@ -42,6 +44,11 @@ const (
 	ViewPortForward
 	ViewHelp
 	ViewFileSelector
+	ViewBulk
+	ViewBroadcast
+	ViewFiles
+	ViewTunnels
+	ViewSnippets
 )
 
 // PortForwardType defines the type of port forwarding
@@ -76,9 +83,16 @@ type Model struct {
 	showHidden     bool // when true, hidden-tagged hosts are shown
 	searchMode     bool
 	deleteMode     bool
-	deleteHost     *config.SSHHost // Host to be deleted (with line number for precise targeting)
+	deleteHost     *config.SSHHost  // Host to be deleted (with line number for precise targeting)
+	deleteHosts    []config.SSHHost // Marked hosts to be deleted, when deleting in bulk
+	marked         map[string]bool  // Hosts marked for bulk actions, by name; kept across searches
+	multiplexed    map[string]bool  // Hosts with a running ControlMaster, by name
 	historyManager *history.HistoryManager
 	pingManager    *connectivity.PingManager
+	pingResults    <-chan *connectivity.HostPingResult // Results of the running ping sweep, if any
+	pingCancel     context.CancelFunc
+	monitorMode    bool // when true, hosts are re-pinged on an interval
+	monitorGen     int  // incremented on each toggle to drop ticks from a previous monitor session
 	sortMode       SortMode
 	configFile     string // Path to the SSH config file
 
@@ -98,6 +112,11 @@ type Model struct {
 	portForwardForm  *portForwardModel
 	helpForm         *helpModel
 	fileSelectorForm *fileSelectorModel
+	bulkForm         *bulkFormModel
+	broadcast        *broadcastModel
+	sftpBrowser      *sftpBrowserModel
+	tunnelsPanel     *tunnelsModel
+	snippetsPicker   *snippetsModel
 
 	// Terminal size and styles
 	width  int
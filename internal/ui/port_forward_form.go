@@ -1,11 +1,14 @@
 package ui
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 
+	"github.com/Gu1llaum-3/sshm/internal/config"
 	"github.com/Gu1llaum-3/sshm/internal/history"
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
@@ -31,12 +34,30 @@ type portForwardModel struct {
 	height         int
 	configFile     string
 	historyManager *history.HistoryManager
+
+	// Forwards launched together, possibly loaded from or saved as a named profile
+	forwards     []tunnel.Forward
+	profiles     []tunnel.Profile
+	profileIndex int    // index of the loaded profile in profiles, -1 if none
+	profileName  string // name of the loaded or saved profile
+	naming       bool   // the profile name prompt is shown
+	nameInput    textinput.Model
+	status       string
+
+	// Warnings about the forwards, confirmed by submitting the same forwards again
+	warning   string
+	confirmed string
 }
 
-// portForwardSubmitMsg is sent when the port forward form is submitted
+// portForwardSubmitMsg is sent when the port forward form is submitted. With
+// background set, the forwards are started as a background tunnel instead of
+// running sshArgs in the terminal.
 type portForwardSubmitMsg struct {
-	err     error
-	sshArgs []string
+	err        error
+	sshArgs    []string
+	forwards   []tunnel.Forward
+	tunnelID   string // name of the background tunnel, when a profile is loaded
+	background bool
 }
 
 // portForwardCancelMsg is sent when the port forward form is cancelled
@@ -77,6 +98,11 @@ func NewPortForwardForm(hostName string, styles Styles, width, height int, confi
 	inputs[pfBindAddressInput].CharLimit = 50
 	inputs[pfBindAddressInput].Width = 30
 
+	nameInput := textinput.New()
+	nameInput.Placeholder = "db, grafana..."
+	nameInput.CharLimit = 40
+	nameInput.Width = 30
+
 	pf := &portForwardModel{
 		inputs:         inputs,
 		focused:        0,
@@ -87,8 +113,13 @@ func NewPortForwardForm(hostName string, styles Styles, width, height int, confi
 		height:         height,
 		configFile:     configFile,
 		historyManager: historyManager,
+		profileIndex:   -1,
+		nameInput:      nameInput,
 	}
 
+	// Load the saved forward profiles of the host
+	pf.loadProfiles()
+
 	// Load previous port forwarding configuration if available
 	pf.loadPreviousConfig()
 
@@ -107,10 +138,42 @@ func (m *portForwardModel) Update(msg tea.Msg) (*portForwardModel, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.naming {
+			return m.updateNaming(msg)
+		}
+
 		switch msg.String() {
 		case "esc", "ctrl+c":
 			return m, func() tea.Msg { return portForwardCancelMsg{} }
 
+		case "ctrl+n":
+			// Add the forward in the fields to the list
+			m.addForward()
+			return m, nil
+
+		case "ctrl+x":
+			// Remove the last forward of the list
+			if len(m.forwards) > 0 {
+				removed := m.forwards[len(m.forwards)-1]
+				m.forwards = m.forwards[:len(m.forwards)-1]
+				m.status = fmt.Sprintf("Removed %s", removed)
+			}
+			return m, nil
+
+		case "ctrl+p":
+			// Load the next saved profile
+			m.loadNextProfile()
+			return m, nil
+
+		case "ctrl+s":
+			// Save the listed forwards as a profile
+			return m, m.startNaming()
+
+		case "ctrl+o":
+			// Write the forwards to the host block of the SSH config
+			m.saveToConfig()
+			return m, nil
+
 		case "enter":
 			nextField := m.getNextValidField(m.focused)
 			if nextField != -1 {
@@ -121,9 +184,13 @@ func (m *portForwardModel) Update(msg tea.Msg) (*portForwardModel, tea.Cmd) {
 				return m, textinput.Blink
 			} else {
 				// Submit form
-				return m, m.submitForm()
+				return m, m.submit(false)
 			}
 
+		case "ctrl+t":
+			// Start the forward as a background tunnel
+			return m, m.submit(true)
+
 		case "shift+tab", "up":
 			prevField := m.getPrevValidField(m.focused)
 			if prevField != -1 {
@@ -228,6 +295,9 @@ func (m *portForwardModel) View() string {
 	if m.err != "" {
 		sections = append(sections, m.styles.Error.Render("Error: "+m.err))
 	}
+	if m.warning != "" {
+		sections = append(sections, m.styles.Error.Render("Warning: "+m.warning))
+	}
 
 	// Form fields
 	var fields []string
@@ -345,9 +415,15 @@ func (m *portForwardModel) View() string {
 	formContent := lipgloss.JoinVertical(lipgloss.Left, fields...)
 	sections = append(sections, formContent)
 
+	// Forwards launched together and saved profiles
+	sections = append(sections, m.profileView())
+
 	// Help text
-	helpText := " Tab/↓: next field • Shift+Tab/↑: previous field • Enter: connect • Esc: cancel"
+	helpText := " Tab/↓: next field • Shift+Tab/↑: previous field • Enter: connect • Ctrl+T: run in background • Esc: cancel"
 	sections = append(sections, m.styles.HelpText.Render(helpText))
+	profileHelp := " Ctrl+N: add forward to list • Ctrl+X: remove last • Ctrl+P: load next profile • Ctrl+S: save list as profile"
+	sections = append(sections, m.styles.HelpText.Render(profileHelp))
+	sections = append(sections, m.styles.HelpText.Render(" Ctrl+O: save the forwards to the host in the SSH config, to come up with every connection"))
 
 	// Join all sections
 	content := lipgloss.JoinVertical(lipgloss.Left, sections...)
@@ -362,23 +438,95 @@ func (m *portForwardModel) View() string {
 	)
 }
 
-func (m *portForwardModel) submitForm() tea.Cmd {
-	return func() tea.Msg {
-		// Validate inputs
-		localPort := strings.TrimSpace(m.inputs[pfLocalPortInput].Value())
-		if localPort == "" {
-			return portForwardSubmitMsg{err: fmt.Errorf("port is required"), sshArgs: nil}
+// submit checks the forwards to launch and returns the command launching them,
+// or nil when the form shows an error or warnings to confirm first
+func (m *portForwardModel) submit(background bool) tea.Cmd {
+	forwards, fieldsUsed, err := m.collectForwards()
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	if !m.checkForwards(forwards, fieldsUsed) {
+		return nil
+	}
+	return m.submitForm(forwards, fieldsUsed, background)
+}
+
+// collectForwards returns the listed forwards and the one in the fields, which
+// is used when a port is filled in or the list is empty
+func (m *portForwardModel) collectForwards() ([]tunnel.Forward, bool, error) {
+	forwards := append([]tunnel.Forward(nil), m.forwards...)
+	localPort := strings.TrimSpace(m.inputs[pfLocalPortInput].Value())
+	if localPort == "" && len(forwards) > 0 {
+		return forwards, false, nil
+	}
+	forward, err := m.fieldsForward()
+	if err != nil {
+		return nil, false, err
+	}
+	return append(forwards, forward), true, nil
+}
+
+// checkForwards checks that the local ports of forwards are free, offering the
+// next free port when one is taken, and asks to confirm risky forwards by
+// submitting again. It reports whether the forwards can be launched.
+func (m *portForwardModel) checkForwards(forwards []tunnel.Forward, fieldsUsed bool) bool {
+	m.warning = ""
+	for i, forward := range forwards {
+		err := tunnel.CheckPort(forward)
+		if err == nil {
+			continue
+		}
+		var portErr *tunnel.PortError
+		if errors.As(err, &portErr) && portErr.Suggested != "" {
+			// Offer the next free port in place of the taken one
+			if fieldsUsed && i == len(forwards)-1 {
+				m.inputs[pfLocalPortInput].SetValue(portErr.Suggested)
+			} else {
+				m.forwards[i].LocalPort = portErr.Suggested
+			}
+			m.err = fmt.Sprintf("Port %s is in use, changed to the next free port %s: submit again to use it", forward.LocalPort, portErr.Suggested)
+		} else {
+			m.err = err.Error()
 		}
+		return false
+	}
 
-		// Validate port number
-		if _, err := strconv.Atoi(localPort); err != nil {
-			return portForwardSubmitMsg{err: fmt.Errorf("invalid port number"), sshArgs: nil}
+	var warnings []string
+	var specs []string
+	for _, forward := range forwards {
+		warnings = append(warnings, tunnel.Warnings(forward)...)
+		specs = append(specs, forward.Flag()+" "+forward.Spec())
+	}
+	if len(warnings) > 0 {
+		key := strings.Join(specs, " ")
+		if m.confirmed != key {
+			m.confirmed = key
+			m.err = ""
+			m.warning = strings.Join(warnings, "\n") + "\nSubmit again to continue anyway"
+			return false
 		}
+	}
+	return true
+}
 
-		// Get form values for saving to history
-		remoteHost := strings.TrimSpace(m.inputs[pfRemoteHostInput].Value())
-		remotePort := strings.TrimSpace(m.inputs[pfRemotePortInput].Value())
-		bindAddress := strings.TrimSpace(m.inputs[pfBindAddressInput].Value())
+func (m *portForwardModel) submitForm(forwards []tunnel.Forward, fieldsUsed, background bool) tea.Cmd {
+	return func() tea.Msg {
+		// Save port forwarding configuration to history
+		if fieldsUsed && m.historyManager != nil {
+			forward := forwards[len(forwards)-1]
+			if err := m.historyManager.RecordPortForwarding(
+				m.hostName,
+				forward.Type,
+				forward.LocalPort,
+				forward.RemoteHost,
+				forward.RemotePort,
+				forward.BindAddress,
+			); err != nil {
+				// Log the error but don't fail the connection
+				// In a production environment, you might want to handle this differently
+			}
+		}
 
 		// Build SSH command with port forwarding
 		var sshArgs []string
@@ -389,87 +537,229 @@ func (m *portForwardModel) submitForm() tea.Cmd {
 		}
 
 		// Add forwarding arguments
-		var forwardTypeStr string
-		switch m.forwardType {
-		case LocalForward:
-			forwardTypeStr = "local"
-			if remoteHost == "" {
-				remoteHost = "localhost"
-			}
-			if remotePort == "" {
-				return portForwardSubmitMsg{err: fmt.Errorf("remote port is required for local forwarding"), sshArgs: nil}
-			}
+		for _, forward := range forwards {
+			sshArgs = append(sshArgs, forward.Args()...)
+		}
 
-			// Validate remote port
-			if _, err := strconv.Atoi(remotePort); err != nil {
-				return portForwardSubmitMsg{err: fmt.Errorf("invalid remote port number"), sshArgs: nil}
-			}
+		// Add hostname
+		sshArgs = append(sshArgs, m.hostName)
 
-			// Build -L argument
-			var forwardArg string
-			if bindAddress != "" {
-				forwardArg = fmt.Sprintf("%s:%s:%s:%s", bindAddress, localPort, remoteHost, remotePort)
-			} else {
-				forwardArg = fmt.Sprintf("%s:%s:%s", localPort, remoteHost, remotePort)
-			}
-			sshArgs = append(sshArgs, "-L", forwardArg)
+		// A loaded profile names the background tunnel
+		var tunnelID string
+		if m.profileName != "" {
+			tunnelID = m.hostName + "-" + m.profileName
+		}
 
-		case RemoteForward:
-			forwardTypeStr = "remote"
-			if remoteHost == "" {
-				remoteHost = "localhost"
-			}
-			if remotePort == "" {
-				return portForwardSubmitMsg{err: fmt.Errorf("local port is required for remote forwarding"), sshArgs: nil}
-			}
+		// Return success with the SSH command to execute
+		return portForwardSubmitMsg{err: nil, sshArgs: sshArgs, forwards: forwards, tunnelID: tunnelID, background: background}
+	}
+}
 
-			// Validate local port
-			if _, err := strconv.Atoi(remotePort); err != nil {
-				return portForwardSubmitMsg{err: fmt.Errorf("invalid local port number"), sshArgs: nil}
-			}
+// fieldsForward validates the form fields and returns the forward they describe
+func (m *portForwardModel) fieldsForward() (tunnel.Forward, error) {
+	// Validate inputs
+	localPort := strings.TrimSpace(m.inputs[pfLocalPortInput].Value())
+	if localPort == "" {
+		return tunnel.Forward{}, fmt.Errorf("port is required")
+	}
 
-			// Build -R argument (note: localPort is actually the remote port in this context)
-			var forwardArg string
-			if bindAddress != "" {
-				forwardArg = fmt.Sprintf("%s:%s:%s:%s", bindAddress, localPort, remoteHost, remotePort)
-			} else {
-				forwardArg = fmt.Sprintf("%s:%s:%s", localPort, remoteHost, remotePort)
-			}
-			sshArgs = append(sshArgs, "-R", forwardArg)
-
-		case DynamicForward:
-			forwardTypeStr = "dynamic"
-			// Build -D argument
-			var forwardArg string
-			if bindAddress != "" {
-				forwardArg = fmt.Sprintf("%s:%s", bindAddress, localPort)
-			} else {
-				forwardArg = localPort
-			}
-			sshArgs = append(sshArgs, "-D", forwardArg)
+	// Validate port number
+	if _, err := strconv.Atoi(localPort); err != nil {
+		return tunnel.Forward{}, fmt.Errorf("invalid port number")
+	}
+
+	remoteHost := strings.TrimSpace(m.inputs[pfRemoteHostInput].Value())
+	remotePort := strings.TrimSpace(m.inputs[pfRemotePortInput].Value())
+	bindAddress := strings.TrimSpace(m.inputs[pfBindAddressInput].Value())
+
+	forward := tunnel.Forward{LocalPort: localPort, BindAddress: bindAddress}
+	switch m.forwardType {
+	case LocalForward:
+		forward.Type = tunnel.Local
+		if remoteHost == "" {
+			remoteHost = "localhost"
+		}
+		if remotePort == "" {
+			return tunnel.Forward{}, fmt.Errorf("remote port is required for local forwarding")
 		}
 
-		// Save port forwarding configuration to history
-		if m.historyManager != nil {
-			if err := m.historyManager.RecordPortForwarding(
-				m.hostName,
-				forwardTypeStr,
-				localPort,
-				remoteHost,
-				remotePort,
-				bindAddress,
-			); err != nil {
-				// Log the error but don't fail the connection
-				// In a production environment, you might want to handle this differently
-			}
+		// Validate remote port
+		if _, err := strconv.Atoi(remotePort); err != nil {
+			return tunnel.Forward{}, fmt.Errorf("invalid remote port number")
 		}
+		forward.RemoteHost, forward.RemotePort = remoteHost, remotePort
 
-		// Add hostname
-		sshArgs = append(sshArgs, m.hostName)
+	case RemoteForward:
+		forward.Type = tunnel.Remote
+		if remoteHost == "" {
+			remoteHost = "localhost"
+		}
+		if remotePort == "" {
+			return tunnel.Forward{}, fmt.Errorf("local port is required for remote forwarding")
+		}
 
-		// Return success with the SSH command to execute
-		return portForwardSubmitMsg{err: nil, sshArgs: sshArgs}
+		// Validate local port (localPort is actually the remote port in this context)
+		if _, err := strconv.Atoi(remotePort); err != nil {
+			return tunnel.Forward{}, fmt.Errorf("invalid local port number")
+		}
+		forward.RemoteHost, forward.RemotePort = remoteHost, remotePort
+
+	case DynamicForward:
+		forward.Type = tunnel.Dynamic
 	}
+
+	return forward, forward.Validate()
+}
+
+// addForward moves the forward in the fields to the list of forwards
+func (m *portForwardModel) addForward() {
+	forward, err := m.fieldsForward()
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.forwards = append(m.forwards, forward)
+	m.err = ""
+	m.status = fmt.Sprintf("Added %s", forward)
+	m.clearPorts()
+}
+
+// clearPorts empties the port fields, so that the fields add no forward on submit
+func (m *portForwardModel) clearPorts() {
+	m.inputs[pfLocalPortInput].SetValue("")
+	m.inputs[pfRemotePortInput].SetValue("")
+}
+
+// loadNextProfile replaces the list of forwards with those of the next saved profile
+func (m *portForwardModel) loadNextProfile() {
+	if len(m.profiles) == 0 {
+		m.err = "No saved profiles for this host, add forwards with Ctrl+N and save them with Ctrl+S"
+		return
+	}
+	m.profileIndex = (m.profileIndex + 1) % len(m.profiles)
+	profile := m.profiles[m.profileIndex]
+	m.profileName = profile.Name
+	m.forwards = append([]tunnel.Forward(nil), profile.Forwards...)
+	m.err = ""
+	m.status = fmt.Sprintf("Loaded profile %s", profile.Name)
+	m.clearPorts()
+}
+
+// startNaming asks for the name of the profile to save the listed forwards as
+func (m *portForwardModel) startNaming() tea.Cmd {
+	if len(m.forwards) == 0 {
+		// Save the forward in the fields on its own
+		m.addForward()
+		if len(m.forwards) == 0 {
+			return nil
+		}
+	}
+	m.naming = true
+	m.err = ""
+	m.nameInput.SetValue(m.profileName)
+	m.nameInput.CursorEnd()
+	m.inputs[m.focused].Blur()
+	return m.nameInput.Focus()
+}
+
+// updateNaming handles the keys of the profile name prompt
+func (m *portForwardModel) updateNaming(msg tea.KeyMsg) (*portForwardModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.naming = false
+		m.nameInput.Blur()
+		return m, m.inputs[m.focused].Focus()
+
+	case "enter":
+		profile := tunnel.Profile{Name: strings.TrimSpace(m.nameInput.Value()), Forwards: m.forwards}
+		if err := tunnel.SaveProfile(m.hostName, profile); err != nil {
+			m.err = err.Error()
+			return m, nil
+		}
+		m.naming = false
+		m.nameInput.Blur()
+		m.profileName = profile.Name
+		m.status = fmt.Sprintf("Saved profile %s", profile.Name)
+		m.loadProfiles()
+		return m, m.inputs[m.focused].Focus()
+	}
+
+	var cmd tea.Cmd
+	m.nameInput, cmd = m.nameInput.Update(msg)
+	return m, cmd
+}
+
+// saveToConfig writes the listed forwards and the one in the fields as
+// LocalForward, RemoteForward or DynamicForward directives of the host, so
+// that they come up with every connection
+func (m *portForwardModel) saveToConfig() {
+	forwards, _, err := m.collectForwards()
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+
+	for i, forward := range forwards {
+		if err := config.AddForwardDirective(m.hostName, forward.Directive(), m.configFile); err != nil {
+			m.err = fmt.Sprintf("Saved %d of %d forwards to the SSH config: %v", i, len(forwards), err)
+			return
+		}
+	}
+	m.err = ""
+	m.status = fmt.Sprintf("Saved %d forwards to the SSH config of %s", len(forwards), m.hostName)
+}
+
+// loadProfiles reads the saved profiles of the host, keeping the loaded one selected
+func (m *portForwardModel) loadProfiles() {
+	profiles, err := tunnel.LoadProfiles(m.hostName)
+	if err != nil {
+		m.err = fmt.Sprintf("Could not read forward profiles: %v", err)
+		return
+	}
+	m.profiles = profiles
+	m.profileIndex = -1
+	for i, p := range profiles {
+		if p.Name == m.profileName {
+			m.profileIndex = i
+		}
+	}
+}
+
+// profileView shows the list of forwards, the saved profiles and the name prompt
+func (m *portForwardModel) profileView() string {
+	var lines []string
+
+	title := "Forwards:"
+	if m.profileName != "" {
+		title = fmt.Sprintf("Forwards (profile %s):", m.profileName)
+	}
+	lines = append(lines, m.styles.Label.Render(title))
+	if len(m.forwards) == 0 {
+		lines = append(lines, m.styles.HelpText.Render("  only the forward above"))
+	}
+	for _, forward := range m.forwards {
+		lines = append(lines, "  "+forward.String())
+	}
+
+	if len(m.profiles) > 0 {
+		var names []string
+		for i, p := range m.profiles {
+			if i == m.profileIndex {
+				names = append(names, m.styles.FocusedLabel.Render(p.Name))
+			} else {
+				names = append(names, p.Name)
+			}
+		}
+		lines = append(lines, m.styles.HelpText.Render("Profiles: ")+strings.Join(names, ", "))
+	}
+
+	if m.naming {
+		lines = append(lines, m.styles.FocusedLabel.Render("Profile name: ")+m.nameInput.View())
+	} else if m.status != "" {
+		lines = append(lines, m.styles.HelpText.Render(m.status))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
 }
 
 // getValidFields returns the list of valid field indices for the current forward type
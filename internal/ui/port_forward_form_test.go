@@ -0,0 +1,113 @@
+package ui
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPortForwardFormBackground(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("APPDATA", t.TempDir())
+
+	form := NewPortForwardForm("db", NewStyles(120), 120, 40, "", nil)
+	form.inputs[pfLocalPortInput].SetValue("5432")
+	form.inputs[pfRemotePortInput].SetValue("5432")
+
+	form, cmd := form.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	msg, ok := cmd().(portForwardSubmitMsg)
+	if !ok || msg.err != nil || !msg.background {
+		t.Fatalf("Expected ctrl+t to submit the form for a background tunnel, got %+v", msg)
+	}
+	want := tunnel.Forward{Type: tunnel.Local, LocalPort: "5432", RemoteHost: "localhost", RemotePort: "5432"}
+	if len(msg.forwards) != 1 || msg.forwards[0] != want {
+		t.Errorf("Expected forward %+v, got %+v", want, msg.forwards)
+	}
+}
+
+func TestPortForwardFormProfiles(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("APPDATA", t.TempDir())
+
+	// List a local forward and a SOCKS proxy, then save them as the "db" profile
+	form := NewPortForwardForm("prod", NewStyles(120), 120, 40, "", nil)
+	form.inputs[pfLocalPortInput].SetValue("5432")
+	form.inputs[pfRemotePortInput].SetValue("5432")
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyRight})
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyRight})
+	form.inputs[pfLocalPortInput].SetValue("1080")
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyCtrlN})
+	if len(form.forwards) != 2 || form.forwards[1].Type != tunnel.Dynamic {
+		t.Fatalf("Expected a local and a dynamic forward in the list, got %+v (%s)", form.forwards, form.err)
+	}
+
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	form.nameInput.SetValue("db")
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if form.naming || form.err != "" {
+		t.Fatalf("Expected the profile to be saved, got %q", form.err)
+	}
+
+	// A new form loads the profile and launches its forwards together
+	form = NewPortForwardForm("prod", NewStyles(120), 120, 40, "", nil)
+	if len(form.profiles) != 1 {
+		t.Fatalf("Expected the saved profile to be listed, got %+v", form.profiles)
+	}
+	form, _ = form.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	form, cmd := form.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	msg := cmd().(portForwardSubmitMsg)
+	if msg.err != nil || len(msg.forwards) != 2 || msg.tunnelID != "prod-db" {
+		t.Errorf("Expected the 2 forwards of the profile in tunnel prod-db, got %+v", msg)
+	}
+	if args := strings.Join(msg.sshArgs, " "); args != "-L 5432:localhost:5432 -D 1080 prod" {
+		t.Errorf("Unexpected ssh arguments %q", args)
+	}
+}
+
+func TestPortForwardFormChecksPorts(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("APPDATA", t.TempDir())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	taken := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	// A taken port is replaced by the next free one, launched on the next submit
+	form := NewPortForwardForm("db", NewStyles(120), 120, 40, "", nil)
+	form.inputs[pfLocalPortInput].SetValue(taken)
+	form.inputs[pfRemotePortInput].SetValue("5432")
+	form, cmd := form.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	if cmd != nil || !strings.Contains(form.err, "in use") {
+		t.Fatalf("Expected the taken port to be reported, got %q", form.err)
+	}
+	suggested := form.inputs[pfLocalPortInput].Value()
+	if suggested == taken {
+		t.Fatal("Expected the next free port to be offered")
+	}
+	form, cmd = form.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	if cmd == nil {
+		t.Fatalf("Expected the free port to be launched, got %q", form.err)
+	}
+	if msg := cmd().(portForwardSubmitMsg); msg.forwards[0].LocalPort != suggested {
+		t.Errorf("Expected port %s, got %+v", suggested, msg.forwards)
+	}
+
+	// Exposing a forward beyond loopback needs a second submit
+	form.inputs[pfBindAddressInput].SetValue("0.0.0.0")
+	form, cmd = form.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	if cmd != nil || !strings.Contains(form.warning, "exposes port") {
+		t.Fatalf("Expected a warning about the bind address, got %q", form.warning)
+	}
+	_, cmd = form.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	if cmd == nil {
+		t.Error("Expected the forward to be launched once the warning was confirmed")
+	}
+}
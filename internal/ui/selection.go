@@ -0,0 +1,87 @@
+package ui
+
+import (
+	"sort"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+)
+
+// markedIndicator is shown before the status of marked hosts
+const markedIndicator = "✓"
+
+// isMarked reports whether a host is marked for bulk actions
+func (m *Model) isMarked(hostName string) bool {
+	return m.marked[hostName]
+}
+
+// toggleMark marks or unmarks a host
+func (m *Model) toggleMark(hostName string) {
+	if m.marked == nil {
+		m.marked = make(map[string]bool)
+	}
+	if m.marked[hostName] {
+		delete(m.marked, hostName)
+	} else {
+		m.marked[hostName] = true
+	}
+}
+
+// markFiltered marks every host matching the current search
+func (m *Model) markFiltered() {
+	if m.marked == nil {
+		m.marked = make(map[string]bool)
+	}
+	for _, host := range m.filteredHosts {
+		m.marked[host.Name] = true
+	}
+}
+
+// invertFiltered inverts the marks of the hosts matching the current search,
+// leaving marked hosts outside the search untouched
+func (m *Model) invertFiltered() {
+	for _, host := range m.filteredHosts {
+		m.toggleMark(host.Name)
+	}
+}
+
+// clearMarks unmarks all hosts
+func (m *Model) clearMarks() {
+	m.marked = nil
+}
+
+// markedHosts returns the visible marked hosts, including those hidden by the search
+func (m *Model) markedHosts() []config.SSHHost {
+	var hosts []config.SSHHost
+	for _, host := range m.hosts {
+		if m.marked[host.Name] {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// pruneMarks drops the marks of hosts that no longer exist
+func (m *Model) pruneMarks() {
+	existing := make(map[string]bool, len(m.allHosts))
+	for _, host := range m.allHosts {
+		existing[host.Name] = true
+	}
+	for name := range m.marked {
+		if !existing[name] {
+			delete(m.marked, name)
+		}
+	}
+}
+
+// sortForDeletion orders hosts so that deleting them one by one never shifts the
+// line numbers of the hosts still to delete in the same file
+func sortForDeletion(hosts []config.SSHHost) []config.SSHHost {
+	sorted := append([]config.SSHHost(nil), hosts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].SourceFile != sorted[j].SourceFile {
+			return sorted[i].SourceFile < sorted[j].SourceFile
+		}
+		return sorted[i].LineNumber > sorted[j].LineNumber
+	})
+	return sorted
+}
@@ -0,0 +1,74 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestMarksSurviveFiltering(t *testing.T) {
+	m := createTestModel()
+
+	// Mark the first host with space
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	m = newModel.(Model)
+	if !m.isMarked("server1") {
+		t.Fatal("Expected 'server1' to be marked after pressing space")
+	}
+
+	// Filter to server2, then invert the marks of the matching hosts
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	m = newModel.(Model)
+	for _, char := range "server2" {
+		newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{char}})
+		m = newModel.(Model)
+	}
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(Model)
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("*")})
+	m = newModel.(Model)
+
+	// server1 is hidden by the search but stays marked
+	var names []string
+	for _, host := range m.markedHosts() {
+		names = append(names, host.Name)
+	}
+	if len(names) != 2 || names[0] != "server1" || names[1] != "server2" {
+		t.Errorf("Expected server1 and server2 to be marked, got %v", names)
+	}
+
+	// Marked rows show the indicator and still resolve to their host name
+	firstColumn := m.table.Rows()[0][0]
+	if !strings.HasPrefix(firstColumn, markedIndicator) {
+		t.Errorf("Expected marked row to start with %q, got %q", markedIndicator, firstColumn)
+	}
+	if name := extractHostNameFromTableRow(firstColumn); name != "server2" {
+		t.Errorf("Expected 'server2', got %q", name)
+	}
+}
+
+func TestEscClearsMarksCtrlCQuits(t *testing.T) {
+	m := createTestModel()
+	appConfig := config.GetDefaultAppConfig()
+	m.appConfig = &appConfig
+	m.toggleMark("server1")
+
+	// esc clears the marks first
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newModel.(Model)
+	if cmd != nil || len(m.marked) != 0 {
+		t.Errorf("Expected esc to clear the marks without quitting, got %d marks", len(m.marked))
+	}
+
+	// ctrl+c quits even with marked hosts
+	m.toggleMark("server1")
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if cmd == nil {
+		t.Fatal("Expected ctrl+c to quit")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("Expected ctrl+c to quit with marked hosts")
+	}
+}
@@ -0,0 +1,626 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sftpPane is one side of the file browser
+type sftpPane struct {
+	title   string
+	fs      fileSystem
+	dir     string
+	entries []os.FileInfo
+	cursor  int
+	offset  int // first entry shown, for scrolling
+	loading bool
+	err     string
+}
+
+// load returns the command listing the pane's directory in the background, so
+// that a slow remote does not block the interface. Once listed, name is
+// selected if present.
+func (p *sftpPane) load(name string) tea.Cmd {
+	p.loading = true
+	fs, dir := p.fs, p.dir
+	return func() tea.Msg {
+		entries, err := fs.ReadDir(dir)
+		return sftpListedMsg{pane: p, dir: dir, entries: entries, err: err, name: name}
+	}
+}
+
+// listed shows a listing of the pane's directory, keeping the cursor in range.
+// Listings of a directory the pane has since left are ignored.
+func (p *sftpPane) listed(msg sftpListedMsg) {
+	if msg.dir != p.dir {
+		return
+	}
+	p.loading = false
+	if msg.err != nil {
+		p.err = msg.err.Error()
+		p.entries = nil
+	} else {
+		p.err = ""
+		sortEntries(msg.entries)
+		p.entries = msg.entries
+	}
+	p.cursor = max(0, min(p.cursor, len(p.entries)-1))
+	for i, entry := range p.entries {
+		if entry.Name() == msg.name {
+			p.cursor = i
+		}
+	}
+}
+
+// chdir changes the pane's directory, selecting name in the new listing if present
+func (p *sftpPane) chdir(dir, name string) tea.Cmd {
+	p.dir = dir
+	p.entries = nil
+	p.err = ""
+	p.cursor = 0
+	p.offset = 0
+	return p.load(name)
+}
+
+// selected returns the entry under the cursor, if any
+func (p *sftpPane) selected() os.FileInfo {
+	if p.cursor < 0 || p.cursor >= len(p.entries) {
+		return nil
+	}
+	return p.entries[p.cursor]
+}
+
+// selectedPath returns the path of the entry under the cursor
+func (p *sftpPane) selectedPath() string {
+	if entry := p.selected(); entry != nil {
+		return p.fs.Join(p.dir, entry.Name())
+	}
+	return ""
+}
+
+type sftpBrowserModel struct {
+	hostName   string
+	configFile string
+	session    *sftpSession
+	panes      [2]*sftpPane // local, remote
+	active     int
+	renaming   bool
+	deleting   bool
+	input      textinput.Model
+	busy       string // operation in progress
+	status     string
+	err        string
+	styles     Styles
+	width      int
+	height     int
+}
+
+// Messages for communication with parent model
+type sftpConnectedMsg struct {
+	session *sftpSession
+	err     error
+}
+
+// sftpListedMsg carries the listing of a pane's directory
+type sftpListedMsg struct {
+	pane    *sftpPane
+	dir     string
+	entries []os.FileInfo
+	err     error
+	name    string // Entry to select, if present
+}
+
+type sftpOpDoneMsg struct {
+	status string
+	err    error
+}
+
+// sftpEditReadyMsg is sent when a remote file has been downloaded for editing
+type sftpEditReadyMsg struct {
+	remotePath string
+	localPath  string
+	before     os.FileInfo
+	err        error
+}
+
+// sftpEditedMsg is sent when the editor exits
+type sftpEditedMsg struct {
+	remotePath string
+	localPath  string
+	before     os.FileInfo
+	err        error
+}
+
+type sftpBrowserCloseMsg struct{}
+
+// NewSFTPBrowser creates a local/remote file browser for a host; the connection
+// is opened by the command returned by Init
+func NewSFTPBrowser(hostName string, styles Styles, width, height int, configFile string) *sftpBrowserModel {
+	input := textinput.New()
+	input.CharLimit = 255
+
+	localDir, err := os.Getwd()
+	if err != nil {
+		localDir, _ = os.UserHomeDir()
+	}
+	local := &sftpPane{title: "Local", fs: localFS{}, dir: localDir}
+
+	return &sftpBrowserModel{
+		hostName:   hostName,
+		configFile: configFile,
+		panes:      [2]*sftpPane{local, nil},
+		active:     1,
+		input:      input,
+		busy:       fmt.Sprintf("Connecting to %s...", hostName),
+		styles:     styles,
+		width:      width,
+		height:     height,
+	}
+}
+
+func (m *sftpBrowserModel) Init() tea.Cmd {
+	hostName, configFile := m.hostName, m.configFile
+	return tea.Batch(m.panes[0].load(""), func() tea.Msg {
+		session, err := openSFTPSession(hostName, configFile)
+		return sftpConnectedMsg{session: session, err: err}
+	})
+}
+
+// Close ends the SFTP session
+func (m *sftpBrowserModel) Close() {
+	if m.session != nil {
+		m.session.Close()
+		m.session = nil
+	}
+}
+
+// connected shows the remote side once the session is open, and starts listing it
+func (m *sftpBrowserModel) connected(session *sftpSession) tea.Cmd {
+	m.session = session
+	m.busy = ""
+
+	remoteDir, err := session.client.Getwd()
+	if err != nil {
+		remoteDir = "."
+	}
+	remote := &sftpPane{title: m.hostName, fs: remoteFS{session.client}, dir: remoteDir}
+	m.panes[1] = remote
+	return remote.load("")
+}
+
+// reload lists both panes again after a change
+func (m *sftpBrowserModel) reload() tea.Cmd {
+	var cmds []tea.Cmd
+	for _, pane := range m.panes {
+		if pane != nil {
+			cmds = append(cmds, pane.load(""))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m *sftpBrowserModel) Update(msg tea.Msg) (*sftpBrowserModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+		m.height = msg.Height
+		m.styles = NewStyles(m.width)
+		return m, nil
+
+	case sftpConnectedMsg:
+		if msg.err != nil {
+			m.busy = ""
+			m.err = fmt.Sprintf("Could not open an SFTP session: %v", msg.err)
+			return m, nil
+		}
+		return m, m.connected(msg.session)
+
+	case sftpListedMsg:
+		msg.pane.listed(msg)
+		m.scrollToCursor(msg.pane)
+		return m, nil
+
+	case sftpOpDoneMsg:
+		m.busy = ""
+		if msg.err != nil {
+			m.err = msg.err.Error()
+			m.status = ""
+		} else {
+			m.err = ""
+			m.status = msg.status
+		}
+		return m, m.reload()
+
+	case sftpEditReadyMsg:
+		if msg.err != nil {
+			m.busy = ""
+			m.err = msg.err.Error()
+			os.RemoveAll(filepath.Dir(msg.localPath))
+			return m, nil
+		}
+		m.busy = fmt.Sprintf("Editing %s...", msg.remotePath)
+		return m, tea.ExecProcess(editorCommand(msg.localPath), func(err error) tea.Msg {
+			return sftpEditedMsg{remotePath: msg.remotePath, localPath: msg.localPath, before: msg.before, err: err}
+		})
+
+	case sftpEditedMsg:
+		return m, m.uploadEdited(msg)
+
+	case tea.KeyMsg:
+		if m.renaming {
+			return m.updateRename(msg)
+		}
+		if m.deleting {
+			return m.updateDelete(msg)
+		}
+		return m.updateBrowse(msg)
+	}
+
+	return m, nil
+}
+
+func (m *sftpBrowserModel) updateBrowse(msg tea.KeyMsg) (*sftpBrowserModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q", "ctrl+c":
+		return m, func() tea.Msg { return sftpBrowserCloseMsg{} }
+	}
+
+	pane := m.panes[m.active]
+	if pane == nil {
+		// Still connecting, or the connection failed
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "tab", "shift+tab":
+		if m.panes[1-m.active] != nil {
+			m.active = 1 - m.active
+		}
+	case "up", "k":
+		if pane.cursor > 0 {
+			pane.cursor--
+		}
+	case "down", "j":
+		if pane.cursor < len(pane.entries)-1 {
+			pane.cursor++
+		}
+	case "pgup":
+		pane.cursor = max(0, pane.cursor-m.listHeight())
+	case "pgdown":
+		pane.cursor = max(0, min(len(pane.entries)-1, pane.cursor+m.listHeight()))
+	case "home", "g":
+		pane.cursor = 0
+	case "end", "G":
+		pane.cursor = max(0, len(pane.entries)-1)
+	case "enter", "right", "l":
+		if entry := pane.selected(); entry != nil && entry.IsDir() {
+			return m, pane.chdir(pane.selectedPath(), "")
+		}
+	case "backspace", "left", "h":
+		parent := pane.fs.Dir(pane.dir)
+		if parent != pane.dir {
+			return m, pane.chdir(parent, pane.fs.Base(pane.dir))
+		}
+	case "R":
+		return m, m.reload()
+	case "c", "f5":
+		if m.busy == "" {
+			return m, m.copySelected()
+		}
+	case "r":
+		if entry := pane.selected(); entry != nil && m.busy == "" {
+			m.renaming = true
+			m.input.SetValue(entry.Name())
+			m.input.CursorEnd()
+			m.input.Focus()
+			return m, textinput.Blink
+		}
+	case "d", "delete":
+		if pane.selected() != nil && m.busy == "" {
+			m.deleting = true
+		}
+	case "e":
+		if m.busy == "" {
+			return m, m.editSelected()
+		}
+	}
+
+	m.scrollToCursor(pane)
+	return m, nil
+}
+
+func (m *sftpBrowserModel) updateRename(msg tea.KeyMsg) (*sftpBrowserModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.renaming = false
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		m.renaming = false
+		m.input.Blur()
+		pane := m.panes[m.active]
+		newName := strings.TrimSpace(m.input.Value())
+		entry := pane.selected()
+		if entry == nil || newName == "" || newName == entry.Name() {
+			return m, nil
+		}
+		if strings.ContainsAny(newName, `/\`) {
+			m.err = "The new name cannot contain a path separator"
+			return m, nil
+		}
+		oldPath := pane.selectedPath()
+		newPath := pane.fs.Join(pane.dir, newName)
+		fs := pane.fs
+		m.busy = fmt.Sprintf("Renaming %s...", entry.Name())
+		return m, func() tea.Msg {
+			if err := fs.Rename(oldPath, newPath); err != nil {
+				return sftpOpDoneMsg{err: fmt.Errorf("rename failed: %w", err)}
+			}
+			return sftpOpDoneMsg{status: fmt.Sprintf("Renamed %s to %s", entry.Name(), newName)}
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m *sftpBrowserModel) updateDelete(msg tea.KeyMsg) (*sftpBrowserModel, tea.Cmd) {
+	m.deleting = false
+	if msg.String() != "y" && msg.String() != "Y" {
+		return m, nil
+	}
+
+	pane := m.panes[m.active]
+	entry := pane.selected()
+	if entry == nil {
+		return m, nil
+	}
+	target := pane.selectedPath()
+	fs := pane.fs
+	m.busy = fmt.Sprintf("Deleting %s...", entry.Name())
+	return m, func() tea.Msg {
+		if err := fs.RemoveAll(target); err != nil {
+			return sftpOpDoneMsg{err: fmt.Errorf("delete failed: %w", err)}
+		}
+		return sftpOpDoneMsg{status: fmt.Sprintf("Deleted %s", entry.Name())}
+	}
+}
+
+// copySelected downloads or uploads the selected entry to the other pane's directory
+func (m *sftpBrowserModel) copySelected() tea.Cmd {
+	src, dst := m.panes[m.active], m.panes[1-m.active]
+	entry := src.selected()
+	if entry == nil || dst == nil {
+		return nil
+	}
+
+	progress, verb := "Downloading", "Downloaded"
+	if m.active == 0 {
+		progress, verb = "Uploading", "Uploaded"
+	}
+	srcPath := src.selectedPath()
+	dstPath := dst.fs.Join(dst.dir, entry.Name())
+	srcFS, dstFS := src.fs, dst.fs
+
+	m.busy = fmt.Sprintf("%s %s...", progress, entry.Name())
+	m.err = ""
+	return func() tea.Msg {
+		start := time.Now()
+		n, err := copyFiles(srcFS, srcPath, dstFS, dstPath)
+		if err != nil {
+			return sftpOpDoneMsg{err: fmt.Errorf("copy of %s failed after %s: %w", entry.Name(), formatSize(n), err)}
+		}
+		return sftpOpDoneMsg{status: fmt.Sprintf("%s %s (%s in %s)", verb, entry.Name(), formatSize(n), time.Since(start).Round(time.Millisecond))}
+	}
+}
+
+// editSelected downloads the selected remote file to a temporary directory to open it in the editor
+func (m *sftpBrowserModel) editSelected() tea.Cmd {
+	pane := m.panes[m.active]
+	entry := pane.selected()
+	if m.active != 1 || entry == nil || entry.IsDir() {
+		m.err = "Select a remote file to edit"
+		return nil
+	}
+
+	remotePath := pane.selectedPath()
+	fs := pane.fs
+	m.busy = fmt.Sprintf("Downloading %s...", entry.Name())
+	m.err = ""
+	return func() tea.Msg {
+		dir, err := os.MkdirTemp("", "sshm-edit-")
+		if err != nil {
+			return sftpEditReadyMsg{remotePath: remotePath, err: err}
+		}
+		localPath := filepath.Join(dir, entry.Name())
+		if _, err := copyFile(fs, remotePath, localFS{}, localPath, 0600); err != nil {
+			return sftpEditReadyMsg{remotePath: remotePath, localPath: localPath, err: fmt.Errorf("download failed: %w", err)}
+		}
+		before, err := os.Stat(localPath)
+		return sftpEditReadyMsg{remotePath: remotePath, localPath: localPath, before: before, err: err}
+	}
+}
+
+// uploadEdited uploads a file back after editing, when it was saved
+func (m *sftpBrowserModel) uploadEdited(msg sftpEditedMsg) tea.Cmd {
+	tempDir := filepath.Dir(msg.localPath)
+	if msg.err != nil {
+		os.RemoveAll(tempDir)
+		m.busy = ""
+		m.err = fmt.Sprintf("Editor failed: %v", msg.err)
+		return nil
+	}
+
+	after, err := os.Stat(msg.localPath)
+	if err != nil || (after.ModTime().Equal(msg.before.ModTime()) && after.Size() == msg.before.Size()) {
+		os.RemoveAll(tempDir)
+		m.busy = ""
+		m.err = ""
+		m.status = fmt.Sprintf("%s not changed", msg.remotePath)
+		return nil
+	}
+
+	fs := m.panes[1].fs
+	m.busy = fmt.Sprintf("Uploading %s...", msg.remotePath)
+	return func() tea.Msg {
+		defer os.RemoveAll(tempDir)
+		// Write over the existing file so that it keeps its permissions and owner
+		in, err := os.Open(msg.localPath)
+		if err != nil {
+			return sftpOpDoneMsg{err: err}
+		}
+		defer in.Close()
+		out, err := fs.Create(msg.remotePath)
+		if err != nil {
+			return sftpOpDoneMsg{err: fmt.Errorf("upload failed: %w", err)}
+		}
+		_, err = in.WriteTo(out)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return sftpOpDoneMsg{err: fmt.Errorf("upload failed: %w", err)}
+		}
+		return sftpOpDoneMsg{status: fmt.Sprintf("Saved %s", msg.remotePath)}
+	}
+}
+
+// editorCommand returns the command opening a file in the user's editor
+func editorCommand(file string) *exec.Cmd {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+	// The editor may come with arguments, e.g. "code --wait"
+	fields := strings.Fields(editor)
+	return exec.Command(fields[0], append(fields[1:], file)...)
+}
+
+// listHeight returns the number of entries shown in each pane
+func (m *sftpBrowserModel) listHeight() int {
+	// Title, status and help lines; pane border, title and path lines
+	return max(3, m.height-10)
+}
+
+// scrollToCursor keeps the cursor of a pane on screen
+func (m *sftpBrowserModel) scrollToCursor(pane *sftpPane) {
+	height := m.listHeight()
+	if pane.cursor < pane.offset {
+		pane.offset = pane.cursor
+	}
+	if pane.cursor >= pane.offset+height {
+		pane.offset = pane.cursor - height + 1
+	}
+}
+
+func (m *sftpBrowserModel) View() string {
+	title := m.styles.FormTitle.Render(fmt.Sprintf("📂 Files: %s", m.hostName))
+
+	paneWidth := max(20, m.width/2-2)
+	views := make([]string, 2)
+	for i, pane := range m.panes {
+		views[i] = m.renderPane(pane, i == m.active, paneWidth)
+	}
+	panes := lipgloss.JoinHorizontal(lipgloss.Top, views...)
+
+	var status string
+	switch {
+	case m.renaming:
+		status = m.styles.FocusedLabel.Render("New name: ") + m.input.View()
+	case m.deleting:
+		name := m.panes[m.active].selected().Name()
+		status = m.styles.ErrorText.Render(fmt.Sprintf("Delete %s? (y/n)", name))
+	case m.busy != "":
+		status = m.styles.FocusedLabel.Render(m.busy)
+	case m.err != "":
+		status = m.styles.ErrorText.Render(m.err)
+	default:
+		status = m.styles.HelpText.Render(m.status)
+	}
+
+	help := m.styles.FormHelp.Render("tab: switch pane • enter/←: open/up • c: copy to other pane • e: edit remote file • r: rename • d: delete • R: refresh • esc: close")
+
+	return lipgloss.JoinVertical(lipgloss.Left, title, panes, status, help)
+}
+
+// renderPane draws the listing of one side of the browser
+func (m *sftpBrowserModel) renderPane(pane *sftpPane, active bool, width int) string {
+	borderColor := lipgloss.Color(SecondaryColor)
+	if active {
+		borderColor = lipgloss.Color(PrimaryColor)
+	}
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(borderColor).
+		Width(width)
+
+	height := m.listHeight()
+	if pane == nil {
+		lines := []string{m.styles.FocusedLabel.Render(m.hostName), ""}
+		if m.busy != "" && m.err == "" {
+			lines = append(lines, m.styles.HelpText.Render("Connecting..."))
+		} else {
+			lines = append(lines, m.styles.ErrorText.Render("Not connected"))
+		}
+		for len(lines) < height+2 {
+			lines = append(lines, "")
+		}
+		return box.Render(strings.Join(lines, "\n"))
+	}
+
+	lines := []string{
+		m.styles.FocusedLabel.Render(truncate(pane.title, width)),
+		m.styles.HelpText.Render(truncate(pane.dir, width)),
+	}
+	if pane.err != "" {
+		lines = append(lines, m.styles.ErrorText.Render(truncate(pane.err, width)))
+	}
+
+	sizeWidth := 10
+	nameWidth := max(1, width-sizeWidth-1)
+	end := min(len(pane.entries), pane.offset+height)
+	for i := pane.offset; i < end; i++ {
+		entry := pane.entries[i]
+		name := entry.Name()
+		size := formatSize(entry.Size())
+		if entry.IsDir() {
+			name += "/"
+			size = ""
+		}
+		line := fmt.Sprintf("%-*s %*s", nameWidth, truncate(name, nameWidth), sizeWidth, size)
+		if i == pane.cursor && active {
+			line = m.styles.Selected.Render(line)
+		} else if entry.IsDir() {
+			line = lipgloss.NewStyle().Foreground(lipgloss.Color(PrimaryColor)).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	if len(pane.entries) == 0 && pane.err == "" {
+		if pane.loading {
+			lines = append(lines, m.styles.HelpText.Render("Loading..."))
+		} else {
+			lines = append(lines, m.styles.HelpText.Render("(empty)"))
+		}
+	}
+	for len(lines) < height+2 {
+		lines = append(lines, "")
+	}
+
+	return box.Render(strings.Join(lines, "\n"))
+}
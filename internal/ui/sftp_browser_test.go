@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkg/sftp"
+)
+
+// newTestSFTPSession connects an SFTP client to an in-memory server
+func newTestSFTPSession(t *testing.T) *sftpSession {
+	t.Helper()
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+
+	server := sftp.NewRequestServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverReader, serverWriter}, sftp.InMemHandler())
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientReader, clientWriter)
+	if err != nil {
+		t.Fatalf("Failed to start SFTP client: %v", err)
+	}
+	session := &sftpSession{client: client, close: func() error {
+		// Ending the server's output lets the client stop reading
+		serverWriter.Close()
+		clientWriter.Close()
+		return client.Close()
+	}}
+	t.Cleanup(func() { session.Close() })
+	return session
+}
+
+// runSFTPCmd runs a browser command and the ones its messages lead to, as the program would
+func runSFTPCmd(browser *sftpBrowserModel, cmd tea.Cmd) *sftpBrowserModel {
+	if cmd == nil {
+		return browser
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		for _, cmd := range batch {
+			browser = runSFTPCmd(browser, cmd)
+		}
+		return browser
+	}
+	browser, cmd = browser.Update(msg)
+	return runSFTPCmd(browser, cmd)
+}
+
+func TestSFTPBrowserUploadAndDownload(t *testing.T) {
+	local := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(local, "app", "conf"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(local, "app", "conf", "app.yml"), []byte("port: 8080\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	browser := NewSFTPBrowser("server1", NewStyles(120), 120, 40, "")
+	browser = runSFTPCmd(browser, browser.panes[0].chdir(local, ""))
+	browser, cmd := browser.Update(sftpConnectedMsg{session: newTestSFTPSession(t)})
+	if browser.panes[1] == nil || !browser.panes[1].loading {
+		t.Fatal("Expected the remote pane to be shown, loading, once connected")
+	}
+	browser = runSFTPCmd(browser, cmd)
+	if browser.panes[1].loading {
+		t.Fatal("Expected the remote listing to be applied")
+	}
+
+	// Upload the app directory from the local pane
+	browser, _ = browser.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if browser.active != 0 || browser.panes[0].selected().Name() != "app" {
+		t.Fatalf("Expected the local pane to be active on 'app'")
+	}
+	browser, cmd = browser.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	browser = runSFTPCmd(browser, cmd)
+	if browser.err != "" {
+		t.Fatalf("Upload failed: %s", browser.err)
+	}
+
+	// Rename the uploaded directory on the remote side, then download it back
+	browser, _ = browser.Update(tea.KeyMsg{Type: tea.KeyTab})
+	browser, _ = browser.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	browser.input.SetValue("app-copy")
+	browser, cmd = browser.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	browser = runSFTPCmd(browser, cmd)
+	if entry := browser.panes[1].selected(); entry == nil || entry.Name() != "app-copy" {
+		t.Fatalf("Expected the remote directory to be renamed, got %v (%s)", entry, browser.err)
+	}
+
+	browser, cmd = browser.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("c")})
+	browser = runSFTPCmd(browser, cmd)
+	content, err := os.ReadFile(filepath.Join(local, "app-copy", "conf", "app.yml"))
+	if err != nil || string(content) != "port: 8080\n" {
+		t.Errorf("Expected the downloaded file to match, got %q (%v)", content, err)
+	}
+}
+
+func TestSFTPPaneIgnoresStaleListing(t *testing.T) {
+	first, second := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(first, "old.txt"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pane := &sftpPane{title: "Local", fs: localFS{}}
+	stale := pane.chdir(first, "")
+	current := pane.chdir(second, "")
+
+	// The listing of the directory left behind arrives last
+	pane.listed(current().(sftpListedMsg))
+	pane.listed(stale().(sftpListedMsg))
+	if pane.loading || len(pane.entries) != 0 {
+		t.Errorf("Expected the stale listing to be ignored, got %d entries", len(pane.entries))
+	}
+}
@@ -0,0 +1,218 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/sftp"
+)
+
+// sftpSession is an SFTP connection made through the ssh client, so that the
+// host's SSH config (ProxyJump, identity files, agent...) applies as for a login
+type sftpSession struct {
+	client *sftp.Client
+	close  func() error
+}
+
+// openSFTPSession starts the sftp subsystem of a host. Authentication must not
+// need a prompt, since the terminal belongs to the interface.
+func openSFTPSession(hostName, configFile string) (*sftpSession, error) {
+	args := []string{"-o", "BatchMode=yes"}
+	if configFile != "" {
+		args = append(args, "-F", configFile)
+	}
+	args = append(args, "-s", hostName, "sftp")
+
+	cmd := exec.Command("ssh", args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ssh: %w", err)
+	}
+
+	client, err := sftp.NewClientPipe(stdout, stdin)
+	if err != nil {
+		stdin.Close()
+		_ = cmd.Wait()
+		// ssh explains why the session could not be opened better than the SFTP handshake
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			lines := strings.Split(message, "\n")
+			return nil, errors.New(strings.TrimSpace(lines[len(lines)-1]))
+		}
+		return nil, err
+	}
+
+	return &sftpSession{
+		client: client,
+		close: func() error {
+			err := client.Close()
+			_ = cmd.Wait()
+			return err
+		},
+	}, nil
+}
+
+// Close ends the SFTP session and the ssh process carrying it
+func (s *sftpSession) Close() error {
+	return s.close()
+}
+
+// fileSystem is what the file browser needs from the local or the remote side
+type fileSystem interface {
+	ReadDir(dir string) ([]os.FileInfo, error)
+	Stat(name string) (os.FileInfo, error)
+	Open(name string) (io.ReadCloser, error)
+	Create(name string) (io.WriteCloser, error)
+	MkdirAll(dir string) error
+	Chmod(name string, mode os.FileMode) error
+	Rename(oldName, newName string) error
+	RemoveAll(name string) error
+	Join(elem ...string) string
+	Dir(name string) string
+	Base(name string) string
+}
+
+// localFS is the file system of this machine
+type localFS struct{}
+
+func (localFS) ReadDir(dir string) ([]os.FileInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			// The file was removed while listing
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (localFS) Stat(name string) (os.FileInfo, error)      { return os.Stat(name) }
+func (localFS) Open(name string) (io.ReadCloser, error)    { return os.Open(name) }
+func (localFS) Create(name string) (io.WriteCloser, error) { return os.Create(name) }
+func (localFS) MkdirAll(dir string) error                  { return os.MkdirAll(dir, 0755) }
+func (localFS) Chmod(name string, mode os.FileMode) error  { return os.Chmod(name, mode) }
+func (localFS) Rename(oldName, newName string) error       { return os.Rename(oldName, newName) }
+func (localFS) RemoveAll(name string) error                { return os.RemoveAll(name) }
+func (localFS) Join(elem ...string) string                 { return filepath.Join(elem...) }
+func (localFS) Dir(name string) string                     { return filepath.Dir(name) }
+func (localFS) Base(name string) string                    { return filepath.Base(name) }
+
+// remoteFS is the file system of a host, over SFTP
+type remoteFS struct {
+	client *sftp.Client
+}
+
+func (r remoteFS) ReadDir(dir string) ([]os.FileInfo, error)  { return r.client.ReadDir(dir) }
+func (r remoteFS) Stat(name string) (os.FileInfo, error)      { return r.client.Stat(name) }
+func (r remoteFS) Open(name string) (io.ReadCloser, error)    { return r.client.Open(name) }
+func (r remoteFS) Create(name string) (io.WriteCloser, error) { return r.client.Create(name) }
+func (r remoteFS) MkdirAll(dir string) error                  { return r.client.MkdirAll(dir) }
+func (r remoteFS) Chmod(name string, mode os.FileMode) error  { return r.client.Chmod(name, mode) }
+func (r remoteFS) Rename(oldName, newName string) error       { return r.client.Rename(oldName, newName) }
+func (r remoteFS) RemoveAll(name string) error                { return r.client.RemoveAll(name) }
+func (r remoteFS) Join(elem ...string) string                 { return path.Join(elem...) }
+func (r remoteFS) Dir(name string) string                     { return path.Dir(name) }
+func (r remoteFS) Base(name string) string                    { return path.Base(name) }
+
+// sortEntries lists directories first, then files, each by name
+func sortEntries(entries []os.FileInfo) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].IsDir() != entries[j].IsDir() {
+			return entries[i].IsDir()
+		}
+		return strings.ToLower(entries[i].Name()) < strings.ToLower(entries[j].Name())
+	})
+}
+
+// copyFiles copies a file or a directory tree between file systems, keeping
+// permissions, and returns the number of bytes copied
+func copyFiles(srcFS fileSystem, src string, dstFS fileSystem, dst string) (int64, error) {
+	info, err := srcFS.Stat(src)
+	if err != nil {
+		return 0, err
+	}
+
+	if !info.IsDir() {
+		return copyFile(srcFS, src, dstFS, dst, info.Mode().Perm())
+	}
+
+	if err := dstFS.MkdirAll(dst); err != nil {
+		return 0, err
+	}
+	entries, err := srcFS.ReadDir(src)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, entry := range entries {
+		if !entry.IsDir() && !entry.Mode().IsRegular() {
+			// Symlinks, sockets and devices are skipped
+			continue
+		}
+		n, err := copyFiles(srcFS, srcFS.Join(src, entry.Name()), dstFS, dstFS.Join(dst, entry.Name()))
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// copyFile copies the content of a single file
+func copyFile(srcFS fileSystem, src string, dstFS fileSystem, dst string, mode os.FileMode) (int64, error) {
+	in, err := srcFS.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := dstFS.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, err
+	}
+	return n, dstFS.Chmod(dst, mode)
+}
+
+// formatSize formats a number of bytes for display
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/snippet"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// snippetsModel lists the snippets available for a host and runs the one picked
+type snippetsModel struct {
+	host       config.SSHHost
+	configFile string
+	snippets   []snippet.Snippet
+	cursor     int
+	err        string
+	styles     Styles
+	width      int
+	height     int
+}
+
+// Messages for communication with parent model
+type snippetRunMsg struct {
+	name string
+	args []string // ssh arguments
+}
+
+type snippetDoneMsg struct {
+	name string
+	host string
+	err  error
+}
+
+type snippetsCloseMsg struct{}
+
+// NewSnippetsPicker creates the snippet picker of host
+func NewSnippetsPicker(host config.SSHHost, configFile string, styles Styles, width, height int) *snippetsModel {
+	m := &snippetsModel{
+		host:       host,
+		configFile: configFile,
+		styles:     styles,
+		width:      width,
+		height:     height,
+	}
+	snippets, err := snippet.Load()
+	if err != nil {
+		m.err = err.Error()
+	}
+	m.snippets = snippet.ForHost(snippets, host)
+	return m
+}
+
+// selected returns the snippet under the cursor, if any
+func (m *snippetsModel) selected() *snippet.Snippet {
+	if m.cursor < 0 || m.cursor >= len(m.snippets) {
+		return nil
+	}
+	return &m.snippets[m.cursor]
+}
+
+func (m *snippetsModel) Update(msg tea.Msg) (*snippetsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q", "ctrl+c":
+			return m, func() tea.Msg { return snippetsCloseMsg{} }
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.snippets)-1 {
+				m.cursor++
+			}
+		case "enter":
+			s := m.selected()
+			if s == nil {
+				return m, nil
+			}
+			args, err := s.SSHArgs(m.host, m.configFile)
+			if err != nil {
+				m.err = err.Error()
+				return m, nil
+			}
+			name := s.Name
+			return m, func() tea.Msg { return snippetRunMsg{name: name, args: args} }
+		}
+	}
+	return m, nil
+}
+
+func (m *snippetsModel) View() string {
+	title := m.styles.FormTitle.Render("⚡ Snippets for " + m.host.Name)
+
+	var lines []string
+	if len(m.snippets) == 0 {
+		path, _ := snippet.Path()
+		lines = append(lines, m.styles.HelpText.Render(fmt.Sprintf("No snippets for this host. Add them to %s (see 'sshm run --help').", path)))
+	} else {
+		nameWidth := 0
+		for _, s := range m.snippets {
+			nameWidth = max(nameWidth, len(s.Name))
+		}
+		for i, s := range m.snippets {
+			label := s.Command
+			if s.Description != "" {
+				label = s.Description
+			}
+			line := truncate(fmt.Sprintf("%-*s  %s", nameWidth, s.Name, label), max(20, m.width-4))
+			if i == m.cursor {
+				line = m.styles.Selected.Render(line)
+			}
+			lines = append(lines, line)
+		}
+
+		// Show the command that will run, with the host filled in
+		if s := m.selected(); s != nil {
+			lines = append(lines, "")
+			if command, err := s.Render(m.host); err == nil {
+				lines = append(lines, m.styles.HelpText.Render(truncate("$ "+command, max(20, m.width-4))))
+			}
+		}
+	}
+
+	var status string
+	if m.err != "" {
+		status = m.styles.ErrorText.Render(m.err)
+	}
+
+	help := m.styles.FormHelp.Render("↑/↓: select • enter: run • esc: close")
+
+	content := lipgloss.JoinVertical(lipgloss.Left, title, "", strings.Join(lines, "\n"), "", status, help)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.styles.FormContainer.Render(content))
+}
+
+// pausedCommand runs a command and waits for Enter before returning, so that
+// its output stays on screen until the TUI is drawn again
+type pausedCommand struct {
+	cmd    *exec.Cmd
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+// newPausedCommand prepares cmd to run attached to the terminal
+func newPausedCommand(cmd *exec.Cmd) *pausedCommand {
+	return &pausedCommand{cmd: cmd, stdin: os.Stdin, stdout: os.Stdout}
+}
+
+func (c *pausedCommand) SetStdin(r io.Reader)  { c.stdin = r }
+func (c *pausedCommand) SetStdout(w io.Writer) { c.stdout = w }
+func (c *pausedCommand) SetStderr(w io.Writer) { c.cmd.Stderr = w }
+
+// Run runs the command and returns its error, as exec.Cmd.Run does
+func (c *pausedCommand) Run() error {
+	c.cmd.Stdin = c.stdin
+	c.cmd.Stdout = c.stdout
+	if c.cmd.Stderr == nil {
+		c.cmd.Stderr = os.Stderr
+	}
+	err := c.cmd.Run()
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		fmt.Fprint(c.stdout, "\nDone. ")
+	case errors.As(err, &exitErr):
+		fmt.Fprintf(c.stdout, "\nExited with status %d. ", exitErr.ExitCode())
+	default:
+		fmt.Fprintf(c.stdout, "\nError: %v. ", err)
+	}
+	fmt.Fprint(c.stdout, "Press Enter to return to sshm...")
+	_, _ = bufio.NewReader(c.stdin).ReadString('\n')
+	return err
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Gu1llaum-3/sshm/internal/snippet"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSnippetsPicker(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("APPDATA", tempDir)
+
+	path, err := snippet.Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := `[
+  {"name": "disk", "command": "df -h", "description": "Disk usage"},
+  {"name": "logs", "command": "tail -f /var/log/{{.Name}}.log", "hosts": ["web-*"], "tty": true},
+  {"name": "restart", "command": "systemctl restart postgresql", "hosts": ["db-*"]}
+]`
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Open the picker on web-server, which gets the global and web snippets
+	m := createTestModel()
+	m.table.SetCursor(3)
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	m = updated.(Model)
+	if m.viewMode != ViewSnippets || m.snippetsPicker == nil {
+		t.Fatal("Expected x to open the snippet picker")
+	}
+	view := m.View()
+	for _, expected := range []string{"Snippets for web-server", "Disk usage", "logs"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("Expected %q in the snippet picker:\n%s", expected, view)
+		}
+	}
+	if strings.Contains(view, "restart") {
+		t.Errorf("Expected the db snippet to be left out:\n%s", view)
+	}
+
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = updated.(Model)
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	msg, ok := cmd().(snippetRunMsg)
+	if !ok {
+		t.Fatal("Expected enter to run the snippet")
+	}
+	if args := strings.Join(msg.args, " "); args != "-t web-server -- tail -f /var/log/web-server.log" {
+		t.Errorf("Unexpected ssh arguments %q", args)
+	}
+
+	updated, _ = m.Update(snippetDoneMsg{name: "logs", host: "web-server"})
+	m = updated.(Model)
+	if m.viewMode != ViewList || m.snippetsPicker != nil {
+		t.Error("Expected to return to the list once the snippet is done")
+	}
+}
@@ -1,14 +1,25 @@
 package ui
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
 	"github.com/Gu1llaum-3/sshm/internal/history"
 
 	"github.com/charmbracelet/bubbles/table"
 )
 
+// monitorColumnWidth is the width of the monitor column: change marker, sparkline and uptime
+const monitorColumnWidth = connectivity.HistorySize + 9
+
+// authColumnWidth is the width of the authentication probe column
+const authColumnWidth = 9
+
+// multiplexedIndicator is shown after the name of hosts with a running ControlMaster
+const multiplexedIndicator = "⇄"
+
 // calculateDynamicColumnWidths calculates optimal column widths based on terminal width
 // and content length, ensuring all content fits when possible
 func (m *Model) calculateDynamicColumnWidths(hosts []config.SSHHost) (int, int, int, int) {
@@ -26,6 +37,13 @@ func (m *Model) calculateDynamicColumnWidths(hosts []config.SSHHost) (int, int,
 	for _, host := range hosts {
 		// Name column includes status indicator (2 chars) + space (1 char) + name
 		nameLength := 3 + len(host.Name)
+		if len(m.marked) > 0 {
+			// Room for the marked indicator and its space
+			nameLength += 2
+		}
+		if m.multiplexed[host.Name] {
+			nameLength += 2
+		}
 		if nameLength > maxNameLength {
 			maxNameLength = nameLength
 		}
@@ -67,6 +85,13 @@ func (m *Model) calculateDynamicColumnWidths(hosts []config.SSHHost) (int, int,
 	// Calculate available width (minus borders and separators)
 	// Table has borders (2 chars) + column separators (3 chars between 4 columns)
 	availableWidth := m.width - 5
+	if m.monitorMode {
+		// Reserve room for the monitor column and its separator
+		availableWidth -= monitorColumnWidth + 1
+	}
+	if m.authProbeEnabled() {
+		availableWidth -= authColumnWidth + 1
+	}
 
 	totalNeededWidth := maxNameLength + maxHostnameLength + maxTagsLength + maxLastLoginLength
 
@@ -152,21 +177,46 @@ func (m *Model) updateTableRows() {
 			}
 		}
 
-		rows = append(rows, table.Row{
-			statusIndicator + " " + host.Name,
+		// Show which hosts are marked once any host is
+		nameCell := statusIndicator + " " + host.Name
+		if m.multiplexed[host.Name] {
+			nameCell += " " + multiplexedIndicator
+		}
+		if m.isMarked(host.Name) {
+			nameCell = markedIndicator + " " + nameCell
+		} else if len(m.marked) > 0 {
+			nameCell = "  " + nameCell
+		}
+
+		row := table.Row{
+			nameCell,
 			host.Hostname,
 			// host.User,      // Commented to save space
 			// host.Port,      // Commented to save space
 			tagsStr,
 			lastLoginStr,
-		})
+		}
+		if m.authProbeEnabled() && m.ready {
+			row = append(row, m.formatAuthCell(host.Name))
+		}
+		if m.monitorMode && m.ready {
+			row = append(row, m.formatMonitorCell(host.Name))
+		}
+		rows = append(rows, row)
 	}
 
-	m.table.SetRows(rows)
+	// Clear rows first so that rows never have more cells than columns
+	// while the monitor column is being added or removed
+	m.table.SetRows(nil)
 
-	// Update table height and columns based on current terminal size
-	m.updateTableHeight()
+	// Update table columns and height based on current terminal size
 	m.updateTableColumns()
+	// Offer to connect to the target typed in the search box
+	if target, ok := m.adhocTarget(); ok {
+		rows = append(rows, adhocRow(target, len(m.table.Columns())))
+	}
+	m.table.SetRows(rows)
+	m.updateTableHeight()
 }
 
 // updateTableHeight dynamically adjusts table height based on terminal size
@@ -250,10 +300,56 @@ func (m *Model) updateTableColumns() {
 		{Title: "Tags", Width: tagsWidth},
 		{Title: lastLoginTitle, Width: lastLoginWidth},
 	}
+	if m.authProbeEnabled() {
+		columns = append(columns, table.Column{Title: "Auth", Width: authColumnWidth})
+	}
+	if m.monitorMode {
+		columns = append(columns, table.Column{Title: "Monitor", Width: monitorColumnWidth})
+	}
 
 	m.table.SetColumns(columns)
 }
 
+// authProbeEnabled reports whether checks probe authentication, which adds the Auth column
+func (m *Model) authProbeEnabled() bool {
+	return m.pingManager != nil && m.pingManager.AuthProbeEnabled()
+}
+
+// formatAuthCell renders the outcome of the authentication probe for a host
+func (m *Model) formatAuthCell(hostName string) string {
+	result, exists := m.pingManager.GetResult(hostName)
+	if !exists {
+		return ""
+	}
+	switch result.Auth {
+	case connectivity.AuthOK:
+		return "✓ ok"
+	case connectivity.AuthDenied:
+		return "✗ denied"
+	}
+	return ""
+}
+
+// formatMonitorCell renders the status change marker, latency sparkline and uptime for a host
+func (m *Model) formatMonitorCell(hostName string) string {
+	if m.pingManager == nil {
+		return ""
+	}
+
+	samples := m.pingManager.GetHistory(hostName)
+	if len(samples) == 0 {
+		return ""
+	}
+
+	marker := " "
+	if m.pingManager.StatusChanged(hostName) {
+		marker = "Δ"
+	}
+
+	uptime, _ := m.pingManager.GetUptime(hostName)
+	return fmt.Sprintf("%s %-*s %3.0f%%", marker, connectivity.HistorySize, renderSparkline(samples), uptime)
+}
+
 // max returns the maximum of two integers
 func max(a, b int) int {
 	if a > b {
@@ -45,6 +45,11 @@ func NewModel(hosts []config.SSHHost, configFile string, searchMode bool, curren
 
 	// Initialize ping manager with 5 second timeout
 	pingManager := connectivity.NewPingManager(5*time.Second, configFile)
+	pingManager.ApplySettings(appConfig.Ping)
+	if err := pingManager.EnableCache(); err != nil {
+		// Not critical: statuses simply start as unknown
+		fmt.Printf("Warning: Could not load connectivity cache: %v\n", err)
+	}
 
 	// Create the model with default sorting by name
 	m := Model{
@@ -0,0 +1,241 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// tunnelsRefreshInterval is how often the panel reads the tunnel states again
+const tunnelsRefreshInterval = time.Second
+
+// tunnelsModel lists the background tunnels with their ports and uptime
+type tunnelsModel struct {
+	dir      string
+	tunnels  []tunnel.Tunnel
+	cursor   int
+	stopping bool // waiting for y/n to stop the selected tunnel
+	status   string
+	err      string
+	styles   Styles
+	width    int
+	height   int
+}
+
+// Messages for communication with parent model
+type tunnelsTickMsg struct {
+	panel *tunnelsModel // ticks of a closed panel are dropped
+}
+
+type tunnelStoppedMsg struct {
+	id  string
+	err error
+}
+
+type tunnelsCloseMsg struct{}
+
+// tunnelStartedMsg reports a tunnel started from the port forwarding form
+type tunnelStartedMsg struct {
+	id  string
+	dir string
+	err error
+}
+
+// NewTunnelsPanel creates the tunnels panel, reading the tunnels of dir
+func NewTunnelsPanel(dir string, styles Styles, width, height int) *tunnelsModel {
+	m := &tunnelsModel{
+		dir:    dir,
+		styles: styles,
+		width:  width,
+		height: height,
+	}
+	m.reload()
+	return m
+}
+
+// Init starts refreshing the panel
+func (m *tunnelsModel) Init() tea.Cmd {
+	return m.tick()
+}
+
+func (m *tunnelsModel) tick() tea.Cmd {
+	return tea.Tick(tunnelsRefreshInterval, func(time.Time) tea.Msg {
+		return tunnelsTickMsg{panel: m}
+	})
+}
+
+// reload reads the tunnel states again, keeping the cursor on the same tunnel
+func (m *tunnelsModel) reload() {
+	var selectedID string
+	if t := m.selected(); t != nil {
+		selectedID = t.ID
+	}
+
+	tunnels, err := tunnel.List(m.dir)
+	if err != nil {
+		m.err = fmt.Sprintf("Could not read tunnels: %v", err)
+		return
+	}
+	m.tunnels = tunnels
+
+	m.cursor = min(m.cursor, max(0, len(m.tunnels)-1))
+	for i, t := range m.tunnels {
+		if t.ID == selectedID {
+			m.cursor = i
+			break
+		}
+	}
+}
+
+// selected returns the tunnel under the cursor, if any
+func (m *tunnelsModel) selected() *tunnel.Tunnel {
+	if m.cursor < 0 || m.cursor >= len(m.tunnels) {
+		return nil
+	}
+	return &m.tunnels[m.cursor]
+}
+
+func (m *tunnelsModel) Update(msg tea.Msg) (*tunnelsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tunnelsTickMsg:
+		m.reload()
+		return m, m.tick()
+
+	case tunnelStoppedMsg:
+		if msg.err != nil {
+			m.err = msg.err.Error()
+		} else {
+			m.err = ""
+			m.status = fmt.Sprintf("Tunnel %s stopped", msg.id)
+		}
+		m.reload()
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.stopping {
+			m.stopping = false
+			if msg.String() != "y" && msg.String() != "Y" {
+				return m, nil
+			}
+			t := m.selected()
+			if t == nil {
+				return m, nil
+			}
+			id := t.ID
+			dir := m.dir
+			m.status = fmt.Sprintf("Stopping %s...", id)
+			return m, func() tea.Msg {
+				return tunnelStoppedMsg{id: id, err: tunnel.Stop(dir, id)}
+			}
+		}
+
+		switch msg.String() {
+		case "esc", "q", "ctrl+c":
+			return m, func() tea.Msg { return tunnelsCloseMsg{} }
+		case "up", "k":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "j":
+			if m.cursor < len(m.tunnels)-1 {
+				m.cursor++
+			}
+		case "r", "R":
+			m.reload()
+		case "x", "d":
+			if m.selected() != nil {
+				m.stopping = true
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *tunnelsModel) View() string {
+	title := m.styles.FormTitle.Render("🔗 Background Tunnels")
+
+	var lines []string
+	if len(m.tunnels) == 0 {
+		lines = append(lines, m.styles.HelpText.Render("No tunnels running. Start one with ctrl+t in the port forwarding form (f) or 'sshm tunnel start'."))
+	} else {
+		idWidth, hostWidth := len("ID"), len("Host")
+		for _, t := range m.tunnels {
+			idWidth = max(idWidth, len(t.ID))
+			hostWidth = max(hostWidth, len(t.Host))
+		}
+		header := fmt.Sprintf("%-*s  %-*s  %-12s  %-7s  %s", idWidth, "ID", hostWidth, "Host", "State", "Uptime", "Forwards")
+		lines = append(lines, m.styles.FocusedLabel.Render(header))
+
+		now := time.Now()
+		for i, t := range m.tunnels {
+			line := fmt.Sprintf("%-*s  %-*s  %-12s  %-7s  %s", idWidth, t.ID, hostWidth, t.Host,
+				t.State, tunnel.FormatUptime(t.Uptime(now)), t.Ports())
+			line = truncate(line, max(20, m.width-4))
+			switch {
+			case i == m.cursor:
+				line = m.styles.Selected.Render(line)
+			case !t.Active():
+				line = m.styles.ErrorText.Render(line)
+			}
+			lines = append(lines, line)
+		}
+
+		if t := m.selected(); t != nil {
+			lines = append(lines, "")
+			details := fmt.Sprintf("Restarts: %d • Log: %s", t.Restarts, tunnel.LogPath(m.dir, t.ID))
+			lines = append(lines, m.styles.HelpText.Render(details))
+			if t.LastError != "" && t.State != tunnel.StateConnected {
+				lines = append(lines, m.styles.ErrorText.Render("Last error: "+t.LastError))
+			}
+		}
+	}
+
+	var status string
+	switch {
+	case m.stopping:
+		status = m.styles.ErrorText.Render(fmt.Sprintf("Stop %s? (y/n)", m.selected().ID))
+	case m.err != "":
+		status = m.styles.ErrorText.Render(m.err)
+	default:
+		status = m.styles.HelpText.Render(m.status)
+	}
+
+	help := m.styles.FormHelp.Render("↑/↓: select • x: stop tunnel • r: refresh • esc: close")
+
+	content := lipgloss.JoinVertical(lipgloss.Left, title, "", strings.Join(lines, "\n"), "", status, help)
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, m.styles.FormContainer.Render(content))
+}
+
+// startTunnel starts forwards to hostName as a background tunnel named id (by
+// default after the host and port) through the sshm executable, and reports
+// the result as a tunnelStartedMsg
+func startTunnel(hostName, configFile string, forwards []tunnel.Forward, id string) tea.Cmd {
+	return func() tea.Msg {
+		dir, err := tunnel.Dir()
+		if err != nil {
+			return tunnelStartedMsg{err: err}
+		}
+		executable, err := os.Executable()
+		if err != nil {
+			return tunnelStartedMsg{err: fmt.Errorf("could not find the sshm executable: %w", err)}
+		}
+		// The supervisor may outlive the working directory
+		if configFile != "" {
+			if abs, err := filepath.Abs(configFile); err == nil {
+				configFile = abs
+			}
+		}
+		t := &tunnel.Tunnel{ID: id, Host: hostName, ConfigFile: configFile, Forwards: forwards}
+		if err := tunnel.Start(dir, t, executable); err != nil {
+			return tunnelStartedMsg{err: err}
+		}
+		return tunnelStartedMsg{id: t.ID, dir: dir}
+	}
+}
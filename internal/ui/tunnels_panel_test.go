@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestTunnelsPanel(t *testing.T) {
+	dir := t.TempDir()
+	for _, tn := range []*tunnel.Tunnel{
+		{ID: "db-5432", Host: "db", PID: os.Getpid(), State: tunnel.StateConnected, ConnectedAt: time.Now().Add(-time.Minute),
+			Forwards: []tunnel.Forward{{Type: tunnel.Local, LocalPort: "5432", RemoteHost: "localhost", RemotePort: "5432"}}},
+		{ID: "web-8080", Host: "web", State: tunnel.StateStopped,
+			Forwards: []tunnel.Forward{{Type: tunnel.Dynamic, LocalPort: "8080"}}},
+	} {
+		if err := tunnel.Save(dir, tn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// This process holds the lock of db-5432, as its supervisor would
+	lock, err := tunnel.Lock(dir, "db-5432")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Close()
+
+	panel := NewTunnelsPanel(dir, NewStyles(120), 120, 40)
+	view := panel.View()
+	for _, expected := range []string{"db-5432", "connected", "1m0", "L 5432 → localhost:5432", "web-8080"} {
+		if !strings.Contains(view, expected) {
+			t.Errorf("Expected %q in the tunnels panel:\n%s", expected, view)
+		}
+	}
+
+	// Stop the stopped tunnel, which only forgets it
+	panel, _ = panel.Update(tea.KeyMsg{Type: tea.KeyDown})
+	panel, _ = panel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if !panel.stopping {
+		t.Fatal("Expected a confirmation before stopping a tunnel")
+	}
+	panel, cmd := panel.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	panel, _ = panel.Update(cmd())
+	if panel.err != "" || len(panel.tunnels) != 1 || panel.tunnels[0].ID != "db-5432" {
+		t.Errorf("Expected web-8080 to be stopped, got %d tunnels (%s)", len(panel.tunnels), panel.err)
+	}
+}
@@ -8,6 +8,9 @@ import (
 
 	"github.com/Gu1llaum-3/sshm/internal/config"
 	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+	"github.com/Gu1llaum-3/sshm/internal/mux"
+	"github.com/Gu1llaum-3/sshm/internal/recording"
+	"github.com/Gu1llaum-3/sshm/internal/tunnel"
 	"github.com/Gu1llaum-3/sshm/internal/version"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -16,41 +19,92 @@ import (
 
 // Messages for SSH ping functionality and version checking
 type (
-	pingResultMsg   *connectivity.HostPingResult
+	pingResultMsg struct {
+		results <-chan *connectivity.HostPingResult // sweep the result belongs to
+		result  *connectivity.HostPingResult
+	}
+	pingDoneMsg struct {
+		results <-chan *connectivity.HostPingResult // sweep that is complete
+	}
+	monitorTickMsg  int // monitor generation that scheduled the tick
+	refreshStaleMsg struct{}
 	versionCheckMsg *version.UpdateInfo
 	versionErrorMsg error
 	errorMsg        string
+	muxCheckedMsg   map[string]bool
 )
 
-// startPingAllCmd creates a command to ping all hosts concurrently
-func (m Model) startPingAllCmd() tea.Cmd {
-	if m.pingManager == nil {
-		return nil
+// checkMultiplexedCmd looks for the hosts with a running ControlMaster
+func checkMultiplexedCmd(hosts []config.SSHHost, configFile string) tea.Cmd {
+	return func() tea.Msg {
+		multiplexed := make(map[string]bool)
+		for _, master := range mux.List(context.Background(), hosts, configFile) {
+			if master.Active {
+				multiplexed[master.Host] = true
+			}
+		}
+		return muxCheckedMsg(multiplexed)
 	}
+}
 
-	return tea.Batch(
-		// Create individual ping commands for each host
-		func() tea.Cmd {
-			var cmds []tea.Cmd
-			for _, host := range m.hosts {
-				cmds = append(cmds, pingSingleHostCmd(m.pingManager, host))
-			}
-			return tea.Batch(cmds...)
-		}(),
-	)
+// startPingAllCmd starts pinging all visible hosts
+func (m *Model) startPingAllCmd() tea.Cmd {
+	return m.startPingCmd(m.hosts)
 }
 
-// listenForPingResultsCmd is no longer needed since we use individual ping commands
+// startPingCmd starts pinging hosts through the ping manager's worker pool
+// and returns the command listening for the first result
+func (m *Model) startPingCmd(hosts []config.SSHHost) tea.Cmd {
+	if m.pingManager == nil || len(hosts) == 0 {
+		return nil
+	}
+
+	// Cancel a sweep that is still running before starting a new one
+	if m.pingCancel != nil {
+		m.pingCancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.pingCancel = cancel
+	m.pingResults = m.pingManager.PingAllHostsAsCompleted(ctx, hosts)
+
+	return listenForPingResultsCmd(m.pingResults)
+}
 
-// pingSingleHostCmd creates a command to ping a single host
-func pingSingleHostCmd(pingManager *connectivity.PingManager, host config.SSHHost) tea.Cmd {
+// listenForPingResultsCmd waits for the next result of a ping sweep
+func listenForPingResultsCmd(results <-chan *connectivity.HostPingResult) tea.Cmd {
 	return func() tea.Msg {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
+		result, ok := <-results
+		if !ok {
+			return pingDoneMsg{results: results}
+		}
+		return pingResultMsg{results: results, result: result}
+	}
+}
+
+// cacheTTL returns the age after which last known statuses are refreshed
+func (m Model) cacheTTL() time.Duration {
+	seconds := config.GetDefaultPingSettings().CacheTTLSeconds
+	if m.appConfig != nil && m.appConfig.Ping.CacheTTLSeconds > 0 {
+		seconds = m.appConfig.Ping.CacheTTLSeconds
+	}
+	return time.Duration(seconds) * time.Second
+}
 
-		result := pingManager.PingHost(ctx, host)
-		return pingResultMsg(result)
+// monitorInterval returns the delay between monitor sweeps
+func (m Model) monitorInterval() time.Duration {
+	seconds := config.GetDefaultPingSettings().MonitorIntervalSeconds
+	if m.appConfig != nil && m.appConfig.Ping.MonitorIntervalSeconds > 0 {
+		seconds = m.appConfig.Ping.MonitorIntervalSeconds
 	}
+	return time.Duration(seconds) * time.Second
+}
+
+// monitorTickCmd schedules the next monitor sweep
+func (m Model) monitorTickCmd() tea.Cmd {
+	gen := m.monitorGen
+	return tea.Tick(m.monitorInterval(), func(time.Time) tea.Msg {
+		return monitorTickMsg(gen)
+	})
 }
 
 // checkVersionCmd creates a command to check for version updates
@@ -74,6 +128,14 @@ func (m Model) Init() tea.Cmd {
 	// Basic initialization commands
 	cmds = append(cmds, textinput.Blink)
 
+	// Refresh last known statuses that are older than the cache TTL
+	if m.pingManager != nil && len(m.pingManager.StaleHosts(m.hosts, m.cacheTTL())) > 0 {
+		cmds = append(cmds, func() tea.Msg { return refreshStaleMsg{} })
+	}
+
+	// Show which hosts have a running ControlMaster
+	cmds = append(cmds, checkMultiplexedCmd(m.allHosts, m.configFile))
+
 	// Check for version updates if we have a current version and updates are enabled
 	if m.currentVersion != "" && m.appConfig.IsUpdateCheckEnabled() {
 		cmds = append(cmds, checkVersionCmd(m.currentVersion))
@@ -135,16 +197,77 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.fileSelectorForm.height = m.height
 			m.fileSelectorForm.styles = m.styles
 		}
+		if m.bulkForm != nil {
+			m.bulkForm.width = m.width
+			m.bulkForm.height = m.height
+			m.bulkForm.styles = m.styles
+		}
+		if m.broadcast != nil {
+			m.broadcast.styles = m.styles
+			m.broadcast.resize(m.width, m.height)
+		}
+		if m.sftpBrowser != nil {
+			m.sftpBrowser.width = m.width
+			m.sftpBrowser.height = m.height
+			m.sftpBrowser.styles = m.styles
+		}
+		if m.tunnelsPanel != nil {
+			m.tunnelsPanel.width = m.width
+			m.tunnelsPanel.height = m.height
+			m.tunnelsPanel.styles = m.styles
+		}
+		if m.snippetsPicker != nil {
+			m.snippetsPicker.width = m.width
+			m.snippetsPicker.height = m.height
+			m.snippetsPicker.styles = m.styles
+		}
 		return m, nil
 
 	case pingResultMsg:
-		// Handle ping result - update table display
-		if msg != nil {
+		// Drop the results of a sweep replaced by a newer one
+		if msg.results != m.pingResults {
+			return m, nil
+		}
+		if msg.result != nil {
 			// Update the table to reflect the new ping status
 			m.updateTableRows()
 		}
+		// Keep listening until the sweep is complete
+		return m, listenForPingResultsCmd(m.pingResults)
+
+	case pingDoneMsg:
+		// The end of a replaced sweep must not stop the current one
+		if msg.results != m.pingResults {
+			return m, nil
+		}
+		m.pingResults = nil
+		if m.pingCancel != nil {
+			m.pingCancel()
+			m.pingCancel = nil
+		}
+		m.updateTableRows()
 		return m, nil
 
+	case refreshStaleMsg:
+		// Background refresh of outdated cached statuses, unless a sweep is already running
+		if m.pingResults != nil || m.pingManager == nil {
+			return m, nil
+		}
+		cmd := m.startPingCmd(m.pingManager.StaleHosts(m.hosts, m.cacheTTL()))
+		return m, cmd
+
+	case monitorTickMsg:
+		// Ignore ticks scheduled before monitor mode was last toggled
+		if !m.monitorMode || int(msg) != m.monitorGen {
+			return m, nil
+		}
+		// Skip this sweep if the previous one is still running
+		var sweepCmd tea.Cmd
+		if m.pingResults == nil {
+			sweepCmd = m.startPingAllCmd()
+		}
+		return m, tea.Batch(sweepCmd, m.monitorTickCmd())
+
 	case versionCheckMsg:
 		// Handle version check result
 		if msg != nil {
@@ -246,9 +369,14 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.viewMode = ViewList
 			m.editForm = nil
 			m.table.Focus()
-			return m, nil
+			return m, checkMultiplexedCmd(m.allHosts, m.configFile)
 		}
 
+	case muxCheckedMsg:
+		m.multiplexed = msg
+		m.updateTableRows()
+		return m, nil
+
 	case editFormCancelMsg:
 		// Cancel: return to list view
 		m.viewMode = ViewList
@@ -346,6 +474,12 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.portForwardForm.err = msg.err.Error()
 			}
 			return m, nil
+		} else if msg.background {
+			// Start the forward as a background tunnel
+			if m.portForwardForm == nil {
+				return m, nil
+			}
+			return m, startTunnel(m.portForwardForm.hostName, m.configFile, msg.forwards, msg.tunnelID)
 		} else {
 			// Success: execute SSH command with port forwarding
 			if len(msg.sshArgs) > 0 {
@@ -371,6 +505,75 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+	case tunnelStartedMsg:
+		if msg.err != nil {
+			if m.portForwardForm != nil {
+				m.portForwardForm.err = msg.err.Error()
+			}
+			return m, nil
+		}
+		// Show the new tunnel among the background tunnels
+		m.portForwardForm = nil
+		m.tunnelsPanel = NewTunnelsPanel(msg.dir, m.styles, m.width, m.height)
+		m.tunnelsPanel.status = fmt.Sprintf("Tunnel %s started", msg.id)
+		m.viewMode = ViewTunnels
+		return m, m.tunnelsPanel.Init()
+
+	case tunnelsTickMsg:
+		if m.tunnelsPanel == nil || msg.panel != m.tunnelsPanel {
+			// The panel was closed or replaced
+			return m, nil
+		}
+		m.tunnelsPanel, cmd = m.tunnelsPanel.Update(msg)
+		return m, cmd
+
+	case tunnelStoppedMsg:
+		if m.tunnelsPanel != nil {
+			m.tunnelsPanel, cmd = m.tunnelsPanel.Update(msg)
+		}
+		return m, cmd
+
+	case tunnelsCloseMsg:
+		// Return to list view
+		m.viewMode = ViewList
+		m.tunnelsPanel = nil
+		m.table.Focus()
+		return m, nil
+
+	case hookFailedMsg:
+		return m, m.showErrorCmd(msg.err.Error())
+
+	case adhocDoneMsg:
+		return m.adhocDone(msg)
+
+	case snippetRunMsg:
+		// Run the snippet with the terminal, then come back to the list
+		sshCmd := exec.Command("ssh", msg.args...)
+		hostName := ""
+		if m.snippetsPicker != nil {
+			hostName = m.snippetsPicker.host.Name
+		}
+		name := msg.name
+		return m, tea.Exec(newPausedCommand(sshCmd), func(err error) tea.Msg {
+			return snippetDoneMsg{name: name, host: hostName, err: err}
+		})
+
+	case snippetDoneMsg:
+		m.viewMode = ViewList
+		m.snippetsPicker = nil
+		m.table.Focus()
+		if msg.err != nil {
+			return m, m.showErrorCmd(fmt.Sprintf("Snippet %s failed on %s: %v", msg.name, msg.host, msg.err))
+		}
+		return m, nil
+
+	case snippetsCloseMsg:
+		// Return to list view
+		m.viewMode = ViewList
+		m.snippetsPicker = nil
+		m.table.Focus()
+		return m, nil
+
 	case portForwardCancelMsg:
 		// Cancel: return to list view
 		m.viewMode = ViewList
@@ -378,6 +581,130 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.table.Focus()
 		return m, nil
 
+	case bulkFormSubmitMsg:
+		// Refresh hosts after tags were changed, hosts moved or exported
+		var hosts []config.SSHHost
+		var err error
+
+		if m.configFile != "" {
+			hosts, err = config.ParseSSHConfigFile(m.configFile)
+		} else {
+			hosts, err = config.ParseSSHConfig()
+		}
+
+		if err != nil {
+			return m, tea.Quit
+		}
+		m.allHosts = hosts
+		m.hosts = m.sortHosts(m.applyVisibilityFilter(hosts))
+		m.pruneMarks()
+
+		// Reapply search filter if there is one active
+		if m.searchInput.Value() != "" {
+			m.filteredHosts = m.filterHosts(m.searchInput.Value())
+		} else {
+			m.filteredHosts = m.hosts
+		}
+
+		m.updateTableRows()
+		m.viewMode = ViewList
+		m.bulkForm = nil
+		m.table.Focus()
+		if len(msg.errs) > 0 {
+			return m, m.showErrorCmd(fmt.Sprintf("%s failed on %d hosts: %v", msg.action, len(msg.errs), msg.errs[0]))
+		}
+		return m, nil
+
+	case bulkFormActionMsg:
+		marked := m.markedHosts()
+		m.viewMode = ViewList
+		m.bulkForm = nil
+		switch msg.action {
+		case bulkPing:
+			m.table.Focus()
+			cmd := m.startPingCmd(marked)
+			return m, cmd
+		case bulkBroadcast:
+			broadcast, err := NewBroadcast(marked, m.configFile, m.styles, m.width, m.height)
+			if err != nil {
+				m.table.Focus()
+				return m, m.showErrorCmd(err.Error())
+			}
+			// Record the connections in history, warning in the view as the TUI owns the terminal
+			if m.historyManager != nil {
+				for _, host := range marked {
+					if err := m.historyManager.RecordConnection(host.Name); err != nil {
+						broadcast.warning = fmt.Sprintf("Warning: Could not record connection history: %v", err)
+					}
+				}
+			}
+			m.broadcast = broadcast
+			m.viewMode = ViewBroadcast
+			return m, broadcast.listenCmd()
+		case bulkDelete:
+			m.deleteMode = true
+			m.deleteHosts = marked
+			m.table.Blur()
+		}
+		return m, nil
+
+	case broadcastUpdateMsg:
+		// Keep listening for output while the broadcast session is open
+		if m.broadcast != nil {
+			return m, m.broadcast.listenCmd()
+		}
+		return m, nil
+
+	case broadcastCloseMsg:
+		// Close the sessions and return to list view
+		if m.broadcast != nil {
+			m.broadcast.Close()
+		}
+		m.viewMode = ViewList
+		m.broadcast = nil
+		m.table.Focus()
+		return m, nil
+
+	case sftpConnectedMsg:
+		if m.sftpBrowser == nil {
+			// The browser was closed while connecting
+			if msg.session != nil {
+				msg.session.Close()
+			}
+			return m, nil
+		}
+		m.sftpBrowser, cmd = m.sftpBrowser.Update(msg)
+		return m, cmd
+
+	case sftpOpDoneMsg, sftpEditReadyMsg, sftpEditedMsg:
+		if m.sftpBrowser != nil {
+			m.sftpBrowser, cmd = m.sftpBrowser.Update(msg)
+		}
+		return m, cmd
+
+	case sftpBrowserCloseMsg:
+		// Close the SFTP session and return to list view
+		if m.sftpBrowser != nil {
+			m.sftpBrowser.Close()
+		}
+		m.viewMode = ViewList
+		m.sftpBrowser = nil
+		m.table.Focus()
+		return m, nil
+
+	case bulkRunDoneMsg:
+		if m.bulkForm != nil {
+			m.bulkForm, cmd = m.bulkForm.Update(msg)
+		}
+		return m, cmd
+
+	case bulkFormCancelMsg:
+		// Cancel: return to list view
+		m.viewMode = ViewList
+		m.bulkForm = nil
+		m.table.Focus()
+		return m, nil
+
 	case helpCloseMsg:
 		// Close help: return to list view
 		m.viewMode = ViewList
@@ -437,6 +764,41 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.fileSelectorForm = newForm
 				return m, cmd
 			}
+		case ViewBulk:
+			if m.bulkForm != nil {
+				var newForm *bulkFormModel
+				newForm, cmd = m.bulkForm.Update(msg)
+				m.bulkForm = newForm
+				return m, cmd
+			}
+		case ViewBroadcast:
+			if m.broadcast != nil {
+				var newBroadcast *broadcastModel
+				newBroadcast, cmd = m.broadcast.Update(msg)
+				m.broadcast = newBroadcast
+				return m, cmd
+			}
+		case ViewFiles:
+			if m.sftpBrowser != nil {
+				var newBrowser *sftpBrowserModel
+				newBrowser, cmd = m.sftpBrowser.Update(msg)
+				m.sftpBrowser = newBrowser
+				return m, cmd
+			}
+		case ViewTunnels:
+			if m.tunnelsPanel != nil {
+				var newPanel *tunnelsModel
+				newPanel, cmd = m.tunnelsPanel.Update(msg)
+				m.tunnelsPanel = newPanel
+				return m, cmd
+			}
+		case ViewSnippets:
+			if m.snippetsPicker != nil {
+				var newPicker *snippetsModel
+				newPicker, cmd = m.snippetsPicker.Update(msg)
+				m.snippetsPicker = newPicker
+				return m, cmd
+			}
 		case ViewList:
 			// Handle list view keys
 			return m.handleListViewKeys(msg)
@@ -456,9 +818,16 @@ func (m Model) handleListViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			// Exit delete mode
 			m.deleteMode = false
 			m.deleteHost = nil
+			m.deleteHosts = nil
 			m.table.Focus()
 			return m, nil
 		}
+		if key == "esc" && len(m.marked) > 0 && !m.searchMode {
+			// Esc clears the marks before quitting, ctrl+c always quits
+			m.clearMarks()
+			m.updateTableRows()
+			return m, nil
+		}
 		// Use configurable key bindings for quit
 		if m.appConfig != nil && m.appConfig.KeyBindings.ShouldQuitOnKey(key) {
 			return m, tea.Quit
@@ -501,6 +870,12 @@ func (m Model) handleListViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 	case "enter":
+		if m.searchMode && len(m.filteredHosts) == 0 {
+			// Connect right away to a target no host matches
+			if target, ok := m.adhocTarget(); ok {
+				return m, m.connectToTarget(target)
+			}
+		}
 		if m.searchMode {
 			// Validate search and return to table mode to allow commands
 			m.searchMode = false
@@ -508,6 +883,17 @@ func (m Model) handleListViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.searchInput.Blur()
 			m.table.Focus()
 			return m, nil
+		} else if m.deleteMode && len(m.deleteHosts) > 0 {
+			// Confirm deletion of the marked hosts, keeping on after a failure
+			var errs []error
+			for _, host := range sortForDeletion(m.deleteHosts) {
+				if err := config.DeleteSSHHostWithLine(host); err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", host.Name, err))
+				}
+			}
+			m.deleteMode = false
+			m.deleteHosts = nil
+			return m.Update(bulkFormSubmitMsg{action: bulkDelete, errs: errs})
 		} else if m.deleteMode {
 			// Confirm deletion
 			var err error
@@ -553,6 +939,10 @@ func (m Model) handleListViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.deleteHost = nil
 			m.table.Focus()
 			return m, nil
+		} else if m.adhocRowSelected() {
+			// Connect to the target typed in the search box
+			target, _ := m.adhocTarget()
+			return m, m.connectToTarget(target)
 		} else {
 			// Connect to the selected host
 			selected := m.table.SelectedRow()
@@ -576,13 +966,37 @@ func (m Model) handleListViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					sshCmd = exec.Command("ssh", hostName)
 				}
 
+				hooks, host := m.hooksFor(hostName)
+
+				// Record the session when the host is tagged for recording
+				if m.shouldRecord(hostName) {
+					settings := m.recordingSettings()
+					dir, err := recording.Dir(settings)
+					if err != nil {
+						return m, m.showErrorCmd(fmt.Sprintf("Could not create the recordings directory: %v", err))
+					}
+					session := tea.ExecCommand(recording.NewCommand(sshCmd, dir, hostName))
+					if !hooks.Empty() {
+						session = newHookedCommand(session, hooks, host)
+					}
+					return m, tea.Exec(session, func(err error) tea.Msg {
+						_, _ = recording.Prune(dir, settings)
+						return connectedMsg(err)
+					})
+				}
+
+				// Run the hooks of the host around the session
+				if !hooks.Empty() {
+					return m, tea.Exec(newHookedCommand(execCommand{sshCmd}, hooks, host), connectedMsg)
+				}
+
 				return m, tea.ExecProcess(sshCmd, func(err error) tea.Msg {
 					return tea.Quit()
 				})
 			}
 		}
 	case "e":
-		if !m.searchMode && !m.deleteMode {
+		if !m.searchMode && !m.deleteMode && !m.adhocRowSelected() {
 			// Edit the selected host
 			selected := m.table.SelectedRow()
 			if len(selected) > 0 {
@@ -598,7 +1012,19 @@ func (m Model) handleListViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		}
 	case "m":
-		if !m.searchMode && !m.deleteMode {
+		if !m.searchMode && !m.deleteMode && len(m.marked) > 0 {
+			// Move the marked hosts to another config file
+			m.bulkForm = NewBulkForm(m.markedHosts(), m.styles, m.width, m.height, m.configFile, m.bulkParallel())
+			cmd := m.bulkForm.startAction(bulkMove)
+			if m.bulkForm.state != bulkFormSelectingFile {
+				errText := m.bulkForm.err
+				m.bulkForm = nil
+				return m, m.showErrorCmd(errText)
+			}
+			m.viewMode = ViewBulk
+			return m, cmd
+		}
+		if !m.searchMode && !m.deleteMode && !m.adhocRowSelected() {
 			// Move the selected host to another config file
 			selected := m.table.SelectedRow()
 			if len(selected) > 0 {
@@ -619,7 +1045,7 @@ func (m Model) handleListViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			}
 		}
 	case "i":
-		if !m.searchMode && !m.deleteMode {
+		if !m.searchMode && !m.deleteMode && !m.adhocRowSelected() {
 			// Show info for the selected host
 			selected := m.table.SelectedRow()
 			if len(selected) > 0 {
@@ -629,6 +1055,12 @@ func (m Model) handleListViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 					// Handle error - could show in UI
 					return m, nil
 				}
+				infoForm.connectivity = m.formatConnectivity(hostName)
+				if scans, err := connectivity.LoadScans(); err == nil {
+					if scan, exists := scans[hostName]; exists {
+						infoForm.scan = &scan
+					}
+				}
 				m.infoForm = infoForm
 				m.viewMode = ViewInfo
 				return m, nil
@@ -673,6 +1105,13 @@ func (m Model) handleListViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			return m, textinput.Blink
 		}
 	case "d":
+		if !m.searchMode && !m.deleteMode && len(m.marked) > 0 {
+			// Delete the marked hosts
+			m.deleteMode = true
+			m.deleteHosts = m.markedHosts()
+			m.table.Blur()
+			return m, nil
+		}
 		if !m.searchMode && !m.deleteMode {
 			// Delete the selected host
 			cursor := m.table.Cursor()
@@ -688,11 +1127,83 @@ func (m Model) handleListViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 	case "p":
 		if !m.searchMode && !m.deleteMode {
-			// Ping all hosts
-			return m, m.startPingAllCmd()
+			// Ping the marked hosts, or all hosts when none is marked
+			var cmd tea.Cmd
+			if len(m.marked) > 0 {
+				cmd = m.startPingCmd(m.markedHosts())
+			} else {
+				cmd = m.startPingAllCmd()
+			}
+			return m, cmd
 		}
-	case "f":
+	case " ":
+		if !m.searchMode && !m.deleteMode {
+			// Mark or unmark the selected host and move to the next one
+			cursor := m.table.Cursor()
+			if cursor >= 0 && cursor < len(m.filteredHosts) {
+				m.toggleMark(m.filteredHosts[cursor].Name)
+				m.updateTableRows()
+				m.table.MoveDown(1)
+			}
+			return m, nil
+		}
+	case "+":
+		if !m.searchMode && !m.deleteMode {
+			// Mark all hosts matching the search
+			m.markFiltered()
+			m.updateTableRows()
+			return m, nil
+		}
+	case "*":
+		if !m.searchMode && !m.deleteMode {
+			// Invert the marks of the hosts matching the search
+			m.invertFiltered()
+			m.updateTableRows()
+			return m, nil
+		}
+	case "-":
+		if !m.searchMode && !m.deleteMode {
+			// Unmark all hosts
+			m.clearMarks()
+			m.updateTableRows()
+			return m, nil
+		}
+	case "B":
+		if !m.searchMode && !m.deleteMode {
+			// Open the bulk actions for the marked hosts
+			marked := m.markedHosts()
+			if len(marked) == 0 {
+				return m, m.showErrorCmd("No hosts marked - press space to mark hosts")
+			}
+			m.bulkForm = NewBulkForm(marked, m.styles, m.width, m.height, m.configFile, m.bulkParallel())
+			m.viewMode = ViewBulk
+			return m, nil
+		}
+	case "M":
 		if !m.searchMode && !m.deleteMode {
+			// Toggle continuous monitoring
+			m.monitorMode = !m.monitorMode
+			m.monitorGen++
+			var cmds []tea.Cmd
+			if m.monitorMode {
+				if m.pingResults == nil {
+					cmds = append(cmds, m.startPingAllCmd())
+				}
+				cmds = append(cmds, m.monitorTickCmd())
+			}
+			m.updateTableRows()
+			return m, tea.Batch(cmds...)
+		}
+	case "A":
+		if !m.searchMode && !m.deleteMode && m.pingManager != nil {
+			// Toggle the authentication probe and check all hosts again
+			m.pingManager.SetAuthProbe(!m.pingManager.AuthProbeEnabled())
+			cmd := m.startPingAllCmd()
+			m.updateTableRows()
+			return m, cmd
+		}
+	case "f":
+		if !m.searchMode && !m.deleteMode && !m.adhocRowSelected() {
 			// Port forwarding for the selected host
 			selected := m.table.SelectedRow()
 			if len(selected) > 0 {
@@ -702,6 +1213,40 @@ func (m Model) handleListViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 				return m, textinput.Blink
 			}
 		}
+	case "F":
+		if !m.searchMode && !m.deleteMode && !m.adhocRowSelected() {
+			// Browse the files of the selected host over SFTP
+			selected := m.table.SelectedRow()
+			if len(selected) > 0 {
+				hostName := extractHostNameFromTableRow(selected[0]) // Extract hostname from first column
+				m.sftpBrowser = NewSFTPBrowser(hostName, m.styles, m.width, m.height, m.configFile)
+				m.viewMode = ViewFiles
+				return m, m.sftpBrowser.Init()
+			}
+		}
+	case "T":
+		if !m.searchMode && !m.deleteMode {
+			// Show the background tunnels
+			dir, err := tunnel.Dir()
+			if err != nil {
+				m.errorMessage = fmt.Sprintf("Could not open the tunnels directory: %v", err)
+				m.showingError = true
+				return m, nil
+			}
+			m.tunnelsPanel = NewTunnelsPanel(dir, m.styles, m.width, m.height)
+			m.viewMode = ViewTunnels
+			return m, m.tunnelsPanel.Init()
+		}
+	case "x":
+		if !m.searchMode && !m.deleteMode {
+			// Pick a snippet to run on the selected host
+			cursor := m.table.Cursor()
+			if cursor >= 0 && cursor < len(m.filteredHosts) {
+				m.snippetsPicker = NewSnippetsPicker(m.filteredHosts[cursor], m.configFile, m.styles, m.width, m.height)
+				m.viewMode = ViewSnippets
+				return m, nil
+			}
+		}
 	case "h":
 		if !m.searchMode && !m.deleteMode {
 			// Show help
@@ -787,3 +1332,42 @@ func (m Model) handleListViewKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	return m, cmd
 }
+
+// bulkParallel returns how many hosts a bulk command runs on at once
+func (m Model) bulkParallel() int {
+	if m.appConfig != nil && m.appConfig.Ping.Concurrency > 0 {
+		return m.appConfig.Ping.Concurrency
+	}
+	return config.GetDefaultPingSettings().Concurrency
+}
+
+// shouldRecord reports whether connections to a host are recorded
+func (m Model) shouldRecord(hostName string) bool {
+	if !recording.Supported {
+		return false
+	}
+	for _, host := range m.allHosts {
+		if host.Name == hostName {
+			return recording.Enabled(host.Tags)
+		}
+	}
+	return false
+}
+
+// recordingSettings returns where recordings are kept and for how long
+func (m Model) recordingSettings() config.RecordingSettings {
+	if m.appConfig != nil {
+		return m.appConfig.Recording
+	}
+	return config.GetDefaultAppConfig().Recording
+}
+
+// showErrorCmd shows an error message in the list view and clears it after a few seconds
+func (m *Model) showErrorCmd(text string) tea.Cmd {
+	m.errorMessage = text
+	m.showingError = true
+	return func() tea.Msg {
+		time.Sleep(3 * time.Second) // Show error for 3 seconds
+		return errorMsg("clear")
+	}
+}
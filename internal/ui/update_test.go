@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPingSweepsBackToBack(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv("APPDATA", t.TempDir())
+
+	m := createTestModel()
+	m.pingManager = connectivity.NewPingManager(time.Second, "")
+
+	// Port 1 on localhost is refused immediately, which keeps the test fast
+	hosts := []config.SSHHost{
+		{Name: "server1", Hostname: "127.0.0.1", Port: "1"},
+		{Name: "server2", Hostname: "127.0.0.1", Port: "1"},
+	}
+
+	m.startPingCmd(hosts)
+	first := m.pingResults
+	cmd := m.startPingCmd(hosts)
+	second := m.pingResults
+	if first == second {
+		t.Fatal("Expected the second sweep to get its own results")
+	}
+
+	// Every message of the replaced sweep, up to its end, is dropped
+	for {
+		msg := listenForPingResultsCmd(first)()
+		updated, next := m.Update(msg)
+		m = updated.(Model)
+		if m.pingResults != second || m.pingCancel == nil {
+			t.Fatalf("Expected %T of the first sweep to leave the second one running", msg)
+		}
+		if next != nil {
+			t.Fatalf("Expected no listener for %T of the first sweep", msg)
+		}
+		if _, done := msg.(pingDoneMsg); done {
+			break
+		}
+	}
+
+	// The second sweep is listened to until it is complete
+	results := 0
+	for cmd != nil {
+		msg := cmd()
+		if _, ok := msg.(pingResultMsg); ok {
+			results++
+		}
+		var updated tea.Model
+		updated, cmd = m.Update(msg)
+		m = updated.(Model)
+	}
+	if results != len(hosts) {
+		t.Errorf("Expected %d results from the second sweep, got %d", len(hosts), results)
+	}
+	if m.pingResults != nil {
+		t.Error("Expected no sweep running once the second one is complete")
+	}
+}
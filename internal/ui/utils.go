@@ -2,9 +2,13 @@ package ui
 
 import (
 	"fmt"
-	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+	"regexp"
 	"strings"
 	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+
+	"github.com/charmbracelet/lipgloss"
 )
 
 // formatTimeAgo formats a time into a readable "X time ago" string
@@ -71,13 +75,54 @@ func formatConfigFile(filePath string) string {
 	return filePath
 }
 
-// getPingStatusIndicator returns a colored circle indicator based on ping status
+// Placeholders of last known statuses in table cells, turned into colored
+// dots by colorStatusIndicators once the table is rendered. The table counts
+// escape sequences as text when fitting cells, so cells cannot carry colors.
+const (
+	cachedOnlineIndicator  = "\ue000"
+	cachedOfflineIndicator = "\ue001"
+	staleOnlineIndicator   = "\ue002"
+	staleOfflineIndicator  = "\ue003"
+)
+
+// cachedIndicatorColors are the colors of last known statuses, muted once
+// they are older than the cache TTL
+var cachedIndicatorColors = map[string]lipgloss.Color{
+	cachedOnlineIndicator:  lipgloss.Color("42"),
+	cachedOfflineIndicator: lipgloss.Color("196"),
+	staleOnlineIndicator:   lipgloss.Color("22"),
+	staleOfflineIndicator:  lipgloss.Color("88"),
+}
+
+// sgrPattern matches the escape sequences setting text styles
+var sgrPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// getPingStatusIndicator returns a colored circle indicator based on ping status.
+// Hosts not checked in this session show their last known status as a dot
+// of the status color, muted when it is older than the cache TTL.
 func (m *Model) getPingStatusIndicator(hostName string) string {
 	if m.pingManager == nil {
 		return "⚫" // Gray circle for unknown
 	}
 
 	status := m.pingManager.GetStatus(hostName)
+	if status == connectivity.StatusUnknown {
+		if lastKnown, exists := m.pingManager.GetLastKnown(hostName); exists {
+			// The dot is one column wide, the circles two
+			stale := lastKnown.Age() >= m.cacheTTL()
+			switch {
+			case lastKnown.Status == connectivity.StatusOnline && stale:
+				return staleOnlineIndicator + " "
+			case lastKnown.Status == connectivity.StatusOnline:
+				return cachedOnlineIndicator + " "
+			case lastKnown.Status == connectivity.StatusOffline && stale:
+				return staleOfflineIndicator + " "
+			case lastKnown.Status == connectivity.StatusOffline:
+				return cachedOfflineIndicator + " "
+			}
+		}
+	}
+
 	switch status {
 	case connectivity.StatusOnline:
 		return "🟢" // Green circle for online
@@ -90,12 +135,109 @@ func (m *Model) getPingStatusIndicator(hostName string) string {
 	}
 }
 
+// colorStatusIndicators replaces the placeholders of last known statuses in
+// the rendered table with colored dots, restoring the style of the line
+// (such as the selected row's) after each dot
+func colorStatusIndicators(view string) string {
+	if !strings.ContainsAny(view, cachedOnlineIndicator+cachedOfflineIndicator+staleOnlineIndicator+staleOfflineIndicator) {
+		return view
+	}
+
+	lines := strings.Split(view, "\n")
+	for i, line := range lines {
+		for placeholder, color := range cachedIndicatorColors {
+			for {
+				at := strings.Index(line, placeholder)
+				if at < 0 {
+					break
+				}
+				active := strings.Join(sgrPattern.FindAllString(line[:at], -1), "")
+				dot := lipgloss.NewStyle().Foreground(color).Render("●")
+				if dot != "●" {
+					dot += active
+				}
+				line = line[:at] + dot + line[at+len(placeholder):]
+			}
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatConnectivity describes the latest check of a host and, when it didn't
+// complete a full SSH session, the reason why
+func (m *Model) formatConnectivity(hostName string) string {
+	if m.pingManager == nil {
+		return ""
+	}
+
+	var status connectivity.PingStatus
+	var reason connectivity.FailureReason
+	var durationMs int64
+	var checked, auth string
+	if result, exists := m.pingManager.GetResult(hostName); exists &&
+		(result.Status == connectivity.StatusOnline || result.Status == connectivity.StatusOffline) {
+		status, reason, durationMs = result.Status, result.Reason, result.Duration.Milliseconds()
+		switch result.Auth {
+		case connectivity.AuthOK:
+			auth = ", key accepted"
+			if result.AuthKey != "" {
+				auth += ": " + result.AuthKey
+			}
+		case connectivity.AuthDenied:
+			auth = ", no key accepted"
+		}
+	} else if lastKnown, exists := m.pingManager.GetLastKnown(hostName); exists {
+		status, reason, durationMs = lastKnown.Status, lastKnown.Reason, lastKnown.DurationMs
+		checked = ", checked " + formatTimeAgo(lastKnown.CheckedAt)
+	} else {
+		return ""
+	}
+
+	text := fmt.Sprintf("%s (%dms%s)", status, durationMs, checked)
+	if reason != connectivity.ReasonNone {
+		text += fmt.Sprintf(" - %s [%s]", reason.Description(), reason)
+	}
+	return text + auth
+}
+
+// sparklineLevels are the block characters used to draw latency sparklines
+var sparklineLevels = []rune("▁▂▃▄▅▆▇█")
+
+// renderSparkline draws the latency of successive checks, scaled to the slowest one.
+// Offline checks are drawn as a dot.
+func renderSparkline(samples []connectivity.PingSample) string {
+	var maxDuration time.Duration
+	for _, sample := range samples {
+		if sample.Status == connectivity.StatusOnline && sample.Duration > maxDuration {
+			maxDuration = sample.Duration
+		}
+	}
+
+	var b strings.Builder
+	for _, sample := range samples {
+		if sample.Status != connectivity.StatusOnline {
+			b.WriteRune('·')
+			continue
+		}
+		level := 0
+		if maxDuration > 0 {
+			level = int(sample.Duration * time.Duration(len(sparklineLevels)-1) / maxDuration)
+		}
+		b.WriteRune(sparklineLevels[level])
+	}
+	return b.String()
+}
+
 // extractHostNameFromTableRow extracts the host name from the first column,
 // removing the ping status indicator
 func extractHostNameFromTableRow(firstColumn string) string {
-	// The first column format is: "🟢 hostname" or "⚫ hostname" etc.
+	// The first column format is: "🟢 hostname" or "⚫ hostname" etc., preceded
+	// by the marked indicator when hosts are marked and followed by the
+	// multiplexed indicator when a master is running.
 	// We need to remove the emoji and space to get just the hostname
-	parts := strings.Fields(firstColumn)
+	firstColumn = strings.TrimSuffix(firstColumn, " "+multiplexedIndicator)
+	parts := strings.Fields(strings.TrimPrefix(firstColumn, markedIndicator+" "))
 	if len(parts) >= 2 {
 		// Return everything after the first part (the emoji)
 		return strings.Join(parts[1:], " ")
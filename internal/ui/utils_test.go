@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gu1llaum-3/sshm/internal/config"
+	"github.com/Gu1llaum-3/sshm/internal/connectivity"
+)
+
+func TestPingStatusIndicatorLastKnown(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", tempDir)
+	t.Setenv("APPDATA", tempDir)
+
+	configDir, err := config.GetSSHMConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache := `{"hosts": {
+  "fresh-up": {"status": "online", "checked_at": "` + time.Now().Format(time.RFC3339) + `"},
+  "fresh-down": {"status": "offline", "checked_at": "` + time.Now().Format(time.RFC3339) + `"},
+  "stale-up": {"status": "online", "checked_at": "2020-01-01T00:00:00Z"},
+  "stale-down": {"status": "offline", "checked_at": "2020-01-01T00:00:00Z"}
+}}`
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configDir, "ping_cache.json"), []byte(cache), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := createTestModel()
+	m.pingManager = connectivity.NewPingManager(time.Second, "")
+	if err := m.pingManager.EnableCache(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"fresh-up":   cachedOnlineIndicator + " ",
+		"fresh-down": cachedOfflineIndicator + " ",
+		"stale-up":   staleOnlineIndicator + " ",
+		"stale-down": staleOfflineIndicator + " ",
+		"never":      "⚫",
+	}
+	for host, want := range tests {
+		if got := m.getPingStatusIndicator(host); got != want {
+			t.Errorf("getPingStatusIndicator(%s) = %q, want %q", host, got, want)
+		}
+	}
+
+	// Last known statuses are drawn as dots once the table is rendered
+	m.hosts = []config.SSHHost{{Name: "fresh-up"}, {Name: "stale-down"}}
+	m.filteredHosts = m.hosts
+	m.updateTableRows()
+	if name := extractHostNameFromTableRow(m.table.Rows()[0][0]); name != "fresh-up" {
+		t.Errorf("Expected the host name to be read back from the row, got %q", name)
+	}
+	view := colorStatusIndicators(m.table.View())
+	if strings.Count(view, "●") != 2 || strings.ContainsAny(view, cachedOnlineIndicator+staleOfflineIndicator) {
+		t.Errorf("Expected the placeholders to be replaced by dots:\n%s", view)
+	}
+}
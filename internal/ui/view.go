@@ -43,6 +43,26 @@ func (m Model) View() string {
 		if m.fileSelectorForm != nil {
 			return m.fileSelectorForm.View()
 		}
+	case ViewBulk:
+		if m.bulkForm != nil {
+			return m.bulkForm.View()
+		}
+	case ViewBroadcast:
+		if m.broadcast != nil {
+			return m.broadcast.View()
+		}
+	case ViewFiles:
+		if m.sftpBrowser != nil {
+			return m.sftpBrowser.View()
+		}
+	case ViewTunnels:
+		if m.tunnelsPanel != nil {
+			return m.tunnelsPanel.View()
+		}
+	case ViewSnippets:
+		if m.snippetsPicker != nil {
+			return m.snippetsPicker.View()
+		}
 	case ViewList:
 		return m.renderListView()
 	}
@@ -94,6 +114,22 @@ func (m Model) renderListView() string {
 		components = append(components, hiddenBannerStyle.Render("  [showing hidden hosts — press H to hide]"))
 	}
 
+	// Add indicator when monitor mode is enabled
+	if m.monitorMode {
+		monitorBannerStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("10")).
+			Bold(true)
+		components = append(components, monitorBannerStyle.Render(fmt.Sprintf("  [monitoring every %s — Δ: status changed — press M to stop]", m.monitorInterval())))
+	}
+
+	// Add indicator when hosts are marked for bulk actions
+	if len(m.marked) > 0 {
+		markedBannerStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color("14")).
+			Bold(true)
+		components = append(components, markedBannerStyle.Render(fmt.Sprintf("  [%d marked — B: bulk actions • d/m/p: delete/move/ping marked • -: clear]", len(m.markedHosts()))))
+	}
+
 	// Add the search bar with the appropriate style based on focus
 	searchPrompt := "Search (/ to focus): "
 	if m.searchMode {
@@ -105,10 +141,10 @@ func (m Model) renderListView() string {
 	// Add the table with the appropriate style based on focus
 	if m.searchMode {
 		// The table is not focused, use the unfocused style
-		components = append(components, m.styles.TableUnfocused.Render(m.table.View()))
+		components = append(components, m.styles.TableUnfocused.Render(colorStatusIndicators(m.table.View())))
 	} else {
 		// The table is focused, use the focused style with the primary color
-		components = append(components, m.styles.TableFocused.Render(m.table.View()))
+		components = append(components, m.styles.TableFocused.Render(colorStatusIndicators(m.table.View())))
 	}
 
 	// Add the help text
@@ -157,6 +193,10 @@ func (m Model) renderDeleteConfirmation() string {
 		hostName = m.deleteHost.Name
 	}
 	question := fmt.Sprintf("Are you sure you want to delete host '%s'?", hostName)
+	if len(m.deleteHosts) > 0 {
+		title = "DELETE SSH HOSTS"
+		question = fmt.Sprintf("Are you sure you want to delete %d marked hosts?", len(m.deleteHosts))
+	}
 	action := "This action cannot be undone."
 	help := "Enter: confirm • Esc: cancel"
 